@@ -1,21 +1,145 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/joao-fontenele/go-url-shortener/pkg/api"
 	"github.com/joao-fontenele/go-url-shortener/pkg/configger"
 	"github.com/joao-fontenele/go-url-shortener/pkg/logger"
+	"github.com/joao-fontenele/go-url-shortener/pkg/postgres"
 	"github.com/valyala/fasthttp"
 	"go.uber.org/zap"
 )
 
+const migrateUsage = "usage: server migrate up | down [steps] | status"
+
 func main() {
-	router := api.New()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(os.Args[2:])
+		return
+	}
+
+	router, closeAPI := api.New()
 
 	logger := logger.Get()
 
+	timeout := time.Duration(configger.Get().ShutdownTimeoutSeconds) * time.Second
+	server := &fasthttp.Server{
+		Handler: router.Handler,
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	errs := make(chan error, 1)
 	port := fmt.Sprintf(":%s", configger.Get().Port)
-	logger.Sugar().Infof("listening on port %s", port)
-	logger.Fatal("error", zap.Error(fasthttp.ListenAndServe(port, router.Handler)))
+	go func() {
+		logger.Sugar().Infof("listening on port %s", port)
+		errs <- server.ListenAndServe(port)
+	}()
+
+	select {
+	case err := <-errs:
+		closeAPI()
+		logger.Fatal("error", zap.Error(err))
+	case sig := <-signals:
+		logger.Info("Shutting down", zap.String("signal", sig.String()), zap.Duration("timeout", timeout))
+	}
+
+	shutdown(server, timeout)
+	closeAPI()
+}
+
+// shutdown stops accepting connections and waits for in-flight requests, for
+// at most timeout
+func shutdown(server *fasthttp.Server, timeout time.Duration) {
+	logger := logger.Get()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Shutdown()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			logger.Error("Failed to shutdown server", zap.Error(err))
+		}
+	case <-time.After(timeout):
+		logger.Warn("Shutdown timed out, dropping in-flight requests")
+	}
+}
+
+// migrate applies or reverts database migrations, according to args
+func migrate(args []string) {
+	if len(args) == 0 {
+		log.Fatal(migrateUsage)
+	}
+
+	err := configger.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configs: %v", err)
+	}
+
+	closeDB, err := postgres.Connect()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer closeDB()
+
+	migrator, err := postgres.NewMigrator(postgres.GetConnection())
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("Failed to migrate: %v", err)
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				log.Fatal(migrateUsage)
+			}
+		}
+
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("Failed to revert migrations: %v", err)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("Failed to get migrations status: %v", err)
+		}
+
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+	default:
+		log.Fatal(migrateUsage)
+	}
 }
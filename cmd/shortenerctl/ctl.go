@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+var errUsage = errors.New("invalid command usage")
+
+// ctl runs admin commands against the link service
+type ctl struct {
+	links  shortener.LinkService
+	clicks shortener.ClickDao
+	cache  shortener.LinkDao
+	auth   shortener.AuthService
+	out    io.Writer
+	format string
+	// invalidator evicts purged links from the memory cache of the servers
+	invalidator shortener.LinkInvalidator
+}
+
+// linkDetails is the output of inspecting a single link
+type linkDetails struct {
+	shortener.Link
+	TotalClicks int `json:"totalClicks"`
+}
+
+func (c *ctl) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "create":
+		return c.create(ctx, args[1:])
+	case "get":
+		return c.get(ctx, args[1:])
+	case "list":
+		return c.list(ctx, args[1:])
+	case "update":
+		return c.update(ctx, args[1:])
+	case "delete":
+		return c.delete(ctx, args[1:])
+	case "purge":
+		return c.purge(ctx, args[1:])
+	case "keys":
+		return c.keys(ctx, args[1:])
+	}
+
+	return errUsage
+}
+
+// newFlagSet creates a flag set that doesn't print to stderr by itself
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	return fs
+}
+
+// linkFlags registers the flags for changing link attributes
+func linkFlags(fs *flag.FlagSet, l *shortener.Link) func() error {
+	fs.StringVar(&l.URL, "url", "", "destination url")
+	fs.IntVar(&l.MaxClicks, "max-clicks", 0, "amount of clicks after which the link expires")
+	expiresAt := fs.String("expires-at", "", "date after which the link expires, in RFC3339")
+	fs.Func("tags", "comma separated tags, empty removes them", func(s string) error {
+		l.Tags = shortener.SplitTags(s)
+		return nil
+	})
+	fs.Func("redirect-type", "status code of redirects: 301, 302, 307 or 308", func(s string) error {
+		n, err := strconv.Atoi(s)
+		l.RedirectType = shortener.RedirectType(n)
+		return err
+	})
+
+	// parses flags that aren't natively supported, after fs.Parse
+	return func() error {
+		if *expiresAt == "" {
+			return nil
+		}
+
+		t, err := time.Parse(time.RFC3339, *expiresAt)
+		if err != nil {
+			return fmt.Errorf("invalid -expires-at: %w", err)
+		}
+		l.ExpiresAt = &t
+		return nil
+	}
+}
+
+// withOwner scopes ctx to owner, when it's given
+func withOwner(ctx context.Context, owner string) context.Context {
+	if owner == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, shortener.OwnerKey, owner)
+}
+
+// slugArg parses commands in the form: command SLUG [flags]
+func slugArg(fs *flag.FlagSet, args []string) (string, error) {
+	if len(args) == 0 || args[0] == "" || args[0][0] == '-' {
+		return "", errUsage
+	}
+
+	if err := fs.Parse(args[1:]); err != nil || fs.NArg() > 0 {
+		return "", errUsage
+	}
+
+	return args[0], nil
+}
+
+func (c *ctl) create(ctx context.Context, args []string) error {
+	l := &shortener.Link{}
+	fs := newFlagSet("create")
+	fs.StringVar(&l.Slug, "slug", "", "custom slug")
+	owner := fs.String("owner", "", "owner of the new link")
+	parseLinkFlags := linkFlags(fs, l)
+
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 || l.URL == "" {
+		return errUsage
+	}
+
+	if err := parseLinkFlags(); err != nil {
+		return err
+	}
+
+	created, err := c.links.Create(withOwner(ctx, *owner), l)
+	if err != nil {
+		return err
+	}
+
+	return c.printLinks(*created)
+}
+
+func (c *ctl) get(ctx context.Context, args []string) error {
+	slug, err := slugArg(newFlagSet("get"), args)
+	if err != nil {
+		return err
+	}
+
+	l, err := c.links.Get(ctx, slug)
+	if err != nil {
+		return err
+	}
+
+	stats, err := c.clicks.Stats(ctx, slug)
+	if err != nil {
+		return err
+	}
+
+	details := linkDetails{Link: *l, TotalClicks: stats.TotalClicks}
+	if c.format == "json" {
+		return c.printJSON(details)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Slug:\t%s\n", details.Slug)
+	fmt.Fprintf(w, "URL:\t%s\n", details.URL)
+	fmt.Fprintf(w, "Owner:\t%s\n", details.Owner)
+	fmt.Fprintf(w, "Created at:\t%s\n", details.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(w, "Expires at:\t%s\n", formatExpiresAt(details.ExpiresAt))
+	fmt.Fprintf(w, "Max clicks:\t%s\n", formatMaxClicks(details.MaxClicks))
+	fmt.Fprintf(w, "Redirect type:\t%s\n", formatRedirectType(details.RedirectType))
+	fmt.Fprintf(w, "Total clicks:\t%d\n", details.TotalClicks)
+	return w.Flush()
+}
+
+func (c *ctl) list(ctx context.Context, args []string) error {
+	fs := newFlagSet("list")
+	limit := fs.Int("limit", 20, "amount of links to list")
+	skip := fs.Int("skip", 0, "skip this many links from the beginning")
+	cursor := fs.String("cursor", "", "list the links after this cursor, from a previous list")
+	opts := shortener.ListOptions{}
+	fs.StringVar(&opts.Owner, "owner", "", "only list links of this owner")
+	fs.StringVar(&opts.Host, "host", "", "only list links to this host")
+	fs.StringVar(&opts.URLContains, "url-contains", "", "only list links whose url contains this text")
+	fs.StringVar(&opts.Tag, "tag", "", "only list links with this tag")
+	sort := fs.String("sort", "", "order of links: createdAt, -createdAt, clicks or -clicks")
+
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 || *limit <= 0 || *skip < 0 {
+		return errUsage
+	}
+
+	opts.Limit = *limit
+	opts.Skip = *skip
+	opts.Sort = shortener.ListSort(*sort)
+	if *cursor != "" {
+		after, err := shortener.DecodeListCursor(*cursor)
+		if err != nil {
+			return err
+		}
+		opts.After = after
+	}
+
+	page, err := c.links.List(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	// json output is the page, so scripts can get the next cursor too
+	if c.format == "json" {
+		return c.printJSON(page)
+	}
+
+	err = c.printLinks(page.Items...)
+	if err != nil {
+		return err
+	}
+
+	if page.NextCursor != "" {
+		fmt.Fprintf(c.out, "\nnext page: list -cursor %s\n", page.NextCursor)
+	}
+	return nil
+}
+
+func (c *ctl) update(ctx context.Context, args []string) error {
+	l := &shortener.Link{}
+	fs := newFlagSet("update")
+	parseLinkFlags := linkFlags(fs, l)
+
+	slug, err := slugArg(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if err := parseLinkFlags(); err != nil {
+		return err
+	}
+
+	u := &shortener.LinkUpdate{
+		Slug:         slug,
+		URL:          l.URL,
+		ExpiresAt:    l.ExpiresAt,
+		Tags:         l.Tags,
+		RedirectType: l.RedirectType,
+	}
+	// flags given explicitly are changed even when empty, which removes them
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "max-clicks":
+			u.MaxClicks = &l.MaxClicks
+		case "expires-at":
+			u.ClearExpiresAt = l.ExpiresAt == nil
+		}
+	})
+
+	updated, err := c.links.Update(ctx, u)
+	if err != nil {
+		return err
+	}
+
+	return c.printLinks(*updated)
+}
+
+func (c *ctl) delete(ctx context.Context, args []string) error {
+	slug, err := slugArg(newFlagSet("delete"), args)
+	if err != nil {
+		return err
+	}
+
+	err = c.links.Delete(ctx, slug)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "deleted %s\n", slug)
+	return nil
+}
+
+func (c *ctl) purge(ctx context.Context, args []string) error {
+	slug, err := slugArg(newFlagSet("purge"), args)
+	if err != nil {
+		return err
+	}
+
+	err = c.cache.Delete(ctx, slug)
+	if err != nil {
+		return err
+	}
+
+	err = c.invalidator.Invalidate(ctx, slug)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "purged %s from cache\n", slug)
+	return nil
+}
+
+func (c *ctl) keys(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "create" {
+		return errUsage
+	}
+
+	fs := newFlagSet("keys create")
+	owner := fs.String("owner", "", "owner of the links created with the key")
+	name := fs.String("name", "", "description of the key")
+
+	if err := fs.Parse(args[1:]); err != nil || fs.NArg() > 0 || *owner == "" {
+		return errUsage
+	}
+
+	key, k, err := c.auth.CreateKey(ctx, *owner, *name)
+	if err != nil {
+		return err
+	}
+
+	if c.format == "json" {
+		return c.printJSON(struct {
+			Key string `json:"key"`
+			*shortener.APIKey
+		}{key, k})
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Key:\t%s\n", key)
+	fmt.Fprintf(w, "Owner:\t%s\n", k.Owner)
+	fmt.Fprintf(w, "Name:\t%s\n", k.Name)
+	fmt.Fprintf(w, "Created at:\t%s\n", k.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintln(w, "\nThe key can't be recovered later, store it somewhere safe")
+	return w.Flush()
+}
+
+func (c *ctl) printJSON(v interface{}) error {
+	enc := json.NewEncoder(c.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (c *ctl) printLinks(links ...shortener.Link) error {
+	if c.format == "json" {
+		return c.printJSON(links)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SLUG\tURL\tOWNER\tCREATED AT\tEXPIRES AT\tMAX CLICKS\tTAGS")
+	for _, l := range links {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			l.Slug,
+			l.URL,
+			l.Owner,
+			l.CreatedAt.Format(time.RFC3339),
+			formatExpiresAt(l.ExpiresAt),
+			formatMaxClicks(l.MaxClicks),
+			strings.Join(l.Tags, ","),
+		)
+	}
+	return w.Flush()
+}
+
+func formatExpiresAt(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func formatMaxClicks(n int) string {
+	if n == 0 {
+		return "unlimited"
+	}
+	return strconv.Itoa(n)
+}
+
+func formatRedirectType(t shortener.RedirectType) string {
+	if t == 0 {
+		return "default"
+	}
+	return strconv.Itoa(int(t))
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/mocks"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+func newTestCtl(format string) (*ctl, *mocks.FakeLinkDao, *bytes.Buffer) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	link := shortener.Link{Slug: "dummy", URL: "https://google.com", CreatedAt: now}
+
+	links := &mocks.FakeLinkService{
+		GetFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+			if slug != "dummy" {
+				return nil, shortener.ErrLinkNotFound
+			}
+			return &link, nil
+		},
+		CreateFn: func(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
+			created := *l
+			created.CreatedAt = now
+			created.Owner = shortener.OwnerFromContext(ctx)
+			return &created, nil
+		},
+		UpdateFn: func(ctx context.Context, u *shortener.LinkUpdate) (*shortener.Link, error) {
+			updated := link
+			if u.MaxClicks != nil {
+				updated.MaxClicks = *u.MaxClicks
+			}
+			if u.ClearExpiresAt {
+				updated.ExpiresAt = nil
+			}
+			return &updated, nil
+		},
+		ListFn: func(ctx context.Context, opts shortener.ListOptions) (*shortener.LinkPage, error) {
+			page := &shortener.LinkPage{Items: []shortener.Link{link}}
+			if opts.After == nil {
+				page.NextCursor = shortener.EncodeListCursor(shortener.ListCursor{Sort: shortener.SortCreatedAt, CreatedAt: now, Slug: "dummy"})
+			}
+			return page, nil
+		},
+		DeleteFn: func(ctx context.Context, slug string) error {
+			return nil
+		},
+	}
+	clicks := &mocks.FakeClickDao{
+		StatsFn: func(ctx context.Context, slug string) (*shortener.LinkStats, error) {
+			return &shortener.LinkStats{Slug: slug, TotalClicks: 42}, nil
+		},
+	}
+	cache := &mocks.FakeLinkDao{
+		DeleteFn: func(ctx context.Context, slug string) error {
+			return nil
+		},
+	}
+
+	invalidator := &mocks.FakeLinkInvalidator{
+		InvalidateFn: func(ctx context.Context, slugs ...string) error {
+			return nil
+		},
+	}
+
+	auth := &mocks.FakeAuthService{
+		CreateKeyFn: func(ctx context.Context, owner, name string) (string, *shortener.APIKey, error) {
+			return "s3cr3t", &shortener.APIKey{Owner: owner, Name: name, CreatedAt: now}, nil
+		},
+	}
+
+	out := &bytes.Buffer{}
+	c := &ctl{
+		links:       links,
+		clicks:      clicks,
+		cache:       cache,
+		invalidator: invalidator,
+		auth:        auth,
+		out:         out,
+		format:      format,
+	}
+	return c, cache, out
+}
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Format   string
+		Args     []string
+		Contains []string
+	}{
+		{
+			Name:     "CreateTable",
+			Format:   "table",
+			Args:     []string{"create", "-url", "https://example.com", "-slug", "mine"},
+			Contains: []string{"SLUG", "mine", "https://example.com", "never", "unlimited"},
+		},
+		{
+			Name:     "CreateJSON",
+			Format:   "json",
+			Args:     []string{"create", "-url", "https://example.com", "-expires-at", "2030-01-01T00:00:00Z"},
+			Contains: []string{`"url": "https://example.com"`, `"expiresAt": "2030-01-01T00:00:00Z"`},
+		},
+		{
+			Name:     "CreateWithOwner",
+			Format:   "json",
+			Args:     []string{"create", "-url", "https://example.com", "-owner", "alice"},
+			Contains: []string{`"owner": "alice"`},
+		},
+		{
+			Name:     "CreateWithTags",
+			Format:   "json",
+			Args:     []string{"create", "-url", "https://example.com", "-tags", "sale, print"},
+			Contains: []string{`"tags": [`, `"sale"`, `"print"`},
+		},
+		{
+			Name:     "CreateWithRedirectType",
+			Format:   "json",
+			Args:     []string{"create", "-url", "https://example.com", "-redirect-type", "307"},
+			Contains: []string{`"redirectType": 307`},
+		},
+		{
+			Name:     "CreateKey",
+			Format:   "table",
+			Args:     []string{"keys", "create", "-owner", "alice", "-name", "ci"},
+			Contains: []string{"Key:", "s3cr3t", "Owner:", "alice", "Name:", "ci"},
+		},
+		{
+			Name:     "CreateKeyJSON",
+			Format:   "json",
+			Args:     []string{"keys", "create", "-owner", "alice"},
+			Contains: []string{`"key": "s3cr3t"`, `"owner": "alice"`},
+		},
+		{
+			Name:     "Get",
+			Format:   "table",
+			Args:     []string{"get", "dummy"},
+			Contains: []string{"Slug:", "dummy", "Redirect type:", "default", "Total clicks:", "42"},
+		},
+		{
+			Name:     "GetJSON",
+			Format:   "json",
+			Args:     []string{"get", "dummy"},
+			Contains: []string{`"slug": "dummy"`, `"totalClicks": 42`},
+		},
+		{
+			Name:     "List",
+			Format:   "table",
+			Args:     []string{"list", "-limit", "5"},
+			Contains: []string{"dummy", "https://google.com", "2020-01-01T00:00:00Z", "next page: list -cursor"},
+		},
+		{
+			Name:     "ListJSON",
+			Format:   "json",
+			Args:     []string{"list", "-cursor", "Y3JlYXRlZEF0IDIwMjAtMDEtMDFUMDA6MDA6MDBaIGR1bW15"},
+			Contains: []string{`"items"`, `"slug": "dummy"`},
+		},
+		{
+			Name:     "Update",
+			Format:   "table",
+			Args:     []string{"update", "dummy", "-max-clicks", "10"},
+			Contains: []string{"dummy", "10"},
+		},
+		{
+			Name:     "Delete",
+			Format:   "table",
+			Args:     []string{"delete", "dummy"},
+			Contains: []string{"deleted dummy"},
+		},
+		{
+			Name:     "Purge",
+			Format:   "table",
+			Args:     []string{"purge", "dummy"},
+			Contains: []string{"purged dummy from cache"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			c, _, out := newTestCtl(tc.Format)
+
+			err := c.run(context.Background(), tc.Args)
+			if err != nil {
+				t.Fatalf("Unexpected error running command: %v", err)
+			}
+
+			for _, s := range tc.Contains {
+				if !strings.Contains(out.String(), s) {
+					t.Errorf("Expected output to contain %q, but got:\n%s", s, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestCommandsUsage(t *testing.T) {
+	tests := []struct {
+		Name string
+		Args []string
+	}{
+		{Name: "NoCommand", Args: []string{}},
+		{Name: "UnknownCommand", Args: []string{"foo"}},
+		{Name: "CreateWithoutURL", Args: []string{"create"}},
+		{Name: "GetWithoutSlug", Args: []string{"get"}},
+		{Name: "UpdateFlagBeforeSlug", Args: []string{"update", "-url", "https://example.com"}},
+		{Name: "InvalidLimit", Args: []string{"list", "-limit", "0"}},
+		{Name: "InvalidRedirectType", Args: []string{"create", "-url", "https://example.com", "-redirect-type", "temporary"}},
+		{Name: "KeysWithoutOwner", Args: []string{"keys", "create"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			c, _, _ := newTestCtl("table")
+
+			err := c.run(context.Background(), tc.Args)
+			if !errors.Is(err, errUsage) {
+				t.Errorf("Expected usage error, but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestCommandsErrors(t *testing.T) {
+	c, cache, _ := newTestCtl("table")
+
+	err := c.run(context.Background(), []string{"get", "other"})
+	if !errors.Is(err, shortener.ErrLinkNotFound) {
+		t.Errorf("Expected ErrLinkNotFound, but got: %v", err)
+	}
+
+	err = c.run(context.Background(), []string{"create", "-url", "https://example.com", "-expires-at", "tomorrow"})
+	if err == nil || errors.Is(err, errUsage) {
+		t.Errorf("Expected invalid date error, but got: %v", err)
+	}
+
+	cache.DeleteFn = func(ctx context.Context, slug string) error {
+		return shortener.ErrLinkNotFound
+	}
+	err = c.run(context.Background(), []string{"purge", "dummy"})
+	if !errors.Is(err, shortener.ErrLinkNotFound) {
+		t.Errorf("Expected ErrLinkNotFound, but got: %v", err)
+	}
+}
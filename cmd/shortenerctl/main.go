@@ -0,0 +1,94 @@
+// shortenerctl is a command line tool for managing links, meant for admins
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/api"
+	"github.com/joao-fontenele/go-url-shortener/pkg/configger"
+	"github.com/joao-fontenele/go-url-shortener/pkg/logger"
+	"github.com/joao-fontenele/go-url-shortener/pkg/postgres"
+	"github.com/joao-fontenele/go-url-shortener/pkg/redis"
+)
+
+const usage = `usage: shortenerctl [-o table|json] <command> [args]
+
+commands:
+  create -url URL [-slug SLUG] [-owner OWNER] [-expires-at RFC3339]
+         [-max-clicks N] [-tags A,B] [-redirect-type CODE]
+  get SLUG
+  list [-limit N] [-skip N | -cursor CURSOR] [-owner OWNER] [-host HOST]
+       [-url-contains TEXT] [-tag TAG] [-sort createdAt|-createdAt|clicks|-clicks]
+  update SLUG [-url URL] [-expires-at RFC3339] [-max-clicks N] [-tags A,B]
+         [-redirect-type CODE]    -expires-at "" or -max-clicks 0 removes them
+  delete SLUG
+  purge SLUG    removes a link from the caches only
+  keys create -owner OWNER [-name NAME]
+`
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	fs := flag.NewFlagSet("shortenerctl", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	format := fs.String("o", "table", "output format, table or json")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return 2
+	}
+
+	if fs.NArg() == 0 || (*format != "table" && *format != "json") {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	err := configger.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configs: %v\n", err)
+		return 1
+	}
+
+	logger := logger.Get()
+
+	closeDB, err := postgres.Connect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer closeDB()
+
+	closeCache, err := redis.Connect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to redis: %v\n", err)
+		return 1
+	}
+	defer closeCache()
+
+	linkRepo := api.NewLinkRepository()
+	c := &ctl{
+		links:       api.NewLinkService(logger, linkRepo),
+		clicks:      postgres.NewClickDao(postgres.GetConnection()),
+		cache:       redis.NewLinkDao(redis.GetConnection()),
+		invalidator: redis.NewLinkInvalidator(redis.GetConnection()),
+		auth:        api.NewAuthService(),
+		out:         os.Stdout,
+		format:      *format,
+	}
+
+	err = c.run(context.Background(), fs.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		if err == errUsage {
+			fmt.Fprint(os.Stderr, usage)
+			return 2
+		}
+		return 1
+	}
+
+	return 0
+}
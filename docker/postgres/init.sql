@@ -1,26 +1,16 @@
-CREATE DATABASE shortdb;
-CREATE DATABASE shortdb_test;
+-- creates users and databases only, tables are created by the app migrations.
+-- See pkg/postgres/migrations
 CREATE USER gopher WITH ENCRYPTED PASSWORD 'short';
 
+CREATE DATABASE shortdb OWNER gopher;
+CREATE DATABASE shortdb_test OWNER gopher;
+
 GRANT ALL PRIVILEGES ON DATABASE shortdb TO gopher;
 GRANT ALL PRIVILEGES ON DATABASE shortdb_test TO gopher;
 
-\connect shortdb;
-
-CREATE TABLE links (
-  slug CHAR(5) PRIMARY KEY NOT NULL,
-  url VARCHAR(200) NOT NULL,
-  createdAt TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-);
-
-GRANT ALL PRIVILEGES ON ALL TABLES IN SCHEMA public TO gopher;
-
-\connect shortdb_test;
-
-CREATE TABLE links (
-  slug CHAR(5) PRIMARY KEY NOT NULL,
-  url VARCHAR(200) NOT NULL,
-  createdAt TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-);
+-- extensions need a superuser, so migrations only check they exist
+\connect shortdb
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
 
-GRANT ALL PRIVILEGES ON ALL TABLES IN SCHEMA public TO gopher;
+\connect shortdb_test
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
@@ -0,0 +1,124 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/logger"
+	"github.com/joao-fontenele/go-url-shortener/pkg/metrics"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// flushTimeout bounds how long a single batch insert may take
+const flushTimeout = 5 * time.Second
+
+// Writer buffers clicks in memory and persists them in batches, in background
+type Writer struct {
+	dao           shortener.ClickDao
+	clicks        chan shortener.Click
+	batchSize     int
+	flushInterval time.Duration
+
+	// mu guards closed, so clicks recorded after Close are dropped, instead of
+	// sent to the closed channel
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+var _ shortener.ClickRecorder = &Writer{}
+
+// NewWriter instantiates a Writer and starts its background flushing routine.
+// A batch is written whenever it reaches batchSize clicks or flushInterval elapses
+func NewWriter(dao shortener.ClickDao, bufferSize, batchSize int, flushInterval time.Duration) *Writer {
+	w := &Writer{
+		dao:           dao,
+		clicks:        make(chan shortener.Click, bufferSize),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		done:          make(chan struct{}),
+	}
+
+	go w.run()
+
+	return w
+}
+
+// Record enqueues a click to be written. It never blocks, if the buffer is full
+// the click is dropped, so a slow database can't slow down redirects. Clicks
+// recorded after Close are dropped too
+func (w *Writer) Record(c shortener.Click) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		metrics.AnalyticsClicksCounter.With(prometheus.Labels{"result": "dropped"}).Inc()
+		return
+	}
+
+	select {
+	case w.clicks <- c:
+	default:
+		metrics.AnalyticsClicksCounter.With(prometheus.Labels{"result": "dropped"}).Inc()
+	}
+}
+
+// Close stops accepting clicks and waits for the buffered ones to be written
+func (w *Writer) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.clicks)
+	}
+	w.mu.Unlock()
+
+	<-w.done
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]shortener.Click, 0, w.batchSize)
+	for {
+		select {
+		case c, ok := <-w.clicks:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+
+			batch = append(batch, c)
+			if len(batch) >= w.batchSize {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			w.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (w *Writer) flush(batch []shortener.Click) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	result := "written"
+	err := w.dao.InsertMany(ctx, batch)
+	if err != nil {
+		result = "error"
+		logger.Get().Error("Failed to write clicks", zap.Int("clicks", len(batch)), zap.Error(err))
+	}
+
+	metrics.AnalyticsClicksCounter.With(prometheus.Labels{"result": result}).Add(float64(len(batch)))
+}
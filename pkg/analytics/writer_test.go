@@ -0,0 +1,120 @@
+package analytics_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/analytics"
+	"github.com/joao-fontenele/go-url-shortener/pkg/mocks"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+// batchSpy records every batch written to a fake click dao
+type batchSpy struct {
+	mu      sync.Mutex
+	batches [][]shortener.Click
+}
+
+func (s *batchSpy) dao() *mocks.FakeClickDao {
+	return &mocks.FakeClickDao{
+		InsertManyFn: func(ctx context.Context, clicks []shortener.Click) error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			batch := make([]shortener.Click, len(clicks))
+			copy(batch, clicks)
+			s.batches = append(s.batches, batch)
+			return nil
+		},
+	}
+}
+
+func (s *batchSpy) sizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sizes := []int{}
+	for _, b := range s.batches {
+		sizes = append(sizes, len(b))
+	}
+	return sizes
+}
+
+func TestWriterFlushesFullBatches(t *testing.T) {
+	spy := &batchSpy{}
+	w := analytics.NewWriter(spy.dao(), 10, 2, time.Hour)
+
+	for i := 0; i < 5; i++ {
+		w.Record(shortener.Click{Slug: "aaaaa"})
+	}
+	w.Close()
+
+	got := spy.sizes()
+	if len(got) != 3 || got[0] != 2 || got[1] != 2 || got[2] != 1 {
+		t.Errorf("Expected batches of sizes [2 2 1], but got %v", got)
+	}
+}
+
+func TestWriterFlushesOnInterval(t *testing.T) {
+	spy := &batchSpy{}
+	w := analytics.NewWriter(spy.dao(), 10, 100, 10*time.Millisecond)
+	defer w.Close()
+
+	w.Record(shortener.Click{Slug: "aaaaa"})
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if len(spy.sizes()) == 1 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Errorf("Expected a batch to have been flushed by the interval, but got %v", spy.sizes())
+}
+
+func TestWriterDropsWhenFull(t *testing.T) {
+	block := make(chan struct{})
+	dao := &mocks.FakeClickDao{
+		InsertManyFn: func(ctx context.Context, clicks []shortener.Click) error {
+			<-block
+			return nil
+		},
+	}
+	w := analytics.NewWriter(dao, 1, 1, time.Hour)
+
+	done := make(chan struct{})
+	go func() {
+		// must never block, even though the dao is stuck
+		for i := 0; i < 100; i++ {
+			w.Record(shortener.Click{Slug: "aaaaa"})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Record blocked while the buffer was full")
+	}
+
+	close(block)
+	w.Close()
+}
+
+func TestWriterDropsAfterClose(t *testing.T) {
+	spy := &batchSpy{}
+	w := analytics.NewWriter(spy.dao(), 10, 100, time.Hour)
+
+	w.Record(shortener.Click{Slug: "aaaaa"})
+	w.Close()
+
+	// requests still in flight after a shutdown may record clicks
+	w.Record(shortener.Click{Slug: "aaaaa"})
+	w.Close()
+
+	got := spy.sizes()
+	if len(got) != 1 || got[0] != 1 {
+		t.Errorf("Expected only the click recorded before Close to be written, but got %v", got)
+	}
+}
@@ -1,14 +1,25 @@
 package api
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"net"
+	"net/url"
+	"time"
 
 	"github.com/fasthttp/router"
+	"github.com/joao-fontenele/go-url-shortener/pkg/analytics"
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/handler"
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/middleware"
 	myRouter "github.com/joao-fontenele/go-url-shortener/pkg/api/router"
 	"github.com/joao-fontenele/go-url-shortener/pkg/configger"
 	"github.com/joao-fontenele/go-url-shortener/pkg/logger"
+	"github.com/joao-fontenele/go-url-shortener/pkg/memory"
 	"github.com/joao-fontenele/go-url-shortener/pkg/metrics"
 	"github.com/joao-fontenele/go-url-shortener/pkg/postgres"
+	"github.com/joao-fontenele/go-url-shortener/pkg/qr"
+	"github.com/joao-fontenele/go-url-shortener/pkg/ratelimit"
 	"github.com/joao-fontenele/go-url-shortener/pkg/redis"
 	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
 	"go.uber.org/zap"
@@ -21,21 +32,51 @@ func loadConfs() {
 	}
 }
 
-func connectDB(logger *zap.Logger) {
-	_, err := postgres.Connect()
+func connectDB(logger *zap.Logger) func() {
+	closeDB, err := postgres.Connect()
 	if err != nil {
 		logger.Fatal("Failed to connect to database", zap.Error(err))
 	}
+	return closeDB
 }
 
-func connectCache(logger *zap.Logger) {
-	_, err := redis.Connect()
+func migrateDB(logger *zap.Logger) {
+	if !configger.Get().Database.MigrateOnStartup {
+		return
+	}
+
+	migrator, err := postgres.NewMigrator(postgres.GetConnection())
+	if err != nil {
+		logger.Fatal("Failed to load database migrations", zap.Error(err))
+	}
+
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		logger.Fatal("Failed to migrate database", zap.Error(err))
+	}
+
+	for _, m := range applied {
+		logger.Info("Applied database migration", zap.Int("version", m.Version), zap.String("name", m.Name))
+	}
+}
+
+func connectCache(logger *zap.Logger) func() error {
+	closeCache, err := redis.Connect()
 	if err != nil {
 		logger.Fatal("Failed to connect to redis", zap.Error(err))
 	}
+	return closeCache
+}
+
+// NewLinkRepository wires up a LinkRepository with previously connected
+// datastores. Also used by shortenerctl
+func NewLinkRepository() shortener.LinkRepository {
+	linkRepo, _ := newLinkRepository()
+	return linkRepo
 }
 
-func newLinkService() shortener.LinkService {
+// newLinkRepository also returns the memory cache tier, or nil if it's disabled
+func newLinkRepository() (shortener.LinkRepository, *memory.LinkDao) {
 	dbConn := postgres.GetConnection()
 	dbDao := postgres.NewLinkDao(dbConn)
 	dbWithMetricsDao := metrics.NewLinkDao(dbDao, "db")
@@ -44,28 +85,275 @@ func newLinkService() shortener.LinkService {
 	cacheDao := redis.NewLinkDao(cacheConn)
 	cacheWithMetricsDao := metrics.NewLinkDao(cacheDao, "cache")
 
-	linkRepo := shortener.NewLinkRepository(dbWithMetricsDao, cacheWithMetricsDao)
+	conf := configger.Get().Cache
+	if conf.MemorySize <= 0 {
+		return shortener.NewLinkRepository(dbWithMetricsDao, cacheWithMetricsDao), nil
+	}
+
+	memoryDao := memory.NewLinkDao(conf.MemorySize, time.Duration(conf.MemoryTTLSeconds)*time.Second)
+	memoryWithMetricsDao := metrics.NewLinkDao(memoryDao, "memory")
 
-	return shortener.NewLinkService(linkRepo)
+	// changes must reach the memory cache of every instance
+	linkRepo := shortener.NewInvalidatingLinkRepository(
+		shortener.NewLinkRepository(dbWithMetricsDao, memoryWithMetricsDao, cacheWithMetricsDao),
+		redis.NewLinkInvalidator(cacheConn),
+	)
+	return linkRepo, memoryDao
+}
+
+// subscribeInvalidations evicts links changed by other instances from the
+// memory cache
+func subscribeInvalidations(memoryDao *memory.LinkDao) *redis.Subscriber {
+	return redis.SubscribeInvalidations(
+		redis.GetConnection(),
+		func(slugs []string) {
+			for _, slug := range slugs {
+				memoryDao.Delete(context.Background(), slug)
+			}
+			metrics.LinkInvalidationsCounter.Add(float64(len(slugs)))
+		},
+		func() {
+			memoryDao.Purge()
+			metrics.LinkInvalidationPurgesCounter.Inc()
+		},
+	)
+}
+
+func newSlugGenerator(logger *zap.Logger) shortener.SlugGenerator {
+	generator := configger.Get().Links.SlugGenerator
+
+	switch generator {
+	case "random":
+		return shortener.NewRandomSlugGenerator()
+	case "crypto":
+		return shortener.NewCryptoSlugGenerator()
+	case "counter":
+		counter := postgres.NewSlugCounter(postgres.GetConnection())
+		return shortener.NewCounterSlugGenerator(counter)
+	}
+
+	logger.Fatal("Unknown slug generator", zap.String("slugGenerator", generator))
+	return nil
+}
+
+func newURLPolicy(logger *zap.Logger) shortener.URLPolicy {
+	conf := configger.Get().URLPolicy
+
+	var blocklist shortener.DomainList
+	if conf.BlocklistFile != "" {
+		fileBlocklist, err := shortener.NewFileBlocklist(
+			conf.BlocklistFile,
+			time.Duration(conf.BlocklistReloadSeconds)*time.Second,
+		)
+		if err != nil {
+			logger.Fatal("Failed to load url blocklist", zap.String("path", conf.BlocklistFile), zap.Error(err))
+		}
+		blocklist = fileBlocklist
+	}
+
+	return shortener.NewURLPolicy(shortener.URLPolicyOptions{
+		AllowedSchemes:  conf.AllowedSchemes,
+		AllowedDomains:  conf.AllowedDomains,
+		BlockedDomains:  conf.BlockedDomains,
+		Blocklist:       blocklist,
+		SelfDomains:     conf.SelfDomains,
+		AllowPrivateIPs: conf.AllowPrivateIPs,
+		Resolver:        net.DefaultResolver,
+	})
+}
+
+// NewLinkService wires up a LinkService, as configured. Also used by shortenerctl
+func NewLinkService(logger *zap.Logger, linkRepo shortener.LinkRepository) shortener.LinkService {
+	conf := configger.Get().Links
+
+	redirectType := shortener.RedirectType(conf.RedirectType)
+	if redirectType != 0 && !redirectType.Valid() {
+		logger.Fatal("Unknown redirect type", zap.Int("redirectType", conf.RedirectType))
+	}
+
+	return shortener.NewLinkService(
+		linkRepo,
+		newSlugGenerator(logger),
+		metrics.NewSlugMetrics(),
+		shortener.LinkServiceOptions{
+			ReservedSlugs: conf.ReservedSlugs,
+			URLPolicy:     newURLPolicy(logger),
+			Canonical: shortener.CanonicalOptions{
+				StripTrackingParams: conf.StripTrackingParams,
+				TrackingParams:      conf.TrackingParams,
+			},
+			DedupeURLs:   conf.DedupeURLs,
+			ListLimit:    conf.ListLimit,
+			ListMaxLimit: conf.ListMaxLimit,
+			RedirectType: redirectType,
+		},
+	)
+}
+
+// checkAnalyticsConfs tells why the analytics configs can't be used, if so
+func checkAnalyticsConfs() error {
+	conf := configger.Get().Analytics
+	if conf.FlushIntervalMs <= 0 {
+		return fmt.Errorf("analytics.flushIntervalMs must be positive, got %d", conf.FlushIntervalMs)
+	}
+	if conf.BatchSize <= 0 {
+		return fmt.Errorf("analytics.batchSize must be positive, got %d", conf.BatchSize)
+	}
+	if conf.BufferSize < 0 {
+		return fmt.Errorf("analytics.bufferSize can't be negative, got %d", conf.BufferSize)
+	}
+	return nil
+}
+
+// newClickService also returns the analytics writer, which must be closed to
+// write the buffered clicks
+func newClickService(logger *zap.Logger, linkRepo shortener.LinkRepository) (shortener.ClickService, *analytics.Writer) {
+	if err := checkAnalyticsConfs(); err != nil {
+		logger.Fatal("Invalid analytics configs", zap.Error(err))
+	}
+
+	conf := configger.Get().Analytics
+	clickDao := postgres.NewClickDao(postgres.GetConnection())
+	writer := analytics.NewWriter(
+		clickDao,
+		conf.BufferSize,
+		conf.BatchSize,
+		time.Duration(conf.FlushIntervalMs)*time.Millisecond,
+	)
+
+	return shortener.NewClickService(linkRepo, clickDao, writer), writer
+}
+
+// NewAuthService wires up an AuthService. Also used by shortenerctl
+func NewAuthService() shortener.AuthService {
+	return shortener.NewAuthService(postgres.NewAPIKeyDao(postgres.GetConnection()))
+}
+
+// newRateLimit sets up rate limiting in redis, falling back to memory when
+// redis fails. Returns nil if rate limiting is disabled
+func newRateLimit(logger *zap.Logger) *middleware.RateLimit {
+	conf := configger.Get().RateLimit
+	if !conf.Enabled {
+		return nil
+	}
+
+	limits, err := rateLimitLimits()
+	if err != nil {
+		logger.Fatal("Invalid rate limit configs", zap.Error(err))
+	}
+
+	limiter := ratelimit.NewFallbackLimiter(
+		redis.NewRateLimiter(redis.GetConnection()),
+		ratelimit.NewMemoryLimiter(),
+	)
+
+	return middleware.NewRateLimit(limiter, limits)
+}
+
+// rateLimitLimits converts the configured limits by route name, which must
+// allow some requests per window
+func rateLimitLimits() (map[string]ratelimit.Limit, error) {
+	limits := make(map[string]ratelimit.Limit)
+	for route, l := range configger.Get().RateLimit.Routes {
+		if l.Requests <= 0 || l.WindowSeconds <= 0 {
+			return nil, fmt.Errorf(
+				"rateLimit.routes.%s requests and windowSeconds must be positive, got %d and %d",
+				route, l.Requests, l.WindowSeconds,
+			)
+		}
+
+		limits[route] = ratelimit.Limit{
+			Requests: l.Requests,
+			Window:   time.Duration(l.WindowSeconds) * time.Second,
+		}
+	}
+	return limits, nil
+}
+
+// newTrustedProxies parses the proxies whose forwarded client IPs are trusted
+func newTrustedProxies(logger *zap.Logger) []*net.IPNet {
+	trustedProxies, err := middleware.ParseTrustedProxies(configger.Get().TrustedProxies)
+	if err != nil {
+		logger.Fatal("Invalid trusted proxies", zap.Error(err))
+	}
+	return trustedProxies
+}
+
+// newHealthChecks lists the dependencies checked by the readiness route. Redis
+// isn't critical, since links are found in the db when it's down
+func newHealthChecks() []handler.HealthCheck {
+	return []handler.HealthCheck{
+		{Name: "postgres", Critical: true, Ping: postgres.Ping},
+		{Name: "redis", Critical: false, Ping: redis.Ping},
+	}
+}
+
+// newQRCodes configures the QR codes of links, or returns nil if they're
+// disabled. Codes point at publicURL, since the request host can't be trusted
+func newQRCodes(logger *zap.Logger) *handler.QRCodes {
+	conf := configger.Get()
+	if !conf.QRCode.Enabled {
+		return nil
+	}
+
+	u, err := url.Parse(conf.PublicURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		logger.Fatal(
+			"QR codes require publicURL to be an absolute url, or qrCode.enabled to be false",
+			zap.String("publicURL", conf.PublicURL),
+		)
+	}
+
+	return &handler.QRCodes{
+		Renderer:  qr.NewRenderer(conf.QRCode.CacheSize),
+		PublicURL: conf.PublicURL,
+	}
 }
 
 func initMetrics() {
 	metrics.Init()
 }
 
-// New loads configs, sets up connection, and api routes
-func New() *router.Router {
+// New loads configs, sets up connections, and api routes. The returned func
+// writes buffered clicks and closes the connections, it must only be called
+// after the server stops handling requests
+func New() (*router.Router, func()) {
 	loadConfs()
 
 	logger := logger.Get()
 
-	connectDB(logger)
-	connectCache(logger)
+	closeDB := connectDB(logger)
+	migrateDB(logger)
+	closeCache := connectCache(logger)
 
 	initMetrics()
 
-	ls := newLinkService()
-	r := myRouter.New(ls)
+	linkRepo, memoryDao := newLinkRepository()
+	var subscriber *redis.Subscriber
+	if memoryDao != nil {
+		subscriber = subscribeInvalidations(memoryDao)
+	}
+
+	ls := NewLinkService(logger, linkRepo)
+	cs, writer := newClickService(logger, linkRepo)
+	as := NewAuthService()
+	r := myRouter.New(ls, cs, as, newTrustedProxies(logger), newRateLimit(logger), newHealthChecks(), newQRCodes(logger))
+
+	closeAll := func() {
+		// clicks are written to the db, so it must be closed last
+		writer.Close()
+
+		if subscriber != nil {
+			subscriber.Close()
+		}
+
+		if err := closeCache(); err != nil {
+			logger.Error("Failed to close redis connection", zap.Error(err))
+		}
+		closeDB()
+
+		logger.Sync()
+	}
 
-	return r
+	return r, closeAll
 }
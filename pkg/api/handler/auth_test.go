@@ -0,0 +1,160 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/router"
+	"github.com/joao-fontenele/go-url-shortener/pkg/mocks"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+// allowAllAuth authenticates any request as the owner alice
+func allowAllAuth() *mocks.FakeAuthService {
+	return &mocks.FakeAuthService{
+		AuthenticateFn: func(ctx context.Context, key string) (*shortener.APIKey, error) {
+			return &shortener.APIKey{Owner: "alice"}, nil
+		},
+	}
+}
+
+func TestAuth(t *testing.T) {
+	var listedOwner string
+	linkService := &mocks.FakeLinkService{
+		ListFn: func(ctx context.Context, opts shortener.ListOptions) (*shortener.LinkPage, error) {
+			listedOwner = shortener.OwnerFromContext(ctx)
+			return &shortener.LinkPage{Items: []shortener.Link{}}, nil
+		},
+		ResolveFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+			return &shortener.Link{Slug: slug, URL: "https://www.google.com", RedirectType: shortener.RedirectFound}, nil
+		},
+	}
+	clickService := &mocks.FakeClickService{
+		RecordFn: func(c shortener.Click) {},
+	}
+	authService := &mocks.FakeAuthService{
+		AuthenticateFn: func(ctx context.Context, key string) (*shortener.APIKey, error) {
+			if key == "valid" {
+				return &shortener.APIKey{Owner: "alice"}, nil
+			}
+
+			if key == "error" {
+				return nil, errors.New("UnexpectedError")
+			}
+
+			return nil, shortener.ErrUnauthorized
+		},
+	}
+	r := router.New(linkService, clickService, authService, nil, nil, nil, nil)
+
+	server := &fasthttp.Server{
+		Handler: r.Handler,
+	}
+	ln := fasthttputil.NewInmemoryListener()
+
+	go server.Serve(ln)
+	defer server.Shutdown()
+
+	c := http.Client{
+		// don't follow redirects, for the sake of this test case
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		// use custom in memory listener to connect to server
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return ln.Dial()
+			},
+		},
+	}
+	defer c.CloseIdleConnections()
+
+	tests := []struct {
+		Name           string
+		Path           string
+		Authorization  string
+		WantBody       []byte
+		WantStatusCode int
+		WantOwner      string
+	}{
+		{
+			Name:           "ValidKey",
+			Path:           "/links?limit=10&skip=0",
+			Authorization:  "Bearer valid",
+			WantBody:       []byte(`{"items":[]}`),
+			WantStatusCode: http.StatusOK,
+			WantOwner:      "alice",
+		},
+		{
+			Name:           "MissingKey",
+			Path:           "/links?limit=10&skip=0",
+			WantBody:       []byte(`{"message":"API key is missing or not valid","statusCode":401}`),
+			WantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:           "InvalidKey",
+			Path:           "/links?limit=10&skip=0",
+			Authorization:  "Bearer invalid",
+			WantBody:       []byte(`{"message":"API key is missing or not valid","statusCode":401}`),
+			WantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:           "ServerErr",
+			Path:           "/links?limit=10&skip=0",
+			Authorization:  "Bearer error",
+			WantBody:       []byte(`{"message":"Error authenticating: UnexpectedError","statusCode":500}`),
+			WantStatusCode: http.StatusInternalServerError,
+		},
+		{
+			Name:           "PublicRedirect",
+			Path:           "/found",
+			WantBody:       nil,
+			WantStatusCode: http.StatusFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			listedOwner = ""
+			endpoint := "http://shortener.com" + tc.Path
+			req, _ := http.NewRequest(http.MethodGet, endpoint, nil)
+			if tc.Authorization != "" {
+				req.Header.Set("Authorization", tc.Authorization)
+			}
+
+			res, err := c.Do(req)
+			if err != nil {
+				t.Fatalf("Unexpected error requesting %s: %v", endpoint, err)
+			}
+			defer res.Body.Close()
+
+			got, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error parsing response body: %v", err)
+			}
+
+			if !bytes.Equal(tc.WantBody, got) {
+				t.Errorf("Wrong response (want, got): (%s, %s)", tc.WantBody, got)
+			}
+
+			if res.StatusCode != tc.WantStatusCode {
+				t.Errorf("Wrong status code (want, got): (%d, %d)", tc.WantStatusCode, res.StatusCode)
+			}
+
+			if listedOwner != tc.WantOwner {
+				t.Errorf("Wrong owner in context (want, got): (%s, %s)", tc.WantOwner, listedOwner)
+			}
+
+			if tc.WantStatusCode == http.StatusUnauthorized && res.Header.Get("WWW-Authenticate") != "Bearer" {
+				t.Errorf("Expected WWW-Authenticate header, but got: %s", res.Header.Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
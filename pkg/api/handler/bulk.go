@@ -0,0 +1,377 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/response"
+	"github.com/joao-fontenele/go-url-shortener/pkg/logger"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+	"github.com/valyala/fasthttp"
+	"go.uber.org/zap"
+)
+
+// maxImportRows limits the size of a single import, since it's held in memory
+const maxImportRows = 10000
+
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+)
+
+var contentTypes = map[string]string{
+	formatCSV:    "text/csv",
+	formatNDJSON: "application/x-ndjson",
+}
+
+// csvColumns are the columns of exported csv files. Imports only require url.
+// Tags are comma separated in a single column
+var csvColumns = []string{"slug", "url", "createdAt", "expiresAt", "maxClicks", "redirectType", "tags"}
+
+// importRow represents a single link in an import file
+type importRow struct {
+	URL          string                 `json:"url"`
+	Slug         string                 `json:"slug"`
+	ExpiresAt    *time.Time             `json:"expiresAt"`
+	MaxClicks    int                    `json:"maxClicks"`
+	RedirectType shortener.RedirectType `json:"redirectType"`
+	Tags         []string               `json:"tags"`
+}
+
+// importRowResult is the outcome of importing a single row, rows start at 1
+type importRowResult struct {
+	Row    int             `json:"row"`
+	Status string          `json:"status"`
+	Link   *shortener.Link `json:"link,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// importResBody represents the response body of the Import request handler
+type importResBody struct {
+	DryRun    bool              `json:"dryRun"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []importRowResult `json:"results"`
+}
+
+// parsedRow is an import row, or the reason it couldn't be parsed
+type parsedRow struct {
+	link shortener.Link
+	err  error
+}
+
+// Import is a handler for creating links in bulk, from a csv or ndjson body
+func (h *ShortenerHandler) Import(ctx *fasthttp.RequestCtx) {
+	ctx.SetContentType("application/json")
+	dryRun := ctx.QueryArgs().GetBool("dryRun")
+
+	var rows []parsedRow
+	var err error
+
+	contentType := string(ctx.Request.Header.ContentType())
+	switch {
+	case strings.HasPrefix(contentType, contentTypes[formatCSV]):
+		rows, err = parseCSVImport(bytes.NewReader(ctx.PostBody()))
+	case strings.HasPrefix(contentType, contentTypes[formatNDJSON]):
+		rows, err = parseNDJSONImport(bytes.NewReader(ctx.PostBody()))
+	default:
+		status := http.StatusUnsupportedMediaType
+		errMessage := fmt.Sprintf(
+			"Content-Type must be either %s or %s",
+			contentTypes[formatCSV],
+			contentTypes[formatNDJSON],
+		)
+		ctx.SetStatusCode(status)
+		b, _ := json.Marshal(response.HTTPErr{Message: errMessage, StatusCode: status})
+		ctx.Write(b)
+		return
+	}
+
+	if err == nil && len(rows) == 0 {
+		err = errors.New("there are no links to import")
+	}
+	if err == nil && len(rows) > maxImportRows {
+		err = fmt.Errorf("import must have at most %d links", maxImportRows)
+	}
+	if err != nil {
+		status := http.StatusBadRequest
+		ctx.SetStatusCode(status)
+		errMessage := fmt.Sprintf("Invalid import file: %s", err.Error())
+		b, _ := json.Marshal(response.HTTPErr{Message: errMessage, StatusCode: status})
+		ctx.Write(b)
+		return
+	}
+
+	// only rows that were parsed are sent to be imported
+	links := []shortener.Link{}
+	linkRows := []int{}
+	for i, r := range rows {
+		if r.err == nil {
+			links = append(links, r.link)
+			linkRows = append(linkRows, i)
+		}
+	}
+
+	imported, err := h.LinkService.Import(ctx, links, dryRun)
+	if err != nil {
+		var status int
+
+		if errors.Is(err, shortener.ErrLinkExists) {
+			status = http.StatusConflict
+		} else {
+			status = http.StatusInternalServerError
+		}
+
+		ctx.SetStatusCode(status)
+		errMessage := fmt.Sprintf("Error importing links: %s", err.Error())
+		b, _ := json.Marshal(response.HTTPErr{Message: errMessage, StatusCode: status})
+		ctx.Write(b)
+		return
+	}
+
+	for i, r := range imported {
+		rows[linkRows[i]].err = r.Err
+		if r.Link != nil {
+			rows[linkRows[i]].link = *r.Link
+		}
+	}
+
+	successStatus := "imported"
+	if dryRun {
+		successStatus = "valid"
+	}
+
+	resBody := importResBody{DryRun: dryRun, Results: make([]importRowResult, len(rows))}
+	for i, r := range rows {
+		result := importRowResult{Row: i + 1}
+		if r.err != nil {
+			resBody.Failed++
+			result.Status = "failed"
+			result.Error = r.err.Error()
+		} else {
+			resBody.Succeeded++
+			result.Status = successStatus
+			link := r.link
+			result.Link = &link
+		}
+		resBody.Results[i] = result
+	}
+
+	b, _ := json.Marshal(resBody)
+	ctx.Write(b)
+}
+
+// parseCSVImport reads csv rows, the first one must be a header naming the
+// columns. Unknown columns are ignored, so exported files can be imported
+func parseCSVImport(r io.Reader) ([]parsedRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["url"]; !ok {
+		return nil, errors.New("csv header must have an url column")
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[strings.ToLower(name)]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := []parsedRow{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row := parsedRow{}
+		row.link, row.err = parseImportRow(
+			field(record, "url"),
+			field(record, "slug"),
+			field(record, "expiresAt"),
+			field(record, "maxClicks"),
+			field(record, "redirectType"),
+			field(record, "tags"),
+		)
+		rows = append(rows, row)
+	}
+}
+
+// parseImportRow converts the textual fields of a csv row to a link
+func parseImportRow(url, slug, expiresAt, maxClicks, redirectType, tags string) (shortener.Link, error) {
+	l := shortener.Link{URL: url, Slug: slug, Tags: shortener.SplitTags(tags)}
+
+	if expiresAt != "" {
+		t, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return l, fmt.Errorf("%w: expiresAt must be a RFC3339 date", shortener.ErrInvalidLink)
+		}
+		l.ExpiresAt = &t
+	}
+
+	if maxClicks != "" {
+		n, err := strconv.Atoi(maxClicks)
+		if err != nil {
+			return l, fmt.Errorf("%w: maxClicks must be an integer", shortener.ErrInvalidLink)
+		}
+		l.MaxClicks = n
+	}
+
+	if redirectType != "" {
+		n, err := strconv.Atoi(redirectType)
+		if err != nil {
+			return l, fmt.Errorf("%w: redirectType must be an integer", shortener.ErrInvalidLink)
+		}
+		l.RedirectType = shortener.RedirectType(n)
+	}
+
+	return l, nil
+}
+
+// parseNDJSONImport reads one json link per line, blank lines are ignored
+func parseNDJSONImport(r io.Reader) ([]parsedRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	rows := []parsedRow{}
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var body importRow
+		row := parsedRow{}
+		err := json.Unmarshal(line, &body)
+		if err != nil {
+			row.err = fmt.Errorf("%w: invalid json", shortener.ErrInvalidLink)
+		}
+		row.link = shortener.Link{
+			URL:          body.URL,
+			Slug:         body.Slug,
+			ExpiresAt:    body.ExpiresAt,
+			MaxClicks:    body.MaxClicks,
+			RedirectType: body.RedirectType,
+			Tags:         body.Tags,
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, scanner.Err()
+}
+
+// Export is a handler for streaming all links, as csv or ndjson
+func (h *ShortenerHandler) Export(ctx *fasthttp.RequestCtx) {
+	format := string(ctx.QueryArgs().Peek("format"))
+	if format == "" {
+		format = formatNDJSON
+	}
+
+	contentType, ok := contentTypes[format]
+	if !ok {
+		ctx.SetContentType("application/json")
+		status := http.StatusBadRequest
+		ctx.SetStatusCode(status)
+		errMessage := fmt.Sprintf("Invalid format argument, must be either %s or %s", formatCSV, formatNDJSON)
+		b, _ := json.Marshal(response.HTTPErr{Message: errMessage, StatusCode: status})
+		ctx.Write(b)
+		return
+	}
+
+	ctx.SetContentType(contentType)
+	ctx.Response.Header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="links.%s"`, format))
+
+	// the request ctx can't be used after the handler returns, so the owner is
+	// carried to a new context
+	exportCtx := context.WithValue(context.Background(), shortener.OwnerKey, shortener.OwnerFromContext(ctx))
+	linkService := h.LinkService
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		// the status is already sent, so errors can only truncate the response
+		var err error
+		if format == formatCSV {
+			err = exportCSV(exportCtx, linkService, w)
+		} else {
+			err = exportNDJSON(exportCtx, linkService, w)
+		}
+
+		if err != nil {
+			logger.Get().Error("Failed to export links", zap.Error(err))
+		}
+	})
+}
+
+func exportCSV(ctx context.Context, linkService shortener.LinkService, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write(csvColumns)
+	if err != nil {
+		return err
+	}
+
+	err = linkService.Export(ctx, func(l shortener.Link) error {
+		expiresAt := ""
+		if l.ExpiresAt != nil {
+			expiresAt = l.ExpiresAt.Format(time.RFC3339)
+		}
+
+		maxClicks := ""
+		if l.MaxClicks != 0 {
+			maxClicks = strconv.Itoa(l.MaxClicks)
+		}
+
+		redirectType := ""
+		if l.RedirectType != 0 {
+			redirectType = strconv.Itoa(int(l.RedirectType))
+		}
+
+		return writer.Write([]string{
+			l.Slug,
+			l.URL,
+			l.CreatedAt.Format(time.RFC3339),
+			expiresAt,
+			maxClicks,
+			redirectType,
+			strings.Join(l.Tags, ","),
+		})
+	})
+
+	writer.Flush()
+	if err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+func exportNDJSON(ctx context.Context, linkService shortener.LinkService, w io.Writer) error {
+	// Encode writes a trailing new line after each link
+	enc := json.NewEncoder(w)
+	return linkService.Export(ctx, func(l shortener.Link) error {
+		return enc.Encode(l)
+	})
+}
@@ -0,0 +1,275 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/router"
+	"github.com/joao-fontenele/go-url-shortener/pkg/mocks"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func TestImport(t *testing.T) {
+	var gotDryRun bool
+	linkService := &mocks.FakeLinkService{
+		ImportFn: func(ctx context.Context, links []shortener.Link, dryRun bool) ([]shortener.ImportResult, error) {
+			gotDryRun = dryRun
+			results := make([]shortener.ImportResult, len(links))
+			for i, l := range links {
+				if l.URL == "https://server.error.dev" {
+					return nil, errors.New("UnexpectedError")
+				}
+
+				if l.URL == "https://link.exists.com" {
+					results[i].Err = fmt.Errorf("%w: slug '%s' is already in use", shortener.ErrLinkExists, l.Slug)
+					continue
+				}
+
+				link := l
+				if link.Slug == "" {
+					link.Slug = "LolOk"
+				}
+				results[i].Link = &link
+			}
+			return results, nil
+		},
+	}
+	r := router.New(linkService, &mocks.FakeClickService{}, allowAllAuth(), nil, nil, nil, nil)
+
+	server := &fasthttp.Server{
+		Handler: r.Handler,
+	}
+	ln := fasthttputil.NewInmemoryListener()
+
+	go server.Serve(ln)
+	defer server.Shutdown()
+
+	c := http.Client{
+		// use custom in memory listener to connect to server
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return ln.Dial()
+			},
+		},
+	}
+	defer c.CloseIdleConnections()
+
+	tests := []struct {
+		Name           string
+		Query          string
+		ContentType    string
+		ReqBody        []byte
+		WantBody       []byte
+		WantStatusCode int
+		WantDryRun     bool
+	}{
+		{
+			Name:        "CSVOk",
+			ContentType: "text/csv",
+			ReqBody: []byte("url,slug,maxClicks,redirectType,tags\n" +
+				"https://ok.com/allOK,,,307,\"sale, print\"\n" +
+				"https://link.exists.com,taken,,,\n" +
+				"https://ok.com/allOK,mine,ten,,\n" +
+				"https://ok.com/allOK,temp,,found,\n"),
+			WantBody: []byte(`{"dryRun":false,"succeeded":1,"failed":3,"results":[` +
+				`{"row":1,"status":"imported","link":{"slug":"LolOk","url":"https://ok.com/allOK","createdAt":"0001-01-01T00:00:00Z","tags":["sale","print"],"redirectType":307}},` +
+				`{"row":2,"status":"failed","error":"Link's slug already exists: slug 'taken' is already in use"},` +
+				`{"row":3,"status":"failed","error":"Link is not valid: maxClicks must be an integer"},` +
+				`{"row":4,"status":"failed","error":"Link is not valid: redirectType must be an integer"}]}`),
+			WantStatusCode: http.StatusOK,
+		},
+		{
+			Name:        "NDJSONDryRun",
+			Query:       "?dryRun=true",
+			ContentType: "application/x-ndjson",
+			ReqBody: []byte(`{"url":"https://ok.com/allOK","slug":"mine","maxClicks":10,"tags":["sale"]}` + "\n\n" +
+				`{"url":` + "\n"),
+			WantBody: []byte(`{"dryRun":true,"succeeded":1,"failed":1,"results":[` +
+				`{"row":1,"status":"valid","link":{"slug":"mine","url":"https://ok.com/allOK","createdAt":"0001-01-01T00:00:00Z","maxClicks":10,"tags":["sale"]}},` +
+				`{"row":2,"status":"failed","error":"Link is not valid: invalid json"}]}`),
+			WantStatusCode: http.StatusOK,
+			WantDryRun:     true,
+		},
+		{
+			Name:           "CSVWithoutURLColumn",
+			ContentType:    "text/csv",
+			ReqBody:        []byte("slug\nmine\n"),
+			WantBody:       []byte(`{"message":"Invalid import file: csv header must have an url column","statusCode":400}`),
+			WantStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:           "Empty",
+			ContentType:    "application/x-ndjson",
+			ReqBody:        []byte(""),
+			WantBody:       []byte(`{"message":"Invalid import file: there are no links to import","statusCode":400}`),
+			WantStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:           "UnsupportedContentType",
+			ContentType:    "application/json",
+			ReqBody:        []byte(`[]`),
+			WantBody:       []byte(`{"message":"Content-Type must be either text/csv or application/x-ndjson","statusCode":415}`),
+			WantStatusCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			Name:           "ServerErr",
+			ContentType:    "application/x-ndjson",
+			ReqBody:        []byte(`{"url":"https://server.error.dev"}`),
+			WantBody:       []byte(`{"message":"Error importing links: UnexpectedError","statusCode":500}`),
+			WantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			gotDryRun = false
+			endpoint := "http://shortener.com/links/import" + tc.Query
+			res, err := c.Post(endpoint, tc.ContentType, bytes.NewReader(tc.ReqBody))
+			if err != nil {
+				t.Fatalf("Unexpected error requesting %s: %v", endpoint, err)
+			}
+			defer res.Body.Close()
+
+			got, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error parsing response body: %v", err)
+			}
+
+			if !bytes.Equal(tc.WantBody, got) {
+				t.Errorf("Wrong response (want, got): (%s, %s)", tc.WantBody, got)
+			}
+
+			if res.StatusCode != tc.WantStatusCode {
+				t.Errorf("Wrong status code (want, got): (%d, %d)", tc.WantStatusCode, res.StatusCode)
+			}
+
+			if gotDryRun != tc.WantDryRun {
+				t.Errorf("Wrong dry run (want, got): (%t, %t)", tc.WantDryRun, gotDryRun)
+			}
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	expiresAt := time.Date(2030, 5, 1, 0, 0, 0, 0, time.UTC)
+	linkService := &mocks.FakeLinkService{
+		ExportFn: func(ctx context.Context, fn func(l shortener.Link) error) error {
+			if shortener.OwnerFromContext(ctx) != "alice" {
+				return errors.New("UnexpectedOwner")
+			}
+
+			links := []shortener.Link{
+				{
+					Slug:      "LolOk",
+					URL:       "https://ok.com/allOK",
+					CreatedAt: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC),
+				},
+				{
+					Slug:         "other",
+					URL:          "https://ok.com/?a=1,2",
+					CreatedAt:    time.Date(2020, 5, 2, 0, 0, 0, 0, time.UTC),
+					ExpiresAt:    &expiresAt,
+					MaxClicks:    10,
+					Tags:         []string{"sale", "print"},
+					RedirectType: shortener.RedirectPermanent,
+				},
+			}
+
+			for _, l := range links {
+				if err := fn(l); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	r := router.New(linkService, &mocks.FakeClickService{}, allowAllAuth(), nil, nil, nil, nil)
+
+	server := &fasthttp.Server{
+		Handler: r.Handler,
+	}
+	ln := fasthttputil.NewInmemoryListener()
+
+	go server.Serve(ln)
+	defer server.Shutdown()
+
+	c := http.Client{
+		// use custom in memory listener to connect to server
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return ln.Dial()
+			},
+		},
+	}
+	defer c.CloseIdleConnections()
+
+	tests := []struct {
+		Name            string
+		Query           string
+		WantBody        []byte
+		WantStatusCode  int
+		WantContentType string
+	}{
+		{
+			Name:  "DefaultNDJSON",
+			Query: "",
+			WantBody: []byte(`{"slug":"LolOk","url":"https://ok.com/allOK","createdAt":"2020-05-01T00:00:00Z"}` + "\n" +
+				`{"slug":"other","url":"https://ok.com/?a=1,2","createdAt":"2020-05-02T00:00:00Z","expiresAt":"2030-05-01T00:00:00Z","maxClicks":10,"tags":["sale","print"],"redirectType":308}` + "\n"),
+			WantStatusCode:  http.StatusOK,
+			WantContentType: "application/x-ndjson",
+		},
+		{
+			Name:  "CSV",
+			Query: "?format=csv",
+			WantBody: []byte("slug,url,createdAt,expiresAt,maxClicks,redirectType,tags\n" +
+				"LolOk,https://ok.com/allOK,2020-05-01T00:00:00Z,,,,\n" +
+				"other,\"https://ok.com/?a=1,2\",2020-05-02T00:00:00Z,2030-05-01T00:00:00Z,10,308,\"sale,print\"\n"),
+			WantStatusCode:  http.StatusOK,
+			WantContentType: "text/csv",
+		},
+		{
+			Name:            "InvalidFormat",
+			Query:           "?format=xml",
+			WantBody:        []byte(`{"message":"Invalid format argument, must be either csv or ndjson","statusCode":400}`),
+			WantStatusCode:  http.StatusBadRequest,
+			WantContentType: "application/json",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			endpoint := "http://shortener.com/links/export" + tc.Query
+			res, err := c.Get(endpoint)
+			if err != nil {
+				t.Fatalf("Unexpected error requesting %s: %v", endpoint, err)
+			}
+			defer res.Body.Close()
+
+			got, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error parsing response body: %v", err)
+			}
+
+			if !bytes.Equal(tc.WantBody, got) {
+				t.Errorf("Wrong response (want, got): (%s, %s)", tc.WantBody, got)
+			}
+
+			if res.StatusCode != tc.WantStatusCode {
+				t.Errorf("Wrong status code (want, got): (%d, %d)", tc.WantStatusCode, res.StatusCode)
+			}
+
+			if got := res.Header.Get("Content-Type"); got != tc.WantContentType {
+				t.Errorf("Wrong content type (want, got): (%s, %s)", tc.WantContentType, got)
+			}
+		})
+	}
+}
@@ -1,14 +1,33 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
 
 	"github.com/joao-fontenele/go-url-shortener/pkg/api/response"
 	"github.com/valyala/fasthttp"
 )
 
+// defaultHealthTimeout bounds each health check, when the handler has none
+const defaultHealthTimeout = time.Second
+
+// HealthCheck is a dependency of the api, checked by the readiness route
+type HealthCheck struct {
+	Name string
+	// Critical dependencies make the api not ready when they're down. Others,
+	// like redis, are only reported, since links can still be found in the db
+	Critical bool
+	Ping     func(ctx context.Context) error
+}
+
 // InternalHandler will handle internal, non public requests
 type InternalHandler struct {
+	HealthChecks []HealthCheck
+	// HealthTimeout bounds each health check
+	HealthTimeout time.Duration
 }
 
 // TODO: handle concurrent access?
@@ -25,3 +44,88 @@ func (h *InternalHandler) StatusHandler(ctx *fasthttp.RequestCtx) {
 
 	ctx.Write(staticResponseCache)
 }
+
+// LiveHandler tells whether the api is running, regardless of its dependencies
+func (h *InternalHandler) LiveHandler(ctx *fasthttp.RequestCtx) {
+	ctx.SetContentType("application/json")
+
+	b, _ := json.Marshal(response.HealthResponse{Status: "up"})
+	ctx.Write(b)
+}
+
+// ReadyHandler checks every dependency concurrently. The api is ready, even if
+// degraded, unless a critical dependency is down
+func (h *InternalHandler) ReadyHandler(ctx *fasthttp.RequestCtx) {
+	ctx.SetContentType("application/json")
+
+	timeout := h.HealthTimeout
+	if timeout <= 0 {
+		timeout = defaultHealthTimeout
+	}
+
+	results := make([]response.DependencyHealth, len(h.HealthChecks))
+	var wg sync.WaitGroup
+	for i, check := range h.HealthChecks {
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			results[i] = runHealthCheck(check, timeout)
+		}(i, check)
+	}
+	wg.Wait()
+
+	res := response.HealthResponse{
+		Status:       "up",
+		Dependencies: make(map[string]response.DependencyHealth, len(results)),
+	}
+	status := http.StatusOK
+	for i, r := range results {
+		res.Dependencies[h.HealthChecks[i].Name] = r
+		if r.Status == "up" {
+			continue
+		}
+
+		if r.Critical {
+			res.Status = "down"
+			status = http.StatusServiceUnavailable
+		} else if res.Status == "up" {
+			res.Status = "degraded"
+		}
+	}
+
+	b, _ := json.Marshal(res)
+	ctx.SetStatusCode(status)
+	ctx.Write(b)
+}
+
+// runHealthCheck pings a dependency for at most timeout. The check may not
+// respect its ctx, and outlive the request, so it doesn't get the request ctx,
+// which fasthttp reuses. Its result is buffered, so it can always finish
+func runHealthCheck(check HealthCheck, timeout time.Duration) response.DependencyHealth {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	errs := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		errs <- check.Ping(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	r := response.DependencyHealth{
+		Status:    "up",
+		Critical:  check.Critical,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		r.Status = "down"
+		r.Error = err.Error()
+	}
+	return r
+}
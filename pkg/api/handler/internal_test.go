@@ -3,13 +3,20 @@ package handler_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/handler"
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/response"
 	"github.com/joao-fontenele/go-url-shortener/pkg/api/router"
 	"github.com/joao-fontenele/go-url-shortener/pkg/configger"
 	"github.com/joao-fontenele/go-url-shortener/pkg/mocks"
@@ -38,7 +45,7 @@ func TestMain(m *testing.M) {
 
 func TestInternalHandler(t *testing.T) {
 	linkService := &mocks.FakeLinkService{}
-	r := router.New(linkService)
+	r := router.New(linkService, &mocks.FakeClickService{}, allowAllAuth(), nil, nil, nil, nil)
 	server := &fasthttp.Server{
 		Handler: r.Handler,
 	}
@@ -73,3 +80,143 @@ func TestInternalHandler(t *testing.T) {
 		t.Errorf("Wrong response (want, got): (%s, %s)", want, got)
 	}
 }
+
+func TestHealthHandlers(t *testing.T) {
+	up := func(ctx context.Context) error {
+		return nil
+	}
+	down := func(ctx context.Context) error {
+		return errors.New("ConnectionRefused")
+	}
+	hang := func(ctx context.Context) error {
+		// ignores ctx, like a client without timeouts
+		time.Sleep(5 * time.Second)
+		return nil
+	}
+
+	tests := []struct {
+		Name           string
+		Path           string
+		Postgres       func(ctx context.Context) error
+		Redis          func(ctx context.Context) error
+		WantStatusCode int
+		WantBody       response.HealthResponse
+	}{
+		{
+			Name:           "Live",
+			Path:           "/internal/health/live",
+			Postgres:       down,
+			Redis:          down,
+			WantStatusCode: http.StatusOK,
+			WantBody:       response.HealthResponse{Status: "up"},
+		},
+		{
+			Name:           "Ready",
+			Path:           "/internal/health/ready",
+			Postgres:       up,
+			Redis:          up,
+			WantStatusCode: http.StatusOK,
+			WantBody: response.HealthResponse{
+				Status: "up",
+				Dependencies: map[string]response.DependencyHealth{
+					"postgres": {Status: "up", Critical: true},
+					"redis":    {Status: "up"},
+				},
+			},
+		},
+		{
+			Name:           "RedisDown",
+			Path:           "/internal/health/ready",
+			Postgres:       up,
+			Redis:          down,
+			WantStatusCode: http.StatusOK,
+			WantBody: response.HealthResponse{
+				Status: "degraded",
+				Dependencies: map[string]response.DependencyHealth{
+					"postgres": {Status: "up", Critical: true},
+					"redis":    {Status: "down", Error: "ConnectionRefused"},
+				},
+			},
+		},
+		{
+			Name:           "PostgresDown",
+			Path:           "/internal/health/ready",
+			Postgres:       down,
+			Redis:          up,
+			WantStatusCode: http.StatusServiceUnavailable,
+			WantBody: response.HealthResponse{
+				Status: "down",
+				Dependencies: map[string]response.DependencyHealth{
+					"postgres": {Status: "down", Critical: true, Error: "ConnectionRefused"},
+					"redis":    {Status: "up"},
+				},
+			},
+		},
+		{
+			Name:           "PostgresTimeout",
+			Path:           "/internal/health/ready",
+			Postgres:       hang,
+			Redis:          down,
+			WantStatusCode: http.StatusServiceUnavailable,
+			WantBody: response.HealthResponse{
+				Status: "down",
+				Dependencies: map[string]response.DependencyHealth{
+					"postgres": {Status: "down", Critical: true, Error: "context deadline exceeded"},
+					"redis":    {Status: "down", Error: "ConnectionRefused"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			checks := []handler.HealthCheck{
+				{Name: "postgres", Critical: true, Ping: tc.Postgres},
+				{Name: "redis", Ping: tc.Redis},
+			}
+			r := router.New(&mocks.FakeLinkService{}, &mocks.FakeClickService{}, allowAllAuth(), nil, nil, checks, nil)
+			server := &fasthttp.Server{
+				Handler: r.Handler,
+			}
+
+			ln := fasthttputil.NewInmemoryListener()
+			go server.Serve(ln)
+			defer server.Shutdown()
+
+			c := http.Client{
+				Transport: &http.Transport{
+					DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+						return ln.Dial()
+					},
+				},
+			}
+			defer c.CloseIdleConnections()
+
+			start := time.Now()
+			res, err := c.Get("http://shortener.com" + tc.Path)
+			if err != nil {
+				t.Fatalf("Unexpected error requesting %s: %v", tc.Path, err)
+			}
+			defer res.Body.Close()
+
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("Expected health checks to time out, but took %s", elapsed)
+			}
+
+			if res.StatusCode != tc.WantStatusCode {
+				t.Errorf("Wrong status code (want, got): (%d, %d)", tc.WantStatusCode, res.StatusCode)
+			}
+
+			var got response.HealthResponse
+			err = json.NewDecoder(res.Body).Decode(&got)
+			if err != nil {
+				t.Fatalf("Unexpected error parsing response body: %v", err)
+			}
+
+			ignoreLatency := cmpopts.IgnoreFields(response.DependencyHealth{}, "LatencyMs")
+			if diff := cmp.Diff(tc.WantBody, got, ignoreLatency); diff != "" {
+				t.Errorf("Wrong response (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/router"
+	"github.com/joao-fontenele/go-url-shortener/pkg/metrics"
+	"github.com/joao-fontenele/go-url-shortener/pkg/mocks"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func TestRouteMetrics(t *testing.T) {
+	metrics.HTTPRequestsCounter.Reset()
+	metrics.HTTPRequestsDurationHistogram.Reset()
+	metrics.HTTPRequestSizeHistogram.Reset()
+	metrics.HTTPResponseSizeHistogram.Reset()
+
+	linkService := &mocks.FakeLinkService{
+		ResolveFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+			return &shortener.Link{Slug: slug, URL: "https://www.google.com", RedirectType: shortener.RedirectFound}, nil
+		},
+		CreateFn: func(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
+			return l, nil
+		},
+	}
+	clickService := &mocks.FakeClickService{
+		RecordFn: func(c shortener.Click) {},
+	}
+	r := router.New(linkService, clickService, allowAllAuth(), nil, nil, nil, nil)
+
+	server := &fasthttp.Server{
+		Handler: r.Handler,
+	}
+	ln := fasthttputil.NewInmemoryListener()
+
+	go server.Serve(ln)
+	defer server.Shutdown()
+
+	c := http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return ln.Dial()
+			},
+		},
+	}
+	defer c.CloseIdleConnections()
+
+	for _, slug := range []string{"aaaaa", "bbbbb", "ccccc"} {
+		res, err := c.Get("http://shortener.com/" + slug)
+		if err != nil {
+			t.Fatalf("Unexpected error requesting /%s: %v", slug, err)
+		}
+		res.Body.Close()
+	}
+
+	reqBody := []byte(`{"url":"https://www.google.com"}`)
+	res, err := c.Post("http://shortener.com/links", "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		t.Fatalf("Unexpected error requesting /links: %v", err)
+	}
+	res.Body.Close()
+
+	if got := promtest.CollectAndCount(metrics.HTTPRequestsCounter); got != 2 {
+		t.Errorf("Expected a series per route, but got %d", got)
+	}
+
+	redirects := promtest.ToFloat64(metrics.HTTPRequestsCounter.With(
+		prometheus.Labels{"route": "/{slug}", "method": "GET", "status": "302"},
+	))
+	if redirects != 3 {
+		t.Errorf("Wrong redirects counted (want, got): (3, %v)", redirects)
+	}
+
+	created := promtest.ToFloat64(metrics.HTTPRequestsCounter.With(
+		prometheus.Labels{"route": "/links", "method": "POST", "status": "201"},
+	))
+	if created != 1 {
+		t.Errorf("Wrong created links counted (want, got): (1, %v)", created)
+	}
+
+	if got := promtest.CollectAndCount(metrics.HTTPRequestSizeHistogram); got != 2 {
+		t.Errorf("Expected request sizes to be observed per route, but got %d series", got)
+	}
+
+	if got := promtest.CollectAndCount(metrics.HTTPResponseSizeHistogram); got != 2 {
+		t.Errorf("Expected response sizes to be observed per route, but got %d series", got)
+	}
+}
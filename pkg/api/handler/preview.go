@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"bytes"
+	_ "embed" // for the preview page template
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/response"
+	"github.com/valyala/fasthttp"
+)
+
+//go:embed templates/preview.html
+var previewHTML string
+
+var previewTemplate = template.Must(template.New("preview").Parse(previewHTML))
+
+// previewSuffix after a slug asks for its preview, slugs can't have it
+const previewSuffix = "+"
+
+// previewPage holds the attributes rendered in the preview page
+type previewPage struct {
+	response.LinkPreview
+	RedirectPath string
+}
+
+// isPreview tells if a redirect request asks for a preview instead, with a
+// trailing + in the slug or the preview query arg. Returns the slug to preview
+func isPreview(ctx *fasthttp.RequestCtx, slug string) (string, bool) {
+	if strings.HasSuffix(slug, previewSuffix) {
+		return strings.TrimSuffix(slug, previewSuffix), true
+	}
+
+	return slug, ctx.QueryArgs().GetBool("preview")
+}
+
+// Preview is a handler for showing where a Link goes, instead of redirecting.
+// It's an html page, or json when the request accepts it. Previews aren't
+// counted as clicks
+func (h *ShortenerHandler) Preview(ctx *fasthttp.RequestCtx, slug string) {
+	// caches must not serve the html page to json clients, or the other way
+	ctx.Response.Header.Set("Vary", "Accept")
+	ctx.SetContentType("application/json")
+	l, err := h.LinkService.Preview(ctx, slug)
+	if err != nil {
+		writeResolveError(ctx, slug, err)
+		return
+	}
+
+	preview := response.LinkPreview{Slug: l.Slug, URL: l.URL, CreatedAt: l.CreatedAt}
+	if strings.Contains(string(ctx.Request.Header.Peek("Accept")), "application/json") {
+		b, _ := json.Marshal(preview)
+		ctx.Write(b)
+		return
+	}
+
+	var buf bytes.Buffer
+	err = previewTemplate.Execute(&buf, previewPage{LinkPreview: preview, RedirectPath: "/" + l.Slug})
+	if err != nil {
+		status := http.StatusInternalServerError
+		ctx.SetStatusCode(status)
+		errMessage := fmt.Sprintf("Error rendering preview: %s", err.Error())
+		b, _ := json.Marshal(response.HTTPErr{Message: errMessage, StatusCode: status})
+		ctx.Write(b)
+		return
+	}
+
+	ctx.SetContentType("text/html; charset=utf-8")
+	ctx.Write(buf.Bytes())
+}
@@ -0,0 +1,155 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/router"
+	"github.com/joao-fontenele/go-url-shortener/pkg/mocks"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func TestPreview(t *testing.T) {
+	linkService := &mocks.FakeLinkService{
+		PreviewFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+			if slug == "found" {
+				return &shortener.Link{
+					Slug:      slug,
+					URL:       "https://www.google.com/?q=<script>",
+					CreatedAt: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC),
+					Owner:     "alice",
+				}, nil
+			}
+			if slug == "expired" {
+				return nil, shortener.ErrLinkExpired
+			}
+			return nil, shortener.ErrLinkNotFound
+		},
+	}
+	clickService := &mocks.FakeClickService{
+		RecordFn: func(c shortener.Click) {},
+	}
+	r := router.New(linkService, clickService, allowAllAuth(), nil, nil, nil, nil)
+
+	server := &fasthttp.Server{
+		Handler: r.Handler,
+	}
+	ln := fasthttputil.NewInmemoryListener()
+
+	go server.Serve(ln)
+	defer server.Shutdown()
+
+	c := http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return ln.Dial()
+			},
+		},
+	}
+	defer c.CloseIdleConnections()
+
+	tests := []struct {
+		Name            string
+		Path            string
+		Accept          string
+		WantStatusCode  int
+		WantContentType string
+		WantContains    []string
+	}{
+		{
+			Name:            "HTMLWithSuffix",
+			Path:            "/found+",
+			WantStatusCode:  http.StatusOK,
+			WantContentType: "text/html; charset=utf-8",
+			WantContains: []string{
+				"https://www.google.com/?q=&lt;script&gt;",
+				"May 1, 2020",
+				`href="/found"`,
+			},
+		},
+		{
+			Name:            "HTMLWithQuery",
+			Path:            "/found?preview=1",
+			Accept:          "text/html,application/xhtml+xml,*/*;q=0.8",
+			WantStatusCode:  http.StatusOK,
+			WantContentType: "text/html; charset=utf-8",
+			WantContains:    []string{`href="/found"`},
+		},
+		{
+			Name:            "JSON",
+			Path:            "/found+",
+			Accept:          "application/json",
+			WantStatusCode:  http.StatusOK,
+			WantContentType: "application/json",
+			WantContains:    []string{`{"slug":"found","url":"https://www.google.com/?q=\u003cscript\u003e","createdAt":"2020-05-01T00:00:00Z"}`},
+		},
+		{
+			Name:            "NotFound",
+			Path:            "/nFoun+",
+			WantStatusCode:  http.StatusNotFound,
+			WantContentType: "application/json",
+			WantContains:    []string{`{"message":"Link with slug 'nFoun' not found","statusCode":404}`},
+		},
+		{
+			Name:            "Expired",
+			Path:            "/expired?preview=true",
+			WantStatusCode:  http.StatusGone,
+			WantContentType: "application/json",
+			WantContains:    []string{`{"message":"Link with slug 'expired' has expired","statusCode":410}`},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			endpoint := fmt.Sprintf("http://shortener.com%s", tc.Path)
+			req, _ := http.NewRequest(http.MethodGet, endpoint, nil)
+			if tc.Accept != "" {
+				req.Header.Set("Accept", tc.Accept)
+			}
+
+			res, err := c.Do(req)
+			if err != nil {
+				t.Fatalf("Unexpected error requesting %s: %v", endpoint, err)
+			}
+			defer res.Body.Close()
+
+			got, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error reading response body: %v", err)
+			}
+
+			if res.StatusCode != tc.WantStatusCode {
+				t.Errorf("Wrong status code (want, got): (%d, %d)", tc.WantStatusCode, res.StatusCode)
+			}
+
+			if contentType := res.Header.Get("Content-Type"); contentType != tc.WantContentType {
+				t.Errorf("Wrong content type (want, got): (%s, %s)", tc.WantContentType, contentType)
+			}
+
+			if vary := res.Header.Get("Vary"); vary != "Accept" {
+				t.Errorf("Wrong Vary header (want, got): (Accept, %s)", vary)
+			}
+
+			for _, want := range tc.WantContains {
+				if !strings.Contains(string(got), want) {
+					t.Errorf("Expected response to contain %s, but got: %s", want, got)
+				}
+			}
+		})
+	}
+
+	if linkService.ResolveCalled || clickService.RecordCalled {
+		t.Error("Expected previews not to be counted as clicks")
+	}
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/response"
+	"github.com/joao-fontenele/go-url-shortener/pkg/qr"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+	"github.com/valyala/fasthttp"
+)
+
+// QRCodes configures the QR codes of short links
+type QRCodes struct {
+	// Renderer caches rendered codes, when nil every code is rendered
+	Renderer *qr.Renderer
+	// PublicURL is the base url of short links
+	PublicURL string
+}
+
+// QRCode is a handler for rendering the QR code of a short link, given a slug
+// from path. The code points at the short url, so scans are counted as clicks
+func (h *ShortenerHandler) QRCode(ctx *fasthttp.RequestCtx) {
+	slug := fmt.Sprintf("%s", ctx.UserValue("slug"))
+
+	opts, err := parseQROptions(ctx.QueryArgs())
+	if err != nil {
+		writeQRErr(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	_, err = h.LinkService.Get(ctx, slug)
+	if err != nil {
+		if errors.Is(err, shortener.ErrLinkNotFound) {
+			writeQRErr(ctx, http.StatusNotFound, fmt.Sprintf("Link with slug '%s' not found", slug))
+		} else {
+			writeQRErr(ctx, http.StatusInternalServerError, fmt.Sprintf("Error getting link: %s", err.Error()))
+		}
+		return
+	}
+
+	var image []byte
+	content := h.shortURL(slug)
+	if h.QRCodes.Renderer != nil {
+		image, err = h.QRCodes.Renderer.Render(content, opts)
+	} else {
+		image, err = qr.Render(content, opts)
+	}
+	if err != nil {
+		if errors.Is(err, qr.ErrInvalidOptions) {
+			writeQRErr(ctx, http.StatusBadRequest, err.Error())
+		} else {
+			writeQRErr(ctx, http.StatusInternalServerError, fmt.Sprintf("Error rendering QR code: %s", err.Error()))
+		}
+		return
+	}
+
+	ctx.SetContentType(opts.Format.ContentType())
+	// short urls never point somewhere else, so codes can be cached for long
+	ctx.Response.Header.Set("Cache-Control", "private, max-age=86400")
+	ctx.Write(image)
+}
+
+// shortURL is the public url of a slug
+func (h *ShortenerHandler) shortURL(slug string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(h.QRCodes.PublicURL, "/"), slug)
+}
+
+// parseQROptions overrides the default QR code options with the query args
+func parseQROptions(args *fasthttp.Args) (qr.Options, error) {
+	opts := qr.DefaultOptions()
+
+	if args.Has("format") {
+		opts.Format = qr.Format(args.Peek("format"))
+	}
+	if args.Has("level") {
+		opts.Level = qr.Level(strings.ToUpper(string(args.Peek("level"))))
+	}
+
+	var err error
+	if args.Has("size") {
+		opts.Size, err = strconv.Atoi(string(args.Peek("size")))
+		if err != nil {
+			return opts, fmt.Errorf("%w: size must be an integer", qr.ErrInvalidOptions)
+		}
+	}
+	if args.Has("margin") {
+		opts.Margin, err = strconv.Atoi(string(args.Peek("margin")))
+		if err != nil {
+			return opts, fmt.Errorf("%w: margin must be an integer", qr.ErrInvalidOptions)
+		}
+	}
+	if args.Has("fg") {
+		opts.Foreground, err = qr.ParseColor(string(args.Peek("fg")))
+		if err != nil {
+			return opts, err
+		}
+	}
+	if args.Has("bg") {
+		opts.Background, err = qr.ParseColor(string(args.Peek("bg")))
+		if err != nil {
+			return opts, err
+		}
+	}
+
+	return opts, opts.Validate()
+}
+
+func writeQRErr(ctx *fasthttp.RequestCtx, status int, errMessage string) {
+	ctx.SetContentType("application/json")
+	ctx.SetStatusCode(status)
+	b, _ := json.Marshal(response.HTTPErr{Message: errMessage, StatusCode: status})
+	ctx.Write(b)
+}
@@ -0,0 +1,168 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/handler"
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/router"
+	"github.com/joao-fontenele/go-url-shortener/pkg/mocks"
+	"github.com/joao-fontenele/go-url-shortener/pkg/qr"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func TestQRCode(t *testing.T) {
+	linkService := &mocks.FakeLinkService{
+		GetFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+			if slug == "found" {
+				return &shortener.Link{Slug: slug, URL: "https://www.google.com"}, nil
+			}
+			if slug == "error" {
+				return nil, errors.New("UnexpectedError")
+			}
+			return nil, shortener.ErrLinkNotFound
+		},
+	}
+
+	render := func(content string, change func(o *qr.Options)) []byte {
+		opts := qr.DefaultOptions()
+		if change != nil {
+			change(&opts)
+		}
+		b, err := qr.Render(content, opts)
+		if err != nil {
+			t.Fatalf("Unexpected error rendering expected code: %v", err)
+		}
+		return b
+	}
+
+	qrCodes := &handler.QRCodes{PublicURL: "https://sho.rt"}
+
+	tests := []struct {
+		Name            string
+		QRCodes         *handler.QRCodes
+		Path            string
+		WantStatusCode  int
+		WantContentType string
+		WantBody        []byte
+	}{
+		{
+			Name:            "DefaultPNG",
+			QRCodes:         &handler.QRCodes{Renderer: qr.NewRenderer(10), PublicURL: "https://sho.rt/"},
+			Path:            "/links/found/qr",
+			WantStatusCode:  http.StatusOK,
+			WantContentType: "image/png",
+			WantBody:        render("https://sho.rt/found", nil),
+		},
+		{
+			Name:            "CustomSVG",
+			QRCodes:         &handler.QRCodes{PublicURL: "https://sho.rt"},
+			Path:            "/links/found/qr?format=svg&size=512&margin=1&level=h&fg=f80&bg=%23000000",
+			WantStatusCode:  http.StatusOK,
+			WantContentType: "image/svg+xml",
+			WantBody: render("https://sho.rt/found", func(o *qr.Options) {
+				o.Format = qr.SVG
+				o.Size = 512
+				o.Margin = 1
+				o.Level = qr.LevelHigh
+				o.Foreground, _ = qr.ParseColor("ff8800")
+				o.Background, _ = qr.ParseColor("000000")
+			}),
+		},
+		{
+			Name:            "Disabled",
+			QRCodes:         nil,
+			Path:            "/links/found/qr",
+			WantStatusCode:  http.StatusNotFound,
+			WantContentType: "text/plain; charset=utf-8",
+			WantBody:        []byte("Not Found"),
+		},
+		{
+			Name:            "InvalidSize",
+			QRCodes:         qrCodes,
+			Path:            "/links/found/qr?size=big",
+			WantStatusCode:  http.StatusBadRequest,
+			WantContentType: "application/json",
+			WantBody:        []byte(`{"message":"QR code options are not valid: size must be an integer","statusCode":400}`),
+		},
+		{
+			Name:            "InvalidColor",
+			QRCodes:         qrCodes,
+			Path:            "/links/found/qr?fg=blue",
+			WantStatusCode:  http.StatusBadRequest,
+			WantContentType: "application/json",
+			WantBody:        []byte(`{"message":"QR code options are not valid: colors must be hex, like 000 or ff8800","statusCode":400}`),
+		},
+		{
+			Name:            "NotFound",
+			QRCodes:         qrCodes,
+			Path:            "/links/nFoun/qr",
+			WantStatusCode:  http.StatusNotFound,
+			WantContentType: "application/json",
+			WantBody:        []byte(`{"message":"Link with slug 'nFoun' not found","statusCode":404}`),
+		},
+		{
+			Name:            "ServiceError",
+			QRCodes:         qrCodes,
+			Path:            "/links/error/qr",
+			WantStatusCode:  http.StatusInternalServerError,
+			WantContentType: "application/json",
+			WantBody:        []byte(`{"message":"Error getting link: UnexpectedError","statusCode":500}`),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			r := router.New(linkService, &mocks.FakeClickService{}, allowAllAuth(), nil, nil, nil, tc.QRCodes)
+
+			server := &fasthttp.Server{
+				Handler: r.Handler,
+			}
+			ln := fasthttputil.NewInmemoryListener()
+
+			go server.Serve(ln)
+			defer server.Shutdown()
+
+			c := http.Client{
+				Transport: &http.Transport{
+					DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+						return ln.Dial()
+					},
+				},
+			}
+			defer c.CloseIdleConnections()
+
+			endpoint := fmt.Sprintf("http://shortener.com%s", tc.Path)
+			res, err := c.Get(endpoint)
+			if err != nil {
+				t.Fatalf("Unexpected error requesting %s: %v", endpoint, err)
+			}
+			defer res.Body.Close()
+
+			got, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error reading response body: %v", err)
+			}
+
+			if res.StatusCode != tc.WantStatusCode {
+				t.Errorf("Wrong status code (want, got): (%d, %d)", tc.WantStatusCode, res.StatusCode)
+			}
+
+			if contentType := res.Header.Get("Content-Type"); contentType != tc.WantContentType {
+				t.Errorf("Wrong content type (want, got): (%s, %s)", tc.WantContentType, contentType)
+			}
+
+			if !bytes.Equal(tc.WantBody, got) {
+				t.Errorf("Wrong response body (want, got): (%q, %q)", tc.WantBody, got)
+			}
+		})
+	}
+}
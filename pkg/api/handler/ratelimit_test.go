@@ -0,0 +1,257 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/middleware"
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/router"
+	"github.com/joao-fontenele/go-url-shortener/pkg/mocks"
+	"github.com/joao-fontenele/go-url-shortener/pkg/ratelimit"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+// fakeLimiter allows the first request of each key, and fails for key errors
+type fakeLimiter struct {
+	keys map[string]int
+}
+
+func (f *fakeLimiter) Allow(ctx context.Context, key string, l ratelimit.Limit) (*ratelimit.Result, error) {
+	if key == "list^owner:error" {
+		return nil, errors.New("ConnectionRefused")
+	}
+
+	f.keys[key]++
+	if f.keys[key] > l.Requests {
+		return &ratelimit.Result{
+			Limit:      l.Requests,
+			ResetAfter: 30 * time.Second,
+			RetryAfter: 1500 * time.Millisecond,
+		}, nil
+	}
+
+	return &ratelimit.Result{
+		Allowed:    true,
+		Limit:      l.Requests,
+		Remaining:  l.Requests - f.keys[key],
+		ResetAfter: 30 * time.Second,
+	}, nil
+}
+
+func TestRateLimit(t *testing.T) {
+	linkService := &mocks.FakeLinkService{
+		ListFn: func(ctx context.Context, opts shortener.ListOptions) (*shortener.LinkPage, error) {
+			return &shortener.LinkPage{Items: []shortener.Link{}}, nil
+		},
+	}
+	authService := &mocks.FakeAuthService{
+		AuthenticateFn: func(ctx context.Context, key string) (*shortener.APIKey, error) {
+			return &shortener.APIKey{Owner: key}, nil
+		},
+	}
+	rateLimit := middleware.NewRateLimit(
+		&fakeLimiter{keys: map[string]int{}},
+		map[string]ratelimit.Limit{"list": {Requests: 1, Window: time.Minute}},
+	)
+	r := router.New(linkService, &mocks.FakeClickService{}, authService, nil, rateLimit, nil, nil)
+
+	server := &fasthttp.Server{
+		Handler: r.Handler,
+	}
+	ln := fasthttputil.NewInmemoryListener()
+
+	go server.Serve(ln)
+	defer server.Shutdown()
+
+	c := http.Client{
+		// use custom in memory listener to connect to server
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return ln.Dial()
+			},
+		},
+	}
+	defer c.CloseIdleConnections()
+
+	tests := []struct {
+		Name           string
+		APIKey         string
+		WantBody       []byte
+		WantStatusCode int
+		WantHeaders    map[string]string
+	}{
+		{
+			Name:           "Allowed",
+			APIKey:         "alice",
+			WantBody:       []byte(`{"items":[]}`),
+			WantStatusCode: http.StatusOK,
+			WantHeaders: map[string]string{
+				"RateLimit-Limit":     "1",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "30",
+				"Retry-After":         "",
+			},
+		},
+		{
+			Name:           "Limited",
+			APIKey:         "alice",
+			WantBody:       []byte(`{"message":"Too many requests","statusCode":429}`),
+			WantStatusCode: http.StatusTooManyRequests,
+			WantHeaders: map[string]string{
+				"RateLimit-Limit":     "1",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "30",
+				"Retry-After":         "2",
+			},
+		},
+		{
+			Name:           "OtherOwnerAllowed",
+			APIKey:         "bob",
+			WantBody:       []byte(`{"items":[]}`),
+			WantStatusCode: http.StatusOK,
+			WantHeaders: map[string]string{
+				"RateLimit-Remaining": "0",
+			},
+		},
+		{
+			Name:           "LimiterErrorFailsOpen",
+			APIKey:         "error",
+			WantBody:       []byte(`{"items":[]}`),
+			WantStatusCode: http.StatusOK,
+			WantHeaders: map[string]string{
+				"RateLimit-Limit": "",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "http://shortener.com/links?limit=10&skip=0", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.APIKey)
+			res, err := c.Do(req)
+			if err != nil {
+				t.Fatalf("Unexpected error requesting links: %v", err)
+			}
+			defer res.Body.Close()
+
+			got, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error parsing response body: %v", err)
+			}
+
+			if !bytes.Equal(tc.WantBody, got) {
+				t.Errorf("Wrong response (want, got): (%s, %s)", tc.WantBody, got)
+			}
+
+			if res.StatusCode != tc.WantStatusCode {
+				t.Errorf("Wrong status code (want, got): (%d, %d)", tc.WantStatusCode, res.StatusCode)
+			}
+
+			for name, want := range tc.WantHeaders {
+				if got := res.Header.Get(name); got != want {
+					t.Errorf("Wrong %s header (want, got): (%s, %s)", name, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitAuthByIP(t *testing.T) {
+	authService := &mocks.FakeAuthService{
+		AuthenticateFn: func(ctx context.Context, key string) (*shortener.APIKey, error) {
+			return nil, shortener.ErrUnauthorized
+		},
+	}
+	// in memory connections come from 0.0.0.0
+	trustedProxies, err := middleware.ParseTrustedProxies([]string{"0.0.0.0", "10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("Unexpected error parsing trusted proxies: %v", err)
+	}
+	rateLimit := middleware.NewRateLimit(
+		&fakeLimiter{keys: map[string]int{}},
+		map[string]ratelimit.Limit{"auth": {Requests: 1, Window: time.Minute}},
+	)
+	r := router.New(&mocks.FakeLinkService{}, &mocks.FakeClickService{}, authService, trustedProxies, rateLimit, nil, nil)
+
+	server := &fasthttp.Server{
+		Handler: r.Handler,
+	}
+	ln := fasthttputil.NewInmemoryListener()
+
+	go server.Serve(ln)
+	defer server.Shutdown()
+
+	c := http.Client{
+		// use custom in memory listener to connect to server
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return ln.Dial()
+			},
+		},
+	}
+	defer c.CloseIdleConnections()
+
+	tests := []struct {
+		Name           string
+		ForwardedFor   string
+		WantStatusCode int
+	}{
+		{
+			Name:           "FailedAuth",
+			ForwardedFor:   "203.0.113.1",
+			WantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:           "FailedAuthLimited",
+			ForwardedFor:   "203.0.113.1",
+			WantStatusCode: http.StatusTooManyRequests,
+		},
+		{
+			Name:           "OtherClientAllowed",
+			ForwardedFor:   "203.0.113.2",
+			WantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:           "TrustedProxiesSkipped",
+			ForwardedFor:   "203.0.113.2, 10.0.0.1",
+			WantStatusCode: http.StatusTooManyRequests,
+		},
+		{
+			Name:           "SpoofedAddressIgnored",
+			ForwardedFor:   "203.0.113.3, 203.0.113.1",
+			WantStatusCode: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "http://shortener.com/links", nil)
+			req.Header.Set("Authorization", "Bearer wrong")
+			req.Header.Set("X-Forwarded-For", tc.ForwardedFor)
+			res, err := c.Do(req)
+			if err != nil {
+				t.Fatalf("Unexpected error requesting links: %v", err)
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode != tc.WantStatusCode {
+				t.Errorf("Wrong status code (want, got): (%d, %d)", tc.WantStatusCode, res.StatusCode)
+			}
+		})
+	}
+
+	t.Run("InvalidTrustedProxy", func(t *testing.T) {
+		_, err := middleware.ParseTrustedProxies([]string{"10.0.0.0/33"})
+		if err == nil {
+			t.Error("Expected error parsing invalid trusted proxy")
+		}
+	})
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/middleware"
 	"github.com/joao-fontenele/go-url-shortener/pkg/api/response"
 	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
 	"github.com/valyala/fasthttp"
@@ -14,12 +16,42 @@ import (
 
 // newLinkReqBody represents a request body received by the NewLink request handler
 type newLinkReqBody struct {
-	URL string `json:"url"`
+	URL          string                 `json:"url"`
+	Slug         string                 `json:"slug"`
+	ExpiresAt    *time.Time             `json:"expiresAt"`
+	MaxClicks    int                    `json:"maxClicks"`
+	Tags         []string               `json:"tags"`
+	RedirectType shortener.RedirectType `json:"redirectType"`
+}
+
+// updateLinkReqBody represents a request body received by the Update request
+// handler. Absent fields are kept, a null expiresAt or maxClicks removes them
+type updateLinkReqBody struct {
+	URL          string                 `json:"url"`
+	ExpiresAt    *time.Time             `json:"expiresAt"`
+	MaxClicks    *int                   `json:"maxClicks"`
+	Tags         []string               `json:"tags"`
+	RedirectType shortener.RedirectType `json:"redirectType"`
+}
+
+// nullFields tells which fields of a json object are explicitly null, since
+// they're decoded the same as absent ones
+func nullFields(body []byte) map[string]bool {
+	fields := map[string]json.RawMessage{}
+	json.Unmarshal(body, &fields)
+
+	null := map[string]bool{}
+	for name, value := range fields {
+		null[name] = string(value) == "null"
+	}
+	return null
 }
 
 // ShortenerHandler is a route handler for link service
 type ShortenerHandler struct {
-	LinkService shortener.LinkService
+	LinkService  shortener.LinkService
+	ClickService shortener.ClickService
+	QRCodes      QRCodes
 }
 
 // NewLink is a handler for creating a new Link
@@ -37,14 +69,27 @@ func (h *ShortenerHandler) NewLink(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
-	l, err := h.LinkService.Create(ctx, body.URL)
+	l, err := h.LinkService.Create(ctx, &shortener.Link{
+		URL:          body.URL,
+		Slug:         body.Slug,
+		ExpiresAt:    body.ExpiresAt,
+		MaxClicks:    body.MaxClicks,
+		Tags:         body.Tags,
+		RedirectType: body.RedirectType,
+	})
 	if err != nil {
 		var status int
 		var errMessage string
 
-		if errors.Is(err, shortener.ErrInvalidLink) {
+		if errors.Is(err, shortener.ErrInvalidLink) || errors.Is(err, shortener.ErrInvalidSlug) {
 			status = http.StatusBadRequest
 			errMessage = err.Error()
+		} else if errors.Is(err, shortener.ErrURLBlocked) {
+			status = http.StatusUnprocessableEntity
+			errMessage = err.Error()
+		} else if errors.Is(err, shortener.ErrLinkExists) {
+			status = http.StatusConflict
+			errMessage = err.Error()
 		} else {
 			status = http.StatusInternalServerError
 			errMessage = fmt.Sprintf("Error creating link: %s", err.Error())
@@ -63,70 +108,268 @@ func (h *ShortenerHandler) NewLink(ctx *fasthttp.RequestCtx) {
 	return
 }
 
-// Redirect is a handler for redirecting to a Link.URL, given a slug from path
-func (h *ShortenerHandler) Redirect(ctx *fasthttp.RequestCtx) {
+// Update is a handler for changing an existing Link, given a slug from path
+func (h *ShortenerHandler) Update(ctx *fasthttp.RequestCtx) {
 	ctx.SetContentType("application/json")
 	slug := fmt.Sprintf("%s", ctx.UserValue("slug"))
-	URL, err := h.LinkService.GetURL(ctx, slug)
+
+	var body updateLinkReqBody
+	err := json.Unmarshal(ctx.PostBody(), &body)
+
+	if err != nil {
+		status := http.StatusBadRequest
+		ctx.SetStatusCode(status)
+		b, _ := json.Marshal(response.HTTPErr{Message: "Invalid json in request body", StatusCode: status})
+		ctx.Write(b)
+		return
+	}
+
+	null := nullFields(ctx.PostBody())
+	if null["maxClicks"] {
+		body.MaxClicks = new(int)
+	}
+
+	l, err := h.LinkService.Update(ctx, &shortener.LinkUpdate{
+		Slug:           slug,
+		URL:            body.URL,
+		ExpiresAt:      body.ExpiresAt,
+		ClearExpiresAt: null["expiresAt"],
+		MaxClicks:      body.MaxClicks,
+		Tags:           body.Tags,
+		RedirectType:   body.RedirectType,
+	})
 	if err != nil {
 		var status int
 		var errMessage string
 
-		if errors.Is(err, shortener.ErrLinkNotFound) {
+		if errors.Is(err, shortener.ErrInvalidLink) {
+			status = http.StatusBadRequest
+			errMessage = err.Error()
+		} else if errors.Is(err, shortener.ErrURLBlocked) {
+			status = http.StatusUnprocessableEntity
+			errMessage = err.Error()
+		} else if errors.Is(err, shortener.ErrLinkNotFound) {
 			status = http.StatusNotFound
 			errMessage = fmt.Sprintf("Link with slug '%s' not found", slug)
 		} else {
 			status = http.StatusInternalServerError
-			errMessage = fmt.Sprintf("Error getting slug: %s", err.Error())
+			errMessage = fmt.Sprintf("Error updating link: %s", err.Error())
 		}
 
-		ctx.SetStatusCode(status)
 		b, _ := json.Marshal(response.HTTPErr{Message: errMessage, StatusCode: status})
+		ctx.SetStatusCode(status)
 		ctx.Write(b)
 		return
 	}
 
-	ctx.Redirect(URL, http.StatusMovedPermanently)
-	return
+	b, _ := json.Marshal(l)
+	ctx.Write(b)
 }
 
-// List is a handler for listing link entities
-func (h *ShortenerHandler) List(ctx *fasthttp.RequestCtx) {
+// Delete is a handler for removing a Link, given a slug from path
+func (h *ShortenerHandler) Delete(ctx *fasthttp.RequestCtx) {
 	ctx.SetContentType("application/json")
-	skipBytes := ctx.QueryArgs().Peek("skip")
-	skip, err := strconv.Atoi(string(skipBytes))
+	slug := fmt.Sprintf("%s", ctx.UserValue("slug"))
+
+	err := h.LinkService.Delete(ctx, slug)
+	if err != nil {
+		var status int
+		var errMessage string
+
+		if errors.Is(err, shortener.ErrLinkNotFound) {
+			status = http.StatusNotFound
+			errMessage = fmt.Sprintf("Link with slug '%s' not found", slug)
+		} else {
+			status = http.StatusInternalServerError
+			errMessage = fmt.Sprintf("Error deleting link: %s", err.Error())
+		}
 
-	if err != nil || skip < 0 {
-		status := http.StatusBadRequest
-		ctx.SetStatusCode(status)
-		errMessage := "Invalid skip argument, must be integer greater than or equal to 0"
 		b, _ := json.Marshal(response.HTTPErr{Message: errMessage, StatusCode: status})
+		ctx.SetStatusCode(status)
 		ctx.Write(b)
 		return
 	}
 
-	limitBytes := ctx.QueryArgs().Peek("limit")
-	limit, err := strconv.Atoi(string(limitBytes))
+	ctx.SetStatusCode(http.StatusNoContent)
+}
+
+// permanentRedirectMaxAge is how long browsers and proxies may cache permanent
+// redirects. Later changes to the link url are missed for as long
+const permanentRedirectMaxAge = 24 * time.Hour
+
+// Redirect is a handler for redirecting to a Link.URL, given a slug from path,
+// with the link redirect type. Requests for previews are handled by Preview
+// instead
+func (h *ShortenerHandler) Redirect(ctx *fasthttp.RequestCtx) {
+	slug, preview := isPreview(ctx, fmt.Sprintf("%s", ctx.UserValue("slug")))
+	if preview {
+		h.Preview(ctx, slug)
+		return
+	}
+
+	ctx.SetContentType("application/json")
+	l, err := h.LinkService.Resolve(ctx, slug)
+	if err != nil {
+		writeResolveError(ctx, slug, err)
+		return
+	}
+
+	h.ClickService.Record(shortener.Click{
+		Slug:       slug,
+		Referrer:   string(ctx.Referer()),
+		UserAgent:  string(ctx.UserAgent()),
+		RemoteAddr: middleware.ClientIPFromContext(ctx).String(),
+		CreatedAt:  time.Now(),
+	})
+
+	ctx.Response.Header.Set("Cache-Control", l.CacheControl(time.Now(), permanentRedirectMaxAge))
+	ctx.Redirect(l.URL, int(l.RedirectType))
+	return
+}
+
+// writeResolveError responds with the error of finding the link to redirect
+// to, or to preview, given its slug
+func writeResolveError(ctx *fasthttp.RequestCtx, slug string, err error) {
+	var status int
+	var errMessage string
+
+	if errors.Is(err, shortener.ErrLinkNotFound) {
+		status = http.StatusNotFound
+		errMessage = fmt.Sprintf("Link with slug '%s' not found", slug)
+	} else if errors.Is(err, shortener.ErrLinkExpired) {
+		status = http.StatusGone
+		errMessage = fmt.Sprintf("Link with slug '%s' has expired", slug)
+	} else {
+		status = http.StatusInternalServerError
+		errMessage = fmt.Sprintf("Error getting slug: %s", err.Error())
+	}
+
+	ctx.SetStatusCode(status)
+	b, _ := json.Marshal(response.HTTPErr{Message: errMessage, StatusCode: status})
+	ctx.Write(b)
+}
+
+// Stats is a handler for getting click statistics of a Link, given a slug from path
+func (h *ShortenerHandler) Stats(ctx *fasthttp.RequestCtx) {
+	ctx.SetContentType("application/json")
+	slug := fmt.Sprintf("%s", ctx.UserValue("slug"))
+
+	stats, err := h.ClickService.Stats(ctx, slug)
+	if err != nil {
+		var status int
+		var errMessage string
+
+		if errors.Is(err, shortener.ErrLinkNotFound) {
+			status = http.StatusNotFound
+			errMessage = fmt.Sprintf("Link with slug '%s' not found", slug)
+		} else {
+			status = http.StatusInternalServerError
+			errMessage = fmt.Sprintf("Error getting link stats: %s", err.Error())
+		}
 
-	if err != nil || limit <= 0 {
-		status := http.StatusBadRequest
 		ctx.SetStatusCode(status)
-		errMessage := "Invalid limit argument, must be integer greater than 0"
 		b, _ := json.Marshal(response.HTTPErr{Message: errMessage, StatusCode: status})
 		ctx.Write(b)
 		return
 	}
 
-	links, err := h.LinkService.List(ctx, limit, skip)
+	b, _ := json.Marshal(stats)
+	ctx.Write(b)
+}
+
+// List is a handler for listing link entities
+func (h *ShortenerHandler) List(ctx *fasthttp.RequestCtx) {
+	ctx.SetContentType("application/json")
+	args := ctx.QueryArgs()
+	opts := shortener.ListOptions{
+		Owner:       string(args.Peek("owner")),
+		Host:        string(args.Peek("host")),
+		URLContains: string(args.Peek("url")),
+		Tag:         string(args.Peek("tag")),
+		Sort:        shortener.ListSort(args.Peek("sort")),
+	}
+
+	var err error
+	for _, name := range []string{"createdAfter", "createdBefore"} {
+		if !args.Has(name) {
+			continue
+		}
+
+		date, err := time.Parse(time.RFC3339, string(args.Peek(name)))
+		if err != nil {
+			status := http.StatusBadRequest
+			ctx.SetStatusCode(status)
+			errMessage := fmt.Sprintf("Invalid %s argument, must be a RFC 3339 date", name)
+			b, _ := json.Marshal(response.HTTPErr{Message: errMessage, StatusCode: status})
+			ctx.Write(b)
+			return
+		}
+
+		if name == "createdAfter" {
+			opts.CreatedAfter = &date
+		} else {
+			opts.CreatedBefore = &date
+		}
+	}
+
+	if args.Has("skip") {
+		opts.Skip, err = strconv.Atoi(string(args.Peek("skip")))
+		if err != nil || opts.Skip < 0 {
+			status := http.StatusBadRequest
+			ctx.SetStatusCode(status)
+			errMessage := "Invalid skip argument, must be integer greater than or equal to 0"
+			b, _ := json.Marshal(response.HTTPErr{Message: errMessage, StatusCode: status})
+			ctx.Write(b)
+			return
+		}
+	}
+
+	if args.Has("limit") {
+		opts.Limit, err = strconv.Atoi(string(args.Peek("limit")))
+		if err != nil || opts.Limit <= 0 {
+			status := http.StatusBadRequest
+			ctx.SetStatusCode(status)
+			errMessage := "Invalid limit argument, must be integer greater than 0"
+			b, _ := json.Marshal(response.HTTPErr{Message: errMessage, StatusCode: status})
+			ctx.Write(b)
+			return
+		}
+	}
+
+	if args.Has("cursor") {
+		if opts.Skip > 0 {
+			status := http.StatusBadRequest
+			ctx.SetStatusCode(status)
+			errMessage := "Invalid arguments, skip and cursor can't be used together"
+			b, _ := json.Marshal(response.HTTPErr{Message: errMessage, StatusCode: status})
+			ctx.Write(b)
+			return
+		}
+
+		opts.After, err = shortener.DecodeListCursor(string(args.Peek("cursor")))
+		if err != nil {
+			status := http.StatusBadRequest
+			ctx.SetStatusCode(status)
+			b, _ := json.Marshal(response.HTTPErr{Message: err.Error(), StatusCode: status})
+			ctx.Write(b)
+			return
+		}
+	}
+
+	page, err := h.LinkService.List(ctx, opts)
 	if err != nil {
 		status := http.StatusInternalServerError
-		ctx.SetStatusCode(status)
 		errMessage := fmt.Sprintf("Failed to list links: %v", err.Error())
+		if errors.Is(err, shortener.ErrInvalidListOptions) || errors.Is(err, shortener.ErrInvalidCursor) {
+			status = http.StatusBadRequest
+			errMessage = err.Error()
+		}
+		ctx.SetStatusCode(status)
 		b, _ := json.Marshal(response.HTTPErr{Message: errMessage, StatusCode: status})
 		ctx.Write(b)
 		return
 	}
 
-	b, _ := json.Marshal(links)
+	b, _ := json.Marshal(page)
 	ctx.Write(b)
 }
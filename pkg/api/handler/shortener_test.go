@@ -11,6 +11,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/middleware"
 	"github.com/joao-fontenele/go-url-shortener/pkg/api/router"
 	"github.com/joao-fontenele/go-url-shortener/pkg/mocks"
 	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
@@ -20,19 +22,41 @@ import (
 
 func TestShortenerRedirect(t *testing.T) {
 	linkService := &mocks.FakeLinkService{
-		GetURLFn: func(ctx context.Context, slug string) (string, error) {
+		ResolveFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
 			if slug == "found" {
-				return "https://www.google.com/?search=Google", nil
+				return &shortener.Link{
+					Slug:         slug,
+					URL:          "https://www.google.com/?search=Google",
+					RedirectType: shortener.RedirectMovedPermanently,
+				}, nil
+			}
+
+			if slug == "tempo" {
+				return &shortener.Link{
+					Slug:         slug,
+					URL:          "https://www.google.com/?search=Go",
+					RedirectType: shortener.RedirectTemporary,
+				}, nil
 			}
 
 			if slug == "nFoun" {
-				return "", shortener.ErrLinkNotFound
+				return nil, shortener.ErrLinkNotFound
 			}
 
-			return "", errors.New("UnexpectedError")
+			if slug == "expir" {
+				return nil, shortener.ErrLinkExpired
+			}
+
+			return nil, errors.New("UnexpectedError")
 		},
 	}
-	r := router.New(linkService)
+	var recorded []shortener.Click
+	clickService := &mocks.FakeClickService{
+		RecordFn: func(c shortener.Click) {
+			recorded = append(recorded, c)
+		},
+	}
+	r := router.New(linkService, clickService, allowAllAuth(), nil, nil, nil, nil)
 
 	server := &fasthttp.Server{
 		Handler: r.Handler,
@@ -57,11 +81,12 @@ func TestShortenerRedirect(t *testing.T) {
 	defer c.CloseIdleConnections()
 
 	tests := []struct {
-		Name           string
-		Slug           string
-		WantBody       []byte
-		WantStatusCode int
-		WantRedirect   string
+		Name             string
+		Slug             string
+		WantBody         []byte
+		WantStatusCode   int
+		WantRedirect     string
+		WantCacheControl string
 	}{
 		{
 			Name:           "NotFound",
@@ -70,6 +95,13 @@ func TestShortenerRedirect(t *testing.T) {
 			WantStatusCode: http.StatusNotFound,
 			WantRedirect:   "",
 		},
+		{
+			Name:           "Expired",
+			Slug:           "expir",
+			WantBody:       []byte(`{"message":"Link with slug 'expir' has expired","statusCode":410}`),
+			WantStatusCode: http.StatusGone,
+			WantRedirect:   "",
+		},
 		{
 			Name:           "ServerErr",
 			Slug:           "error",
@@ -78,11 +110,20 @@ func TestShortenerRedirect(t *testing.T) {
 			WantRedirect:   "",
 		},
 		{
-			Name:           "Found",
-			Slug:           "found",
-			WantBody:       nil,
-			WantStatusCode: http.StatusMovedPermanently,
-			WantRedirect:   "https://www.google.com/?search=Google",
+			Name:             "Found",
+			Slug:             "found",
+			WantBody:         nil,
+			WantStatusCode:   http.StatusMovedPermanently,
+			WantRedirect:     "https://www.google.com/?search=Google",
+			WantCacheControl: "public, max-age=86400",
+		},
+		{
+			Name:             "Temporary",
+			Slug:             "tempo",
+			WantBody:         nil,
+			WantStatusCode:   http.StatusTemporaryRedirect,
+			WantRedirect:     "https://www.google.com/?search=Go",
+			WantCacheControl: "private, no-store",
 		},
 	}
 
@@ -105,7 +146,11 @@ func TestShortenerRedirect(t *testing.T) {
 			}
 
 			if res.StatusCode != tc.WantStatusCode {
-				t.Errorf("Wrong status code (want, got): (%s, %s)", tc.WantBody, got)
+				t.Errorf("Wrong status code (want, got): (%d, %d)", tc.WantStatusCode, res.StatusCode)
+			}
+
+			if cacheControl := res.Header.Get("Cache-Control"); cacheControl != tc.WantCacheControl {
+				t.Errorf("Wrong cache control (want, got): (%s, %s)", tc.WantCacheControl, cacheControl)
 			}
 
 			// TODO: find a simpler way to make this assertion
@@ -116,31 +161,106 @@ func TestShortenerRedirect(t *testing.T) {
 			}
 		})
 	}
+
+	// only successful redirects should be recorded as clicks
+	if len(recorded) != 2 {
+		t.Fatalf("Expected exactly two clicks to be recorded, but got %d", len(recorded))
+	}
+
+	if recorded[0].Slug != "found" || recorded[0].UserAgent != "Go-http-client/1.1" {
+		t.Errorf("Recorded click doesn't match request: %#v", recorded[0])
+	}
+}
+
+func TestRedirectRecordsClientIP(t *testing.T) {
+	linkService := &mocks.FakeLinkService{
+		ResolveFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+			return &shortener.Link{Slug: slug, URL: "https://www.google.com"}, nil
+		},
+	}
+	var recorded []shortener.Click
+	clickService := &mocks.FakeClickService{
+		RecordFn: func(c shortener.Click) {
+			recorded = append(recorded, c)
+		},
+	}
+	// in memory connections come from 0.0.0.0
+	trustedProxies, err := middleware.ParseTrustedProxies([]string{"0.0.0.0"})
+	if err != nil {
+		t.Fatalf("Unexpected error parsing trusted proxies: %v", err)
+	}
+	r := router.New(linkService, clickService, allowAllAuth(), trustedProxies, nil, nil, nil)
+
+	server := &fasthttp.Server{
+		Handler: r.Handler,
+	}
+	ln := fasthttputil.NewInmemoryListener()
+
+	go server.Serve(ln)
+	defer server.Shutdown()
+
+	c := http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return ln.Dial()
+			},
+		},
+	}
+	defer c.CloseIdleConnections()
+
+	req, _ := http.NewRequest(http.MethodGet, "http://shortener.com/found", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.1")
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error requesting redirect: %v", err)
+	}
+	res.Body.Close()
+
+	if len(recorded) != 1 || recorded[0].RemoteAddr != "203.0.113.1" {
+		t.Errorf("Expected the click to be recorded with the forwarded client IP, but got: %v", recorded)
+	}
 }
 
 func TestNewLink(t *testing.T) {
 	linkService := &mocks.FakeLinkService{
-		CreateFn: func(ctx context.Context, URL string) (*shortener.Link, error) {
-			if URL == "https://ok.com/allOK" {
+		CreateFn: func(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
+			if l.Slug == "links" {
+				return nil, fmt.Errorf("%w: slug 'links' is reserved", shortener.ErrInvalidSlug)
+			}
+
+			if l.URL == "https://ok.com/allOK" {
+				slug := l.Slug
+				if slug == "" {
+					slug = "LolOk"
+				}
 				return &shortener.Link{
 					URL:       "https://ok.com/allOK",
-					Slug:      "LolOk",
+					Slug:      slug,
 					CreatedAt: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC),
+					ExpiresAt: l.ExpiresAt,
+					MaxClicks: l.MaxClicks,
 				}, nil
 			}
 
-			if URL == "" {
+			if l.URL == "" {
 				return nil, shortener.ErrInvalidLink
 			}
 
-			if URL == "https://link.exists.com" {
+			if l.URL == "https://link.exists.com" {
 				return nil, shortener.ErrLinkExists
 			}
 
+			if l.URL == "http://127.0.0.1" {
+				return nil, fmt.Errorf("%w: private and loopback addresses are not allowed", shortener.ErrURLBlocked)
+			}
+
 			return nil, errors.New("UnexpectedError")
 		},
 	}
-	r := router.New(linkService)
+	r := router.New(linkService, &mocks.FakeClickService{}, allowAllAuth(), nil, nil, nil, nil)
 
 	server := &fasthttp.Server{
 		Handler: r.Handler,
@@ -193,8 +313,32 @@ func TestNewLink(t *testing.T) {
 		{
 			Name:           "LinkExists",
 			ReqBody:        []byte(`{"url":"https://link.exists.com"}`),
-			WantBody:       []byte(`{"message":"Error creating link: Link's slug already exists","statusCode":500}`),
-			WantStatusCode: http.StatusInternalServerError,
+			WantBody:       []byte(`{"message":"Link's slug already exists","statusCode":409}`),
+			WantStatusCode: http.StatusConflict,
+		},
+		{
+			Name:           "URLBlocked",
+			ReqBody:        []byte(`{"url":"http://127.0.0.1"}`),
+			WantBody:       []byte(`{"message":"Link URL is not allowed: private and loopback addresses are not allowed","statusCode":422}`),
+			WantStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:           "ExpiringLinkOk",
+			ReqBody:        []byte(`{"url":"https://ok.com/allOK","expiresAt":"2030-05-01T00:00:00Z","maxClicks":10}`),
+			WantBody:       []byte(`{"slug":"LolOk","url":"https://ok.com/allOK","createdAt":"2020-05-01T00:00:00Z","expiresAt":"2030-05-01T00:00:00Z","maxClicks":10}`),
+			WantStatusCode: http.StatusCreated,
+		},
+		{
+			Name:           "CustomSlugOk",
+			ReqBody:        []byte(`{"url":"https://ok.com/allOK","slug":"spring-sale"}`),
+			WantBody:       []byte(`{"slug":"spring-sale","url":"https://ok.com/allOK","createdAt":"2020-05-01T00:00:00Z"}`),
+			WantStatusCode: http.StatusCreated,
+		},
+		{
+			Name:           "CustomSlugReserved",
+			ReqBody:        []byte(`{"url":"https://ok.com/allOK","slug":"links"}`),
+			WantBody:       []byte(`{"message":"Slug is not valid: slug 'links' is reserved","statusCode":400}`),
+			WantStatusCode: http.StatusBadRequest,
 		},
 	}
 
@@ -224,8 +368,14 @@ func TestNewLink(t *testing.T) {
 }
 
 func TestList(t *testing.T) {
+	var listed shortener.ListOptions
 	linkService := &mocks.FakeLinkService{
-		ListFn: func(ctx context.Context, limit, skip int) ([]shortener.Link, error) {
+		ListFn: func(ctx context.Context, opts shortener.ListOptions) (*shortener.LinkPage, error) {
+			if err := opts.Validate(); err != nil {
+				return nil, err
+			}
+			listed = opts
+
 			links := []shortener.Link{
 				{
 					URL:       "https://link1.com/?s=google.com",
@@ -244,18 +394,37 @@ func TestList(t *testing.T) {
 				},
 			}
 
-			if skip > len(links) {
-				return []shortener.Link{}, nil
+			start := opts.Skip
+			if opts.After != nil {
+				for i, l := range links {
+					if l.Slug == opts.After.Slug {
+						start = i + 1
+					}
+				}
 			}
 
-			if limit+skip > len(links) {
-				limit = len(links) - skip
+			limit := opts.Limit
+			if limit == 0 {
+				limit = 2
 			}
 
-			return links[skip : skip+limit], nil
+			page := &shortener.LinkPage{Items: []shortener.Link{}}
+			if start > len(links) {
+				return page, nil
+			}
+
+			end := start + limit
+			if end >= len(links) {
+				end = len(links)
+			} else {
+				page.NextCursor = shortener.EncodeListCursor(shortener.ListCursor{Sort: shortener.SortCreatedAt, CreatedAt: links[end-1].CreatedAt, Slug: links[end-1].Slug})
+			}
+
+			page.Items = links[start:end]
+			return page, nil
 		},
 	}
-	r := router.New(linkService)
+	r := router.New(linkService, &mocks.FakeClickService{}, allowAllAuth(), nil, nil, nil, nil)
 
 	server := &fasthttp.Server{
 		Handler: r.Handler,
@@ -275,11 +444,14 @@ func TestList(t *testing.T) {
 	}
 	defer c.CloseIdleConnections()
 
+	createdAfter := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+
 	tests := []struct {
 		Name           string
 		QueryString    string
 		WantBody       []byte
 		WantStatusCode int
+		WantOpts       *shortener.ListOptions
 	}{
 		{
 			Name:           "LimitNotInt",
@@ -305,24 +477,75 @@ func TestList(t *testing.T) {
 			WantBody:       []byte(`{"message":"Invalid skip argument, must be integer greater than or equal to 0","statusCode":400}`),
 			WantStatusCode: http.StatusBadRequest,
 		},
+		{
+			Name:           "InvalidCursor",
+			QueryString:    "cursor=$$$",
+			WantBody:       []byte(`{"message":"List cursor is not valid: malformed cursor","statusCode":400}`),
+			WantStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:           "SkipWithCursor",
+			QueryString:    "skip=1&cursor=Y3JlYXRlZEF0IDIwMjAtMDUtMDFUMDA6MDA6MDBaIGxpbmsy",
+			WantBody:       []byte(`{"message":"Invalid arguments, skip and cursor can't be used together","statusCode":400}`),
+			WantStatusCode: http.StatusBadRequest,
+		},
 		{
 			Name:           "FirstPageOk",
 			QueryString:    "limit=2&skip=0",
-			WantBody:       []byte(`[{"slug":"link1","url":"https://link1.com/?s=google.com","createdAt":"2020-05-01T00:00:00Z"},{"slug":"link2","url":"https://link2.com/?s=google.com","createdAt":"2020-05-01T00:00:00Z"}]`),
+			WantBody:       []byte(`{"items":[{"slug":"link1","url":"https://link1.com/?s=google.com","createdAt":"2020-05-01T00:00:00Z"},{"slug":"link2","url":"https://link2.com/?s=google.com","createdAt":"2020-05-01T00:00:00Z"}],"nextCursor":"Y3JlYXRlZEF0IDIwMjAtMDUtMDFUMDA6MDA6MDBaIGxpbmsy"}`),
+			WantStatusCode: http.StatusOK,
+		},
+		{
+			Name:           "DefaultLimitOk",
+			QueryString:    "",
+			WantBody:       []byte(`{"items":[{"slug":"link1","url":"https://link1.com/?s=google.com","createdAt":"2020-05-01T00:00:00Z"},{"slug":"link2","url":"https://link2.com/?s=google.com","createdAt":"2020-05-01T00:00:00Z"}],"nextCursor":"Y3JlYXRlZEF0IDIwMjAtMDUtMDFUMDA6MDA6MDBaIGxpbmsy"}`),
 			WantStatusCode: http.StatusOK,
 		},
 		{
 			Name:           "LastPageOk",
 			QueryString:    "limit=2&skip=2",
-			WantBody:       []byte(`[{"slug":"link3","url":"https://link3.com/?s=google.com","createdAt":"2020-05-01T00:00:00Z"}]`),
+			WantBody:       []byte(`{"items":[{"slug":"link3","url":"https://link3.com/?s=google.com","createdAt":"2020-05-01T00:00:00Z"}]}`),
+			WantStatusCode: http.StatusOK,
+		},
+		{
+			Name:           "CursorPageOk",
+			QueryString:    "limit=2&cursor=Y3JlYXRlZEF0IDIwMjAtMDUtMDFUMDA6MDA6MDBaIGxpbmsy",
+			WantBody:       []byte(`{"items":[{"slug":"link3","url":"https://link3.com/?s=google.com","createdAt":"2020-05-01T00:00:00Z"}]}`),
 			WantStatusCode: http.StatusOK,
 		},
 		{
 			Name:           "AfterLastPageIsEmpty",
 			QueryString:    "limit=2&skip=4",
-			WantBody:       []byte(`[]`),
+			WantBody:       []byte(`{"items":[]}`),
 			WantStatusCode: http.StatusOK,
 		},
+		{
+			Name:           "InvalidDate",
+			QueryString:    "createdAfter=yesterday",
+			WantBody:       []byte(`{"message":"Invalid createdAfter argument, must be a RFC 3339 date","statusCode":400}`),
+			WantStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:           "InvalidSort",
+			QueryString:    "sort=slug",
+			WantBody:       []byte(`{"message":"List options are not valid: sort must be one of createdAt, -createdAt, clicks or -clicks","statusCode":400}`),
+			WantStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:           "Filters",
+			QueryString:    "limit=1&owner=alice&host=link1.com&url=google&tag=sale&createdAfter=2020-05-01T00:00:00Z&sort=-clicks",
+			WantBody:       []byte(`{"items":[{"slug":"link1","url":"https://link1.com/?s=google.com","createdAt":"2020-05-01T00:00:00Z"}],"nextCursor":"Y3JlYXRlZEF0IDIwMjAtMDUtMDFUMDA6MDA6MDBaIGxpbmsx"}`),
+			WantStatusCode: http.StatusOK,
+			WantOpts: &shortener.ListOptions{
+				Owner:        "alice",
+				Host:         "link1.com",
+				URLContains:  "google",
+				Tag:          "sale",
+				CreatedAfter: &createdAfter,
+				Sort:         shortener.SortClicksDesc,
+				Limit:        1,
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -346,6 +569,343 @@ func TestList(t *testing.T) {
 			if res.StatusCode != tc.WantStatusCode {
 				t.Errorf("Wrong status code (want, got): (%d, %d)", tc.WantStatusCode, res.StatusCode)
 			}
+
+			if tc.WantOpts != nil {
+				if diff := cmp.Diff(*tc.WantOpts, listed); diff != "" {
+					t.Errorf("Wrong list options (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateLink(t *testing.T) {
+	linkService := &mocks.FakeLinkService{
+		UpdateFn: func(ctx context.Context, l *shortener.LinkUpdate) (*shortener.Link, error) {
+			if l.Slug == "nFoun" {
+				return nil, shortener.ErrLinkNotFound
+			}
+
+			if l.Slug == "clear" {
+				if !l.ClearExpiresAt || l.MaxClicks == nil || *l.MaxClicks != 0 {
+					return nil, shortener.ErrInvalidLink
+				}
+				l.URL = "https://ok.com/allOK"
+			}
+
+			if l.URL == "" {
+				return nil, shortener.ErrInvalidLink
+			}
+
+			if l.URL == "https://server.error.dev" {
+				return nil, errors.New("UnexpectedError")
+			}
+
+			if l.URL == "javascript:alert(1)" {
+				return nil, fmt.Errorf("%w: scheme 'javascript' is not allowed", shortener.ErrURLBlocked)
+			}
+
+			return &shortener.Link{
+				URL:       l.URL,
+				Slug:      l.Slug,
+				CreatedAt: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC),
+			}, nil
+		},
+	}
+	r := router.New(linkService, &mocks.FakeClickService{}, allowAllAuth(), nil, nil, nil, nil)
+
+	server := &fasthttp.Server{
+		Handler: r.Handler,
+	}
+	ln := fasthttputil.NewInmemoryListener()
+
+	go server.Serve(ln)
+	defer server.Shutdown()
+
+	c := http.Client{
+		// use custom in memory listener to connect to server
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return ln.Dial()
+			},
+		},
+	}
+	defer c.CloseIdleConnections()
+
+	tests := []struct {
+		Name           string
+		Slug           string
+		ReqBody        []byte
+		WantBody       []byte
+		WantStatusCode int
+	}{
+		{
+			Name:           "Ok",
+			Slug:           "LolOk",
+			ReqBody:        []byte(`{"url":"https://ok.com/allOK"}`),
+			WantBody:       []byte(`{"slug":"LolOk","url":"https://ok.com/allOK","createdAt":"2020-05-01T00:00:00Z"}`),
+			WantStatusCode: http.StatusOK,
+		},
+		{
+			Name:           "NotFound",
+			Slug:           "nFoun",
+			ReqBody:        []byte(`{"url":"https://ok.com/allOK"}`),
+			WantBody:       []byte(`{"message":"Link with slug 'nFoun' not found","statusCode":404}`),
+			WantStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:           "ServerErr",
+			Slug:           "LolOk",
+			ReqBody:        []byte(`{"url":"https://server.error.dev"}`),
+			WantBody:       []byte(`{"message":"Error updating link: UnexpectedError","statusCode":500}`),
+			WantStatusCode: http.StatusInternalServerError,
+		},
+		{
+			Name:           "InvalidLink",
+			Slug:           "LolOk",
+			ReqBody:        []byte(`{"url":""}`),
+			WantBody:       []byte(`{"message":"Link is not valid","statusCode":400}`),
+			WantStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:           "URLBlocked",
+			Slug:           "LolOk",
+			ReqBody:        []byte(`{"url":"javascript:alert(1)"}`),
+			WantBody:       []byte(`{"message":"Link URL is not allowed: scheme 'javascript' is not allowed","statusCode":422}`),
+			WantStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:           "NullRemovesLimits",
+			Slug:           "clear",
+			ReqBody:        []byte(`{"expiresAt":null,"maxClicks":null}`),
+			WantBody:       []byte(`{"slug":"clear","url":"https://ok.com/allOK","createdAt":"2020-05-01T00:00:00Z"}`),
+			WantStatusCode: http.StatusOK,
+		},
+		{
+			Name:           "ZeroMaxClicksRemovesLimit",
+			Slug:           "clear",
+			ReqBody:        []byte(`{"expiresAt":null,"maxClicks":0}`),
+			WantBody:       []byte(`{"slug":"clear","url":"https://ok.com/allOK","createdAt":"2020-05-01T00:00:00Z"}`),
+			WantStatusCode: http.StatusOK,
+		},
+		{
+			Name:           "AbsentLimitsKept",
+			Slug:           "clear",
+			ReqBody:        []byte(`{"url":"https://ok.com/allOK"}`),
+			WantBody:       []byte(`{"message":"Link is not valid","statusCode":400}`),
+			WantStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:           "InvalidJSON",
+			Slug:           "LolOk",
+			ReqBody:        []byte(`{"url":"https://ok.com/allOK",`),
+			WantBody:       []byte(`{"message":"Invalid json in request body","statusCode":400}`),
+			WantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			endpoint := fmt.Sprintf("http://shortener.com/links/%s", tc.Slug)
+			req, _ := http.NewRequest(http.MethodPatch, endpoint, bytes.NewReader(tc.ReqBody))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := c.Do(req)
+			if err != nil {
+				t.Fatalf("Unexpected error requesting %s: %v", endpoint, err)
+			}
+			defer res.Body.Close()
+
+			got, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error parsing response body: %v", err)
+			}
+
+			if !bytes.Equal(tc.WantBody, got) {
+				t.Errorf("Wrong response (want, got): (%s, %s)", tc.WantBody, got)
+			}
+
+			if res.StatusCode != tc.WantStatusCode {
+				t.Errorf("Wrong status code (want, got): (%d, %d)", tc.WantStatusCode, res.StatusCode)
+			}
+		})
+	}
+}
+
+func TestDeleteLink(t *testing.T) {
+	linkService := &mocks.FakeLinkService{
+		DeleteFn: func(ctx context.Context, slug string) error {
+			if slug == "found" {
+				return nil
+			}
+
+			if slug == "nFoun" {
+				return shortener.ErrLinkNotFound
+			}
+
+			return errors.New("UnexpectedError")
+		},
+	}
+	r := router.New(linkService, &mocks.FakeClickService{}, allowAllAuth(), nil, nil, nil, nil)
+
+	server := &fasthttp.Server{
+		Handler: r.Handler,
+	}
+	ln := fasthttputil.NewInmemoryListener()
+
+	go server.Serve(ln)
+	defer server.Shutdown()
+
+	c := http.Client{
+		// use custom in memory listener to connect to server
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return ln.Dial()
+			},
+		},
+	}
+	defer c.CloseIdleConnections()
+
+	tests := []struct {
+		Name           string
+		Slug           string
+		WantBody       []byte
+		WantStatusCode int
+	}{
+		{
+			Name:           "Ok",
+			Slug:           "found",
+			WantBody:       []byte{},
+			WantStatusCode: http.StatusNoContent,
+		},
+		{
+			Name:           "NotFound",
+			Slug:           "nFoun",
+			WantBody:       []byte(`{"message":"Link with slug 'nFoun' not found","statusCode":404}`),
+			WantStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:           "ServerErr",
+			Slug:           "error",
+			WantBody:       []byte(`{"message":"Error deleting link: UnexpectedError","statusCode":500}`),
+			WantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			endpoint := fmt.Sprintf("http://shortener.com/links/%s", tc.Slug)
+			req, _ := http.NewRequest(http.MethodDelete, endpoint, nil)
+			res, err := c.Do(req)
+			if err != nil {
+				t.Fatalf("Unexpected error requesting %s: %v", endpoint, err)
+			}
+			defer res.Body.Close()
+
+			got, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error parsing response body: %v", err)
+			}
+
+			if !bytes.Equal(tc.WantBody, got) {
+				t.Errorf("Wrong response (want, got): (%s, %s)", tc.WantBody, got)
+			}
+
+			if res.StatusCode != tc.WantStatusCode {
+				t.Errorf("Wrong status code (want, got): (%d, %d)", tc.WantStatusCode, res.StatusCode)
+			}
+		})
+	}
+}
+
+func TestStats(t *testing.T) {
+	linkService := &mocks.FakeLinkService{}
+	clickService := &mocks.FakeClickService{
+		StatsFn: func(ctx context.Context, slug string) (*shortener.LinkStats, error) {
+			if slug == "found" {
+				return &shortener.LinkStats{
+					Slug:        "found",
+					TotalClicks: 3,
+					Daily: []shortener.DailyClicks{
+						{Day: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC), Clicks: 1},
+						{Day: time.Date(2020, 5, 2, 0, 0, 0, 0, time.UTC), Clicks: 2},
+					},
+				}, nil
+			}
+
+			if slug == "nFoun" {
+				return nil, shortener.ErrLinkNotFound
+			}
+
+			return nil, errors.New("UnexpectedError")
+		},
+	}
+	r := router.New(linkService, clickService, allowAllAuth(), nil, nil, nil, nil)
+
+	server := &fasthttp.Server{
+		Handler: r.Handler,
+	}
+	ln := fasthttputil.NewInmemoryListener()
+
+	go server.Serve(ln)
+	defer server.Shutdown()
+
+	c := http.Client{
+		// use custom in memory listener to connect to server
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return ln.Dial()
+			},
+		},
+	}
+	defer c.CloseIdleConnections()
+
+	tests := []struct {
+		Name           string
+		Slug           string
+		WantBody       []byte
+		WantStatusCode int
+	}{
+		{
+			Name:           "Found",
+			Slug:           "found",
+			WantBody:       []byte(`{"slug":"found","totalClicks":3,"daily":[{"day":"2020-05-01T00:00:00Z","clicks":1},{"day":"2020-05-02T00:00:00Z","clicks":2}]}`),
+			WantStatusCode: http.StatusOK,
+		},
+		{
+			Name:           "NotFound",
+			Slug:           "nFoun",
+			WantBody:       []byte(`{"message":"Link with slug 'nFoun' not found","statusCode":404}`),
+			WantStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:           "ServerErr",
+			Slug:           "error",
+			WantBody:       []byte(`{"message":"Error getting link stats: UnexpectedError","statusCode":500}`),
+			WantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			endpoint := fmt.Sprintf("http://shortener.com/links/%s/stats", tc.Slug)
+			res, err := c.Get(endpoint)
+			if err != nil {
+				t.Fatalf("Unexpected error requesting %s: %v", endpoint, err)
+			}
+			defer res.Body.Close()
+
+			got, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error parsing response body: %v", err)
+			}
+
+			if !bytes.Equal(tc.WantBody, got) {
+				t.Errorf("Wrong response (want, got): (%s, %s)", tc.WantBody, got)
+			}
+
+			if res.StatusCode != tc.WantStatusCode {
+				t.Errorf("Wrong status code (want, got): (%d, %d)", tc.WantStatusCode, res.StatusCode)
+			}
 		})
 	}
 }
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/response"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+	"github.com/valyala/fasthttp"
+)
+
+var bearerPrefix = []byte("Bearer ")
+
+// Auth is a middleware that only lets requests with a valid api key through,
+// in the "Authorization: Bearer <key>" header. The key owner is attached to
+// the request context, under shortener.OwnerKey
+func Auth(authService shortener.AuthService, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		var key string
+		header := ctx.Request.Header.Peek("Authorization")
+		if bytes.HasPrefix(header, bearerPrefix) {
+			key = string(bytes.TrimSpace(header[len(bearerPrefix):]))
+		}
+
+		apiKey, err := authService.Authenticate(ctx, key)
+		if err != nil {
+			var status int
+			var errMessage string
+
+			if errors.Is(err, shortener.ErrUnauthorized) {
+				status = http.StatusUnauthorized
+				errMessage = err.Error()
+				ctx.Response.Header.Set("WWW-Authenticate", "Bearer")
+			} else {
+				status = http.StatusInternalServerError
+				errMessage = fmt.Sprintf("Error authenticating: %s", err.Error())
+			}
+
+			ctx.SetContentType("application/json")
+			ctx.SetStatusCode(status)
+			b, _ := json.Marshal(response.HTTPErr{Message: errMessage, StatusCode: status})
+			ctx.Write(b)
+			return
+		}
+
+		ctx.SetUserValue(shortener.OwnerKey, apiKey.Owner)
+		next(ctx)
+		return
+	}
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// ClientIPKey is the user value key holding the IP of the client making a
+// request, set by ClientIP
+const ClientIPKey = "middleware.clientIP"
+
+// ParseTrustedProxies parses IPs and CIDR ranges of proxies
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	nets := []*net.IPNet{}
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", p)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", p)
+		}
+		nets = append(nets, n)
+	}
+
+	return nets, nil
+}
+
+// ClientIP is a middleware that attaches the IP the request came from to its
+// context, under ClientIPKey. Requests from trustedProxies are attributed to
+// the client IP they forwarded, in the X-Forwarded-For header
+func ClientIP(trustedProxies []*net.IPNet, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		ctx.SetUserValue(ClientIPKey, clientIP(ctx, trustedProxies))
+		next(ctx)
+		return
+	}
+}
+
+// ClientIPFromContext returns the IP attached to ctx by ClientIP, or the
+// remote IP of requests that didn't go through it
+func ClientIPFromContext(ctx *fasthttp.RequestCtx) net.IP {
+	ip, ok := ctx.UserValue(ClientIPKey).(net.IP)
+	if !ok {
+		return ctx.RemoteIP()
+	}
+	return ip
+}
+
+// clientIP is the IP the request came from. When it came through trusted
+// proxies, it's the last X-Forwarded-For address that isn't a trusted proxy,
+// since clients can send the header with any addresses they want
+func clientIP(ctx *fasthttp.RequestCtx, trustedProxies []*net.IPNet) net.IP {
+	ip := ctx.RemoteIP()
+	if !trusted(trustedProxies, ip) {
+		return ip
+	}
+
+	forwarded := bytes.Split(ctx.Request.Header.Peek("X-Forwarded-For"), []byte(","))
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		forwardedIP := net.ParseIP(string(bytes.TrimSpace(forwarded[i])))
+		if forwardedIP == nil {
+			break
+		}
+
+		ip = forwardedIP
+		if !trusted(trustedProxies, ip) {
+			break
+		}
+	}
+
+	return ip
+}
+
+func trusted(trustedProxies []*net.IPNet, ip net.IP) bool {
+	for _, n := range trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
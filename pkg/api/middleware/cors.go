@@ -10,7 +10,7 @@ func Cors(next fasthttp.RequestHandler) fasthttp.RequestHandler {
 		ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
 		ctx.Response.Header.Set(
 			"Access-Control-Allow-Methods",
-			"POST, GET, OPTIONS, PUT, DELETE",
+			"POST, GET, OPTIONS, PUT, PATCH, DELETE",
 		)
 		ctx.Response.Header.Set(
 			"Access-Control-Allow-Headers",
@@ -10,19 +10,33 @@ import (
 	"github.com/joao-fontenele/go-url-shortener/pkg/metrics"
 )
 
-// Metrics is a middleware that handles default route metrics
-func Metrics(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+// Metrics is a middleware that handles default route metrics. route must be
+// the template the handler is registered with, like /{slug}, since labeling by
+// the requested path would create a series per link
+func Metrics(route string, next fasthttp.RequestHandler) fasthttp.RequestHandler {
 	return func(ctx *fasthttp.RequestCtx) {
+		start := time.Now()
 		defer func() {
 			statusCode := strconv.Itoa(ctx.Response.StatusCode())
-			route := string(ctx.Path())
+			method := string(ctx.Method())
 
 			metrics.HTTPRequestsCounter.With(
-				prometheus.Labels{"route": route, "status": statusCode},
+				prometheus.Labels{"route": route, "method": method, "status": statusCode},
 			).Inc()
 			metrics.HTTPRequestsDurationHistogram.With(
-				prometheus.Labels{"route": route, "status": statusCode},
-			).Observe(time.Since(ctx.ConnTime()).Seconds())
+				prometheus.Labels{"route": route, "method": method, "status": statusCode},
+			).Observe(time.Since(start).Seconds())
+
+			metrics.HTTPRequestSizeHistogram.With(
+				prometheus.Labels{"route": route, "method": method},
+			).Observe(float64(len(ctx.Request.Body())))
+
+			// reading the body of a streamed response would consume the stream
+			if !ctx.Response.IsBodyStream() {
+				metrics.HTTPResponseSizeHistogram.With(
+					prometheus.Labels{"route": route, "method": method},
+				).Observe(float64(len(ctx.Response.Body())))
+			}
 		}()
 		next(ctx)
 		return
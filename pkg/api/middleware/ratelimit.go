@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/api/response"
+	"github.com/joao-fontenele/go-url-shortener/pkg/logger"
+	"github.com/joao-fontenele/go-url-shortener/pkg/metrics"
+	"github.com/joao-fontenele/go-url-shortener/pkg/ratelimit"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+	"go.uber.org/zap"
+)
+
+// RateLimit limits requests per route, by client. Authenticated clients are
+// identified by their owner, and others by their IP, see ClientIP
+type RateLimit struct {
+	limiter ratelimit.Limiter
+	limits  map[string]ratelimit.Limit
+}
+
+// NewRateLimit instantiates a RateLimit, given the limits of each route name
+func NewRateLimit(limiter ratelimit.Limiter, limits map[string]ratelimit.Limit) *RateLimit {
+	return &RateLimit{
+		limiter: limiter,
+		limits:  limits,
+	}
+}
+
+// seconds rounds a duration up to whole seconds, as used in headers
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// Handler is a middleware that limits requests to route. Routes without a
+// configured limit, or a nil RateLimit, don't limit anything
+func (rl *RateLimit) Handler(route string, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	if rl == nil {
+		return next
+	}
+
+	limit, ok := rl.limits[route]
+	if !ok {
+		return next
+	}
+
+	return func(ctx *fasthttp.RequestCtx) {
+		client := "owner:" + shortener.OwnerFromContext(ctx)
+		if client == "owner:" {
+			client = "ip:" + ClientIPFromContext(ctx).String()
+		}
+
+		r, err := rl.limiter.Allow(ctx, route+"^"+client, limit)
+		if err != nil {
+			// don't turn limiter failures into an outage
+			logger.Get().Error("Failed to rate limit request", zap.String("route", route), zap.Error(err))
+			metrics.RateLimitRequestsCounter.With(prometheus.Labels{"route": route, "result": "error"}).Inc()
+			next(ctx)
+			return
+		}
+
+		ctx.Response.Header.Set("RateLimit-Limit", strconv.Itoa(r.Limit))
+		ctx.Response.Header.Set("RateLimit-Remaining", strconv.Itoa(r.Remaining))
+		ctx.Response.Header.Set("RateLimit-Reset", seconds(r.ResetAfter))
+
+		if !r.Allowed {
+			metrics.RateLimitRequestsCounter.With(prometheus.Labels{"route": route, "result": "limited"}).Inc()
+
+			status := http.StatusTooManyRequests
+			ctx.Response.Header.Set("Retry-After", seconds(r.RetryAfter))
+			ctx.SetContentType("application/json")
+			ctx.SetStatusCode(status)
+			b, _ := json.Marshal(response.HTTPErr{Message: "Too many requests", StatusCode: status})
+			ctx.Write(b)
+			return
+		}
+
+		metrics.RateLimitRequestsCounter.With(prometheus.Labels{"route": route, "result": "allowed"}).Inc()
+		next(ctx)
+		return
+	}
+}
@@ -1,5 +1,7 @@
 package response
 
+import "time"
+
 // HTTPErr represents an error response for the server
 type HTTPErr struct {
 	Message    string `json:"message"`
@@ -10,3 +12,25 @@ type HTTPErr struct {
 type StatusResponse struct {
 	Running bool `json:"running"`
 }
+
+// HealthResponse represents the schema of the /internal/health routes response
+type HealthResponse struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyHealth `json:"dependencies,omitempty"`
+}
+
+// DependencyHealth is the result of checking a dependency of the api
+type DependencyHealth struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// LinkPreview represents the schema of a link preview, it only has attributes
+// that are safe to show to anyone with the short url
+type LinkPreview struct {
+	Slug      string    `json:"slug"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -1,6 +1,8 @@
 package router
 
 import (
+	"net"
+
 	"github.com/fasthttp/router"
 	"github.com/joao-fontenele/go-url-shortener/pkg/api/handler"
 	"github.com/joao-fontenele/go-url-shortener/pkg/api/middleware"
@@ -10,17 +12,30 @@ import (
 	"github.com/valyala/fasthttp/fasthttpadaptor"
 )
 
-// New configures routes and it's handlers, and return it
-func New(linkService shortener.LinkService) *router.Router {
+// New configures routes and it's handlers, and return it. Routes under /links
+// require an api key, while redirects are public. Routes are rate limited by
+// name: create, list, import, export, update, delete, stats, qr and redirect.
+// Routes under /links are also limited as auth, by IP, before the api key is
+// checked, so clients guessing keys are limited too. Requests from
+// trustedProxies are attributed to the client IP they forwarded.
+// healthChecks are the dependencies checked by the readiness route, and
+// qrCodes configures the QR codes of links, the qr route is only served when
+// it's set
+func New(
+	linkService shortener.LinkService,
+	clickService shortener.ClickService,
+	authService shortener.AuthService,
+	trustedProxies []*net.IPNet,
+	rateLimit *middleware.RateLimit,
+	healthChecks []handler.HealthCheck,
+	qrCodes *handler.QRCodes,
+) *router.Router {
 	router := router.New()
 
-	internalHandler := &handler.InternalHandler{}
-	router.GET(
-		"/internal/status",
-		middleware.Logger(
-			middleware.Metrics(internalHandler.StatusHandler),
-		),
-	)
+	internalHandler := &handler.InternalHandler{HealthChecks: healthChecks}
+	handle(router, fasthttp.MethodGet, "/internal/status", internalHandler.StatusHandler)
+	handle(router, fasthttp.MethodGet, "/internal/health/live", internalHandler.LiveHandler)
+	handle(router, fasthttp.MethodGet, "/internal/health/ready", internalHandler.ReadyHandler)
 	router.GET(
 		"/internal/metrics",
 		middleware.Logger(
@@ -28,34 +43,59 @@ func New(linkService shortener.LinkService) *router.Router {
 		),
 	)
 
-	linkHandler := &handler.ShortenerHandler{LinkService: linkService}
+	linkHandler := &handler.ShortenerHandler{
+		LinkService:  linkService,
+		ClickService: clickService,
+	}
+
+	// authed limits requests by IP before checking their api key, and then by
+	// owner, as the route name
+	authed := func(name string, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return middleware.Cors(
+			middleware.ClientIP(
+				trustedProxies,
+				rateLimit.Handler(
+					"auth",
+					middleware.Auth(
+						authService,
+						rateLimit.Handler(name, next),
+					),
+				),
+			),
+		)
+	}
+
 	router.OPTIONS("/links", middleware.Cors(func(ctx *fasthttp.RequestCtx) {
 		return
 	}))
-	router.POST(
-		"/links",
-		middleware.Logger(
-			middleware.Metrics(
-				middleware.Cors(linkHandler.NewLink),
-			),
-		),
-	)
-	router.GET(
-		"/links",
-		middleware.Logger(
-			middleware.Metrics(
-				middleware.Cors(linkHandler.List),
-			),
-		),
-	)
-	router.GET(
+	handle(router, fasthttp.MethodPost, "/links", authed("create", linkHandler.NewLink))
+	handle(router, fasthttp.MethodGet, "/links", authed("list", linkHandler.List))
+	handle(router, fasthttp.MethodPost, "/links/import", authed("import", linkHandler.Import))
+	handle(router, fasthttp.MethodGet, "/links/export", authed("export", linkHandler.Export))
+	router.OPTIONS("/links/{slug}", middleware.Cors(func(ctx *fasthttp.RequestCtx) {
+		return
+	}))
+	handle(router, fasthttp.MethodPatch, "/links/{slug}", authed("update", linkHandler.Update))
+	handle(router, fasthttp.MethodDelete, "/links/{slug}", authed("delete", linkHandler.Delete))
+	handle(router, fasthttp.MethodGet, "/links/{slug}/stats", authed("stats", linkHandler.Stats))
+	if qrCodes != nil {
+		linkHandler.QRCodes = *qrCodes
+		handle(router, fasthttp.MethodGet, "/links/{slug}/qr", authed("qr", linkHandler.QRCode))
+	}
+	handle(
+		router,
+		fasthttp.MethodGet,
 		"/{slug}",
-		middleware.Logger(
-			middleware.Metrics(
-				middleware.Cors(linkHandler.Redirect),
-			),
+		middleware.Cors(
+			middleware.ClientIP(trustedProxies, rateLimit.Handler("redirect", linkHandler.Redirect)),
 		),
 	)
 
 	return router
 }
+
+// handle registers next for requests to method and path, logged and with
+// metrics labeled by path, so the label always matches the registered route
+func handle(r *router.Router, method, path string, next fasthttp.RequestHandler) {
+	r.Handle(method, path, middleware.Logger(middleware.Metrics(path, next)))
+}
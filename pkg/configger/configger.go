@@ -18,23 +18,80 @@ type database struct {
 	User       string `mapstructure:"user"`
 	Pass       string `mapstructure:"pass"`
 	SSLMode    string `mapstructure:"sslMode"`
+
+	MigrateOnStartup bool `mapstructure:"migrateOnStartup"`
 }
 
 type cache struct {
-	ConnectURL      string `mapstructure:"connectURL"`
-	Host            string `mapstructure:"host"`
-	Port            string `mapstructure:"port"`
-	CachePrefix     string `mapstructure:"cachePrefix"`
-	LinksTTLSeconds int    `mapstructure:"linksTTLSeconds"`
+	ConnectURL         string `mapstructure:"connectURL"`
+	Host               string `mapstructure:"host"`
+	Port               string `mapstructure:"port"`
+	CachePrefix        string `mapstructure:"cachePrefix"`
+	LinksTTLSeconds    int    `mapstructure:"linksTTLSeconds"`
+	NotFoundTTLSeconds int    `mapstructure:"notFoundTTLSeconds"`
+	MemorySize         int    `mapstructure:"memorySize"`
+	MemoryTTLSeconds   int    `mapstructure:"memoryTTLSeconds"`
+}
+
+type links struct {
+	ReservedSlugs       []string `mapstructure:"reservedSlugs"`
+	SlugGenerator       string   `mapstructure:"slugGenerator"`
+	StripTrackingParams bool     `mapstructure:"stripTrackingParams"`
+	TrackingParams      []string `mapstructure:"trackingParams"`
+	DedupeURLs          bool     `mapstructure:"dedupeURLs"`
+	ListLimit           int      `mapstructure:"listLimit"`
+	ListMaxLimit        int      `mapstructure:"listMaxLimit"`
+	RedirectType        int      `mapstructure:"redirectType"`
+}
+
+type urlPolicy struct {
+	AllowedSchemes         []string `mapstructure:"allowedSchemes"`
+	AllowedDomains         []string `mapstructure:"allowedDomains"`
+	BlockedDomains         []string `mapstructure:"blockedDomains"`
+	BlocklistFile          string   `mapstructure:"blocklistFile"`
+	BlocklistReloadSeconds int      `mapstructure:"blocklistReloadSeconds"`
+	SelfDomains            []string `mapstructure:"selfDomains"`
+	AllowPrivateIPs        bool     `mapstructure:"allowPrivateIPs"`
+}
+
+type analytics struct {
+	BufferSize      int `mapstructure:"bufferSize"`
+	BatchSize       int `mapstructure:"batchSize"`
+	FlushIntervalMs int `mapstructure:"flushIntervalMs"`
+}
+
+type rateLimitRoute struct {
+	Requests      int `mapstructure:"requests"`
+	WindowSeconds int `mapstructure:"windowSeconds"`
+}
+
+type qrCode struct {
+	Enabled   bool `mapstructure:"enabled"`
+	CacheSize int  `mapstructure:"cacheSize"`
+}
+
+type rateLimit struct {
+	Enabled bool                      `mapstructure:"enabled"`
+	Routes  map[string]rateLimitRoute `mapstructure:"routes"`
 }
 
 // Config holds all applications configs
 type Config struct {
 	Env          string
-	DBConnectURL string   `mapstructure:"dbURL"`
-	Port         string   `mapstructure:"port"`
-	Database     database `mapstructure:"database"`
-	Cache        cache    `mapstructure:"cache"`
+	DBConnectURL string    `mapstructure:"dbURL"`
+	Port         string    `mapstructure:"port"`
+	Database     database  `mapstructure:"database"`
+	Cache        cache     `mapstructure:"cache"`
+	Links        links     `mapstructure:"links"`
+	URLPolicy    urlPolicy `mapstructure:"urlPolicy"`
+	Analytics    analytics `mapstructure:"analytics"`
+	RateLimit    rateLimit `mapstructure:"rateLimit"`
+	QRCode       qrCode    `mapstructure:"qrCode"`
+	PublicURL    string    `mapstructure:"publicURL"`
+
+	TrustedProxies []string `mapstructure:"trustedProxies"`
+
+	ShutdownTimeoutSeconds int `mapstructure:"shutdownTimeoutSeconds"`
 }
 
 // Load configs from ./config/ yml files depending on APP_ENV.
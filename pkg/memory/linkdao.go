@@ -0,0 +1,208 @@
+package memory
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+// entry is a cached link, or a slug cached as not found when link is nil
+type entry struct {
+	slug      string
+	link      *shortener.Link
+	expiresAt time.Time
+}
+
+// LinkDao caches links in memory, it's safe for concurrent use
+type LinkDao struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// lru holds entries from the most to the least recently used
+	lru *list.List
+}
+
+// NewLinkDao instantiates a dao for Link in memory, holding at most size links
+// for ttl. When full, the least recently used link is evicted. It's meant as a
+// cache tier in front of redis, for the most accessed links
+func NewLinkDao(size int, ttl time.Duration) *LinkDao {
+	return newLinkDao(size, ttl, time.Now)
+}
+
+var _ shortener.LinkDao = &LinkDao{}
+
+func newLinkDao(size int, ttl time.Duration, now func() time.Time) *LinkDao {
+	return &LinkDao{
+		size:    size,
+		ttl:     ttl,
+		now:     now,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// linkTTL is the cache expiration for a link, it shouldn't outlive the link
+// itself. Returns a non positive duration if the link is already expired
+func (d *LinkDao) linkTTL(l *shortener.Link) time.Duration {
+	if l.ExpiresAt != nil {
+		remaining := l.ExpiresAt.Sub(d.now())
+		if remaining < d.ttl {
+			return remaining
+		}
+	}
+
+	return d.ttl
+}
+
+func (d *LinkDao) Find(ctx context.Context, slug string) (*shortener.Link, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	el, ok := d.entries[slug]
+	if !ok {
+		return nil, shortener.ErrCacheMiss
+	}
+
+	e := el.Value.(*entry)
+	if !d.now().Before(e.expiresAt) {
+		d.remove(el)
+		return nil, shortener.ErrCacheMiss
+	}
+
+	d.lru.MoveToFront(el)
+	if e.link == nil {
+		return nil, shortener.ErrLinkNotFound
+	}
+
+	// callers get a copy, so they can't change the cached link
+	link := *e.link
+	return &link, nil
+}
+
+func (d *LinkDao) Insert(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.set(l)
+	return l, nil
+}
+
+func (d *LinkDao) InsertMany(ctx context.Context, links []shortener.Link) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for i := range links {
+		d.set(&links[i])
+	}
+	return nil
+}
+
+// MarkNotFound caches that a slug doesn't exist, for the same ttl as links
+func (d *LinkDao) MarkNotFound(ctx context.Context, slug string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.put(&entry{slug: slug, expiresAt: d.now().Add(d.ttl)})
+	return nil
+}
+
+// Update only overwrites links that are already cached
+func (d *LinkDao) Update(ctx context.Context, l *shortener.Link) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	el, ok := d.entries[l.Slug]
+	if !ok || el.Value.(*entry).link == nil {
+		return shortener.ErrLinkNotFound
+	}
+
+	d.set(l)
+	return nil
+}
+
+func (d *LinkDao) Delete(ctx context.Context, slug string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if el, ok := d.entries[slug]; ok {
+		d.remove(el)
+	}
+	return nil
+}
+
+// Purge removes every cached link
+func (d *LinkDao) Purge() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.entries = make(map[string]*list.Element)
+	d.lru.Init()
+}
+
+// FindByURL always misses, since links are only cached by slug
+func (d *LinkDao) FindByURL(ctx context.Context, owner, url string) (*shortener.Link, error) {
+	return nil, shortener.ErrCacheMiss
+}
+
+// ExistingSlugs always misses, since the cache only holds some of the links
+func (d *LinkDao) ExistingSlugs(ctx context.Context, slugs []string) ([]string, error) {
+	return nil, shortener.ErrCacheMiss
+}
+
+// IncrementClicks always misses, since click counters are only kept in the db
+func (d *LinkDao) IncrementClicks(ctx context.Context, slug string) (int, error) {
+	return 0, shortener.ErrCacheMiss
+}
+
+// List always misses, since the cache only holds some of the links
+func (d *LinkDao) List(ctx context.Context, opts shortener.ListOptions) ([]shortener.Link, error) {
+	return nil, shortener.ErrCacheMiss
+}
+
+// set caches a copy of l, or removes it if it's already expired. Must be
+// called with the lock held
+func (d *LinkDao) set(l *shortener.Link) {
+	ttl := d.linkTTL(l)
+	if ttl <= 0 {
+		// there's no point in caching expired links
+		if el, ok := d.entries[l.Slug]; ok {
+			d.remove(el)
+		}
+		return
+	}
+
+	link := *l
+	d.put(&entry{slug: l.Slug, link: &link, expiresAt: d.now().Add(ttl)})
+}
+
+// put adds or replaces an entry as the most recently used, evicting the least
+// recently used entries if there's no room. Must be called with the lock held
+func (d *LinkDao) put(e *entry) {
+	if d.size <= 0 {
+		return
+	}
+
+	if el, ok := d.entries[e.slug]; ok {
+		el.Value = e
+		d.lru.MoveToFront(el)
+		return
+	}
+
+	for d.lru.Len() >= d.size {
+		d.remove(d.lru.Back())
+	}
+
+	d.entries[e.slug] = d.lru.PushFront(e)
+}
+
+// remove must be called with the lock held
+func (d *LinkDao) remove(el *list.Element) {
+	d.lru.Remove(el)
+	delete(d.entries, el.Value.(*entry).slug)
+}
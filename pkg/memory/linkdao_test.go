@@ -0,0 +1,180 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestDao(size int) (*LinkDao, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)}
+	return newLinkDao(size, time.Minute, clock.Now), clock
+}
+
+func TestFind(t *testing.T) {
+	d, clock := newTestDao(10)
+	ctx := context.Background()
+	link := &shortener.Link{Slug: "aaaaa", URL: "https://www.google.com"}
+
+	if _, err := d.Find(ctx, "aaaaa"); !errors.Is(err, shortener.ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss for link not cached, but got: %v", err)
+	}
+
+	d.Insert(ctx, link)
+	got, err := d.Find(ctx, "aaaaa")
+	if err != nil {
+		t.Fatalf("Unexpected error finding cached link: %v", err)
+	}
+
+	if diff := cmp.Diff(link, got); diff != "" {
+		t.Errorf("Found link different from expected (-want +got):\n%s", diff)
+	}
+
+	got.URL = "https://www.duckduckgo.com"
+	if again, _ := d.Find(ctx, "aaaaa"); again.URL != link.URL {
+		t.Error("Expected changes to found links to not change the cached link")
+	}
+
+	d.MarkNotFound(ctx, "n0tFd")
+	if _, err := d.Find(ctx, "n0tFd"); !errors.Is(err, shortener.ErrLinkNotFound) {
+		t.Errorf("Expected ErrLinkNotFound for slug marked as not found, but got: %v", err)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	for _, slug := range []string{"aaaaa", "n0tFd"} {
+		if _, err := d.Find(ctx, slug); !errors.Is(err, shortener.ErrCacheMiss) {
+			t.Errorf("Expected %s to have expired, but got: %v", slug, err)
+		}
+	}
+
+	if len(d.entries) != 0 || d.lru.Len() != 0 {
+		t.Errorf("Expected expired entries to be removed, but got %d", len(d.entries))
+	}
+}
+
+func TestInsertExpiringLink(t *testing.T) {
+	d, clock := newTestDao(10)
+	ctx := context.Background()
+
+	soon := clock.now.Add(10 * time.Second)
+	d.Insert(ctx, &shortener.Link{Slug: "s00nn", URL: "https://www.google.com", ExpiresAt: &soon})
+
+	past := clock.now.Add(-time.Second)
+	d.Insert(ctx, &shortener.Link{Slug: "p4stt", URL: "https://www.google.com", ExpiresAt: &past})
+
+	if _, err := d.Find(ctx, "p4stt"); !errors.Is(err, shortener.ErrCacheMiss) {
+		t.Errorf("Expected expired link to not be cached, but got: %v", err)
+	}
+
+	clock.now = soon
+	if _, err := d.Find(ctx, "s00nn"); !errors.Is(err, shortener.ErrCacheMiss) {
+		t.Errorf("Expected link to not be cached past its expiration, but got: %v", err)
+	}
+}
+
+func TestEviction(t *testing.T) {
+	d, _ := newTestDao(3)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		d.Insert(ctx, &shortener.Link{Slug: fmt.Sprintf("s%d", i), URL: "https://www.google.com"})
+	}
+
+	// s0 becomes the most recently used, so s1 is the one evicted
+	d.Find(ctx, "s0")
+	d.InsertMany(ctx, []shortener.Link{{Slug: "s3", URL: "https://www.google.com"}})
+
+	if _, err := d.Find(ctx, "s1"); !errors.Is(err, shortener.ErrCacheMiss) {
+		t.Errorf("Expected least recently used link to be evicted, but got: %v", err)
+	}
+
+	for _, slug := range []string{"s0", "s2", "s3"} {
+		if _, err := d.Find(ctx, slug); err != nil {
+			t.Errorf("Expected %s to be cached, but got: %v", slug, err)
+		}
+	}
+
+	if len(d.entries) != 3 {
+		t.Errorf("Expected cache to be bounded to 3 links, but got %d", len(d.entries))
+	}
+}
+
+func TestUpdateAndDelete(t *testing.T) {
+	d, _ := newTestDao(10)
+	ctx := context.Background()
+
+	err := d.Update(ctx, &shortener.Link{Slug: "aaaaa", URL: "https://www.google.com"})
+	if !errors.Is(err, shortener.ErrLinkNotFound) {
+		t.Errorf("Expected links not cached to not be updated, but got: %v", err)
+	}
+
+	d.Insert(ctx, &shortener.Link{Slug: "aaaaa", URL: "https://www.google.com"})
+	err = d.Update(ctx, &shortener.Link{Slug: "aaaaa", URL: "https://www.duckduckgo.com"})
+	if err != nil {
+		t.Fatalf("Unexpected error updating link: %v", err)
+	}
+
+	if got, _ := d.Find(ctx, "aaaaa"); got.URL != "https://www.duckduckgo.com" {
+		t.Errorf("Expected cached link to be updated, but got: %v", got)
+	}
+
+	d.Delete(ctx, "aaaaa")
+	if _, err := d.Find(ctx, "aaaaa"); !errors.Is(err, shortener.ErrCacheMiss) {
+		t.Errorf("Expected deleted link to not be cached, but got: %v", err)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	d, _ := newTestDao(10)
+	ctx := context.Background()
+
+	d.Insert(ctx, &shortener.Link{Slug: "aaaaa", URL: "https://www.google.com"})
+	d.MarkNotFound(ctx, "n0tFd")
+	d.Purge()
+
+	for _, slug := range []string{"aaaaa", "n0tFd"} {
+		if _, err := d.Find(ctx, slug); !errors.Is(err, shortener.ErrCacheMiss) {
+			t.Errorf("Expected %s to have been purged, but got: %v", slug, err)
+		}
+	}
+
+	d.Insert(ctx, &shortener.Link{Slug: "aaaaa", URL: "https://www.google.com"})
+	if _, err := d.Find(ctx, "aaaaa"); err != nil {
+		t.Errorf("Expected links to be cached after a purge, but got: %v", err)
+	}
+}
+
+func TestUncachedLookupsMiss(t *testing.T) {
+	d, _ := newTestDao(10)
+	ctx := context.Background()
+	d.Insert(ctx, &shortener.Link{Slug: "aaaaa", URL: "https://www.google.com"})
+
+	if _, err := d.FindByURL(ctx, "", "https://www.google.com"); !errors.Is(err, shortener.ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss finding by url, but got: %v", err)
+	}
+
+	if _, err := d.ExistingSlugs(ctx, []string{"aaaaa"}); !errors.Is(err, shortener.ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss checking existing slugs, but got: %v", err)
+	}
+
+	if _, err := d.IncrementClicks(ctx, "aaaaa"); !errors.Is(err, shortener.ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss incrementing clicks, but got: %v", err)
+	}
+
+	if _, err := d.List(ctx, shortener.ListOptions{}); !errors.Is(err, shortener.ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss listing links, but got: %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package metrics
 import (
 	"context"
 	"errors"
+	"net"
 	"time"
 
 	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
@@ -24,17 +25,37 @@ func NewLinkDao(dao shortener.LinkDao, name string) shortener.LinkDao {
 
 var _ shortener.LinkDao = &daoWrapper{}
 
+// operationResult classifies the outcome of a DAO operation. Links not found
+// aren't failures, but are told apart from successes
+func operationResult(err error) string {
+	if err == nil {
+		return "success"
+	}
+
+	if errors.Is(err, shortener.ErrLinkNotFound) || errors.Is(err, shortener.ErrCacheMiss) {
+		return "not_found"
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return "canceled"
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return "timeout"
+	}
+
+	return "other"
+}
+
+// apm must be called right after the operation, with start taken right before it
 func apm(err error, name string, operation string, start time.Time) {
 	DAOOperationsDurationHistogram.With(
 		prometheus.Labels{"name": name, "operation": operation},
 	).Observe(time.Since(start).Seconds())
 
-	result := "error"
-	if err == nil || errors.Is(err, shortener.ErrLinkNotFound) {
-		result = "success"
-	}
 	DAOOperationsCounter.With(
-		prometheus.Labels{"name": name, "result": result},
+		prometheus.Labels{"name": name, "operation": operation, "result": operationResult(err)},
 	).Inc()
 }
 
@@ -43,7 +64,7 @@ func (dw *daoWrapper) Find(ctx context.Context, slug string) (*shortener.Link, e
 	l, err := dw.dao.Find(ctx, slug)
 
 	findResult := "hit"
-	if errors.Is(err, shortener.ErrLinkNotFound) {
+	if errors.Is(err, shortener.ErrLinkNotFound) || errors.Is(err, shortener.ErrCacheMiss) {
 		findResult = "miss"
 	}
 	DAOFindResultCounter.With(
@@ -55,26 +76,65 @@ func (dw *daoWrapper) Find(ctx context.Context, slug string) (*shortener.Link, e
 	return l, err
 }
 
+func (dw *daoWrapper) FindByURL(ctx context.Context, owner, url string) (*shortener.Link, error) {
+	start := time.Now()
+	l, err := dw.dao.FindByURL(ctx, owner, url)
+	apm(err, dw.name, "find_by_url", start)
+	return l, err
+}
+
+func (dw *daoWrapper) ExistingSlugs(ctx context.Context, slugs []string) ([]string, error) {
+	start := time.Now()
+	existing, err := dw.dao.ExistingSlugs(ctx, slugs)
+	apm(err, dw.name, "existing_slugs", start)
+	return existing, err
+}
+
+func (dw *daoWrapper) MarkNotFound(ctx context.Context, slug string) error {
+	start := time.Now()
+	err := dw.dao.MarkNotFound(ctx, slug)
+	apm(err, dw.name, "mark_not_found", start)
+	return err
+}
+
 func (dw *daoWrapper) Insert(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
+	start := time.Now()
 	l, err := dw.dao.Insert(ctx, l)
-	apm(err, dw.name, "insert", time.Now())
+	apm(err, dw.name, "insert", start)
 	return l, err
 }
 
+func (dw *daoWrapper) InsertMany(ctx context.Context, links []shortener.Link) error {
+	start := time.Now()
+	err := dw.dao.InsertMany(ctx, links)
+	apm(err, dw.name, "insert_many", start)
+	return err
+}
+
 func (dw *daoWrapper) Update(ctx context.Context, l *shortener.Link) error {
+	start := time.Now()
 	err := dw.dao.Update(ctx, l)
-	apm(err, dw.name, "update", time.Now())
+	apm(err, dw.name, "update", start)
 	return err
 }
 
 func (dw *daoWrapper) Delete(ctx context.Context, slug string) error {
+	start := time.Now()
 	err := dw.dao.Delete(ctx, slug)
-	apm(err, dw.name, "delete", time.Now())
+	apm(err, dw.name, "delete", start)
 	return err
 }
 
-func (dw *daoWrapper) List(ctx context.Context, limit, skip int) ([]shortener.Link, error) {
-	links, err := dw.dao.List(ctx, limit, skip)
-	apm(err, dw.name, "list", time.Now())
+func (dw *daoWrapper) List(ctx context.Context, opts shortener.ListOptions) ([]shortener.Link, error) {
+	start := time.Now()
+	links, err := dw.dao.List(ctx, opts)
+	apm(err, dw.name, "list", start)
 	return links, err
 }
+
+func (dw *daoWrapper) IncrementClicks(ctx context.Context, slug string) (int, error) {
+	start := time.Now()
+	clicks, err := dw.dao.IncrementClicks(ctx, slug)
+	apm(err, dw.name, "increment_clicks", start)
+	return clicks, err
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/joao-fontenele/go-url-shortener/pkg/metrics"
 	"github.com/joao-fontenele/go-url-shortener/pkg/mocks"
 	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+	"github.com/prometheus/client_golang/prometheus"
 	promtest "github.com/prometheus/client_golang/prometheus/testutil"
 )
 
@@ -24,6 +25,9 @@ func resetMetrics() {
 	metrics.DAOFindResultCounter.Reset()
 	metrics.DAOOperationsCounter.Reset()
 	metrics.DAOOperationsDurationHistogram.Reset()
+	metrics.AnalyticsClicksCounter.Reset()
+	metrics.SlugAttemptsCounter.Reset()
+	metrics.RateLimitRequestsCounter.Reset()
 }
 
 func testMain(m *testing.M) int {
@@ -49,6 +53,9 @@ func TestFind(t *testing.T) {
 	errNotFound := func(ctx context.Context, slug string) (*shortener.Link, error) {
 		return nil, shortener.ErrLinkNotFound
 	}
+	errCacheMiss := func(ctx context.Context, slug string) (*shortener.Link, error) {
+		return nil, shortener.ErrCacheMiss
+	}
 	UnexpectedErr := func(ctx context.Context, slug string) (*shortener.Link, error) {
 		return nil, unexpectedErr
 	}
@@ -72,15 +79,23 @@ func TestFind(t *testing.T) {
 		{
 			Name:                "ErrLinkNotFound",
 			FindFn:              errNotFound,
-			ExpectedLabelResult: "success",
+			ExpectedLabelResult: "not_found",
 			ExpectedLabelHit:    "miss",
 			ExpectedErr:         shortener.ErrLinkNotFound,
 			ExpectedLink:        nil,
 		},
+		{
+			Name:                "ErrCacheMiss",
+			FindFn:              errCacheMiss,
+			ExpectedLabelResult: "not_found",
+			ExpectedLabelHit:    "miss",
+			ExpectedErr:         shortener.ErrCacheMiss,
+			ExpectedLink:        nil,
+		},
 		{
 			Name:                "UnexpectedErr",
 			FindFn:              UnexpectedErr,
-			ExpectedLabelResult: "error",
+			ExpectedLabelResult: "other",
 			ExpectedLabelHit:    "hit",
 			ExpectedErr:         unexpectedErr,
 			ExpectedLink:        nil,
@@ -127,9 +142,9 @@ func TestFind(t *testing.T) {
 
 			metricsText = fmt.Sprintf(
 				`
-				# HELP dao_operations_total Total DAO operations by result (error/success)
+				# HELP dao_operations_total Total DAO operations by operation and result (success/not_found/timeout/canceled/other)
 				# TYPE dao_operations_total counter
-				dao_operations_total{name="%s",result="%s"} 1
+				dao_operations_total{name="%s",operation="find",result="%s"} 1
 				`,
 				daoName,
 				tc.ExpectedLabelResult,
@@ -143,9 +158,6 @@ func TestFind(t *testing.T) {
 			if err != nil {
 				t.Errorf("Error comparing dao_operations_total metric: %v", err)
 			}
-
-			// TODO: test histogram metrics, and find a way to spy on prometheus metrics
-			// without having to collect the metrics themselves
 		})
 	}
 }
@@ -162,6 +174,12 @@ func TestDelete(t *testing.T) {
 	UnexpectedErr := func(ctx context.Context, slug string) error {
 		return unexpectedErr
 	}
+	errTimeout := func(ctx context.Context, slug string) error {
+		return fmt.Errorf("failed to delete: %w", context.DeadlineExceeded)
+	}
+	errCanceled := func(ctx context.Context, slug string) error {
+		return fmt.Errorf("failed to delete: %w", context.Canceled)
+	}
 
 	tests := []struct {
 		Name                string
@@ -178,15 +196,27 @@ func TestDelete(t *testing.T) {
 		{
 			Name:                "ErrLinkNotFound",
 			DeleteFn:            errNotFound,
-			ExpectedLabelResult: "success",
+			ExpectedLabelResult: "not_found",
 			ExpectedErr:         shortener.ErrLinkNotFound,
 		},
 		{
 			Name:                "UnexpectedErr",
 			DeleteFn:            UnexpectedErr,
-			ExpectedLabelResult: "error",
+			ExpectedLabelResult: "other",
 			ExpectedErr:         unexpectedErr,
 		},
+		{
+			Name:                "Timeout",
+			DeleteFn:            errTimeout,
+			ExpectedLabelResult: "timeout",
+			ExpectedErr:         context.DeadlineExceeded,
+		},
+		{
+			Name:                "Canceled",
+			DeleteFn:            errCanceled,
+			ExpectedLabelResult: "canceled",
+			ExpectedErr:         context.Canceled,
+		},
 	}
 
 	for _, tc := range tests {
@@ -206,9 +236,9 @@ func TestDelete(t *testing.T) {
 
 			metricsText := fmt.Sprintf(
 				`
-				# HELP dao_operations_total Total DAO operations by result (error/success)
+				# HELP dao_operations_total Total DAO operations by operation and result (success/not_found/timeout/canceled/other)
 				# TYPE dao_operations_total counter
-				dao_operations_total{name="%s",result="%s"} 1
+				dao_operations_total{name="%s",operation="delete",result="%s"} 1
 				`,
 				daoName,
 				tc.ExpectedLabelResult,
@@ -222,9 +252,6 @@ func TestDelete(t *testing.T) {
 			if err != nil {
 				t.Errorf("Error comparing dao_operations_total metric: %v", err)
 			}
-
-			// TODO: test histogram metrics, and find a way to spy on prometheus metrics
-			// without having to collect the metrics themselves
 		})
 	}
 }
@@ -264,14 +291,14 @@ func TestInsert(t *testing.T) {
 		{
 			Name:                "ErrLinkNotFound",
 			InsertFn:            errNotFound,
-			ExpectedLabelResult: "success",
+			ExpectedLabelResult: "not_found",
 			ExpectedErr:         shortener.ErrLinkNotFound,
 			ExpectedLink:        nil,
 		},
 		{
 			Name:                "UnexpectedErr",
 			InsertFn:            UnexpectedErr,
-			ExpectedLabelResult: "error",
+			ExpectedLabelResult: "other",
 			ExpectedErr:         unexpectedErr,
 			ExpectedLink:        nil,
 		},
@@ -298,9 +325,9 @@ func TestInsert(t *testing.T) {
 
 			metricsText := fmt.Sprintf(
 				`
-				# HELP dao_operations_total Total DAO operations by result (error/success)
+				# HELP dao_operations_total Total DAO operations by operation and result (success/not_found/timeout/canceled/other)
 				# TYPE dao_operations_total counter
-				dao_operations_total{name="%s",result="%s"} 1
+				dao_operations_total{name="%s",operation="insert",result="%s"} 1
 				`,
 				daoName,
 				tc.ExpectedLabelResult,
@@ -314,9 +341,6 @@ func TestInsert(t *testing.T) {
 			if err != nil {
 				t.Errorf("Error comparing dao_operations_total metric: %v", err)
 			}
-
-			// TODO: test histogram metrics, and find a way to spy on prometheus metrics
-			// without having to collect the metrics themselves
 		})
 	}
 }
@@ -354,13 +378,13 @@ func TestUpdate(t *testing.T) {
 		{
 			Name:                "ErrLinkNotFound",
 			UpdateFn:            errNotFound,
-			ExpectedLabelResult: "success",
+			ExpectedLabelResult: "not_found",
 			ExpectedErr:         shortener.ErrLinkNotFound,
 		},
 		{
 			Name:                "UnexpectedErr",
 			UpdateFn:            UnexpectedErr,
-			ExpectedLabelResult: "error",
+			ExpectedLabelResult: "other",
 			ExpectedErr:         unexpectedErr,
 		},
 	}
@@ -382,9 +406,9 @@ func TestUpdate(t *testing.T) {
 
 			metricsText := fmt.Sprintf(
 				`
-				# HELP dao_operations_total Total DAO operations by result (error/success)
+				# HELP dao_operations_total Total DAO operations by operation and result (success/not_found/timeout/canceled/other)
 				# TYPE dao_operations_total counter
-				dao_operations_total{name="%s",result="%s"} 1
+				dao_operations_total{name="%s",operation="update",result="%s"} 1
 				`,
 				daoName,
 				tc.ExpectedLabelResult,
@@ -398,9 +422,6 @@ func TestUpdate(t *testing.T) {
 			if err != nil {
 				t.Errorf("Error comparing dao_operations_total metric: %v", err)
 			}
-
-			// TODO: test histogram metrics, and find a way to spy on prometheus metrics
-			// without having to collect the metrics themselves
 		})
 	}
 }
@@ -408,16 +429,16 @@ func TestUpdate(t *testing.T) {
 func TestList(t *testing.T) {
 	unexpectedErr := fmt.Errorf("Unexpected")
 
-	successList := func(ctx context.Context, limit, skip int) ([]shortener.Link, error) {
+	successList := func(ctx context.Context, opts shortener.ListOptions) ([]shortener.Link, error) {
 		return []shortener.Link{}, nil
 	}
-	UnexpectedErr := func(ctx context.Context, limit, skip int) ([]shortener.Link, error) {
+	UnexpectedErr := func(ctx context.Context, opts shortener.ListOptions) ([]shortener.Link, error) {
 		return []shortener.Link{}, unexpectedErr
 	}
 
 	tests := []struct {
 		Name                string
-		ListFn              func(ctx context.Context, limit, skip int) ([]shortener.Link, error)
+		ListFn              func(ctx context.Context, opts shortener.ListOptions) ([]shortener.Link, error)
 		ExpectedLabelResult string
 		ExpectedErr         error
 	}{
@@ -430,7 +451,7 @@ func TestList(t *testing.T) {
 		{
 			Name:                "UnexpectedErr",
 			ListFn:              UnexpectedErr,
-			ExpectedLabelResult: "error",
+			ExpectedLabelResult: "other",
 			ExpectedErr:         unexpectedErr,
 		},
 	}
@@ -445,7 +466,7 @@ func TestList(t *testing.T) {
 			}
 			dao := metrics.NewLinkDao(baseDao, daoName)
 
-			links, err := dao.List(context.Background(), 10, 0)
+			links, err := dao.List(context.Background(), shortener.ListOptions{Limit: 10})
 			if !errors.Is(err, tc.ExpectedErr) {
 				t.Errorf("Expected error to be equal %v but got %v", tc.ExpectedErr, err)
 			}
@@ -456,9 +477,9 @@ func TestList(t *testing.T) {
 
 			metricsText := fmt.Sprintf(
 				`
-				# HELP dao_operations_total Total DAO operations by result (error/success)
+				# HELP dao_operations_total Total DAO operations by operation and result (success/not_found/timeout/canceled/other)
 				# TYPE dao_operations_total counter
-				dao_operations_total{name="%s",result="%s"} 1
+				dao_operations_total{name="%s",operation="list",result="%s"} 1
 				`,
 				daoName,
 				tc.ExpectedLabelResult,
@@ -472,9 +493,109 @@ func TestList(t *testing.T) {
 			if err != nil {
 				t.Errorf("Error comparing dao_operations_total metric: %v", err)
 			}
-
-			// TODO: test histogram metrics, and find a way to spy on prometheus metrics
-			// without having to collect the metrics themselves
 		})
 	}
 }
+
+// durationSum returns the total seconds observed for a DAO operation
+func durationSum(t *testing.T, name, operation string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	for _, f := range families {
+		if f.GetName() != "dao_operation_duration_seconds" {
+			continue
+		}
+
+		for _, m := range f.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+
+			if labels["name"] == name && labels["operation"] == operation {
+				return m.GetHistogram().GetSampleSum()
+			}
+		}
+	}
+
+	t.Fatalf("No duration observed for operation %s", operation)
+	return 0
+}
+
+func TestOperationDurations(t *testing.T) {
+	const delay = 20 * time.Millisecond
+
+	link := &shortener.Link{URL: "https://www.google.com", Slug: "aaaaa"}
+	baseDao := &mocks.FakeLinkDao{
+		FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+			time.Sleep(delay)
+			return link, nil
+		},
+		FindByURLFn: func(ctx context.Context, owner, url string) (*shortener.Link, error) {
+			time.Sleep(delay)
+			return link, nil
+		},
+		InsertFn: func(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
+			time.Sleep(delay)
+			return l, nil
+		},
+		InsertManyFn: func(ctx context.Context, links []shortener.Link) error {
+			time.Sleep(delay)
+			return nil
+		},
+		UpdateFn: func(ctx context.Context, l *shortener.Link) error {
+			time.Sleep(delay)
+			return nil
+		},
+		DeleteFn: func(ctx context.Context, slug string) error {
+			time.Sleep(delay)
+			return nil
+		},
+		ListFn: func(ctx context.Context, opts shortener.ListOptions) ([]shortener.Link, error) {
+			time.Sleep(delay)
+			return nil, nil
+		},
+		IncrementClicksFn: func(ctx context.Context, slug string) (int, error) {
+			time.Sleep(delay)
+			return 1, nil
+		},
+		MarkNotFoundFn: func(ctx context.Context, slug string) error {
+			time.Sleep(delay)
+			return nil
+		},
+	}
+
+	resetMetrics()
+	dao := metrics.NewLinkDao(baseDao, "db")
+	ctx := context.Background()
+
+	dao.Find(ctx, "aaaaa")
+	dao.FindByURL(ctx, "", "https://www.google.com")
+	dao.Insert(ctx, link)
+	dao.InsertMany(ctx, []shortener.Link{*link})
+	dao.Update(ctx, link)
+	dao.Delete(ctx, "aaaaa")
+	dao.List(ctx, shortener.ListOptions{Limit: 10})
+	dao.IncrementClicks(ctx, "aaaaa")
+	dao.MarkNotFound(ctx, "aaaaa")
+
+	operations := []string{
+		"find",
+		"find_by_url",
+		"insert",
+		"insert_many",
+		"update",
+		"delete",
+		"list",
+		"increment_clicks",
+		"mark_not_found",
+	}
+	for _, op := range operations {
+		if got := durationSum(t, "db", op); got < delay.Seconds() {
+			t.Errorf("Expected %s to take at least %s, but observed %.4fs", op, delay, got)
+		}
+	}
+}
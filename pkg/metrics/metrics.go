@@ -6,12 +6,15 @@ import (
 
 // These are prometheus metrics
 var (
+	// HTTP metrics are labeled by route template, like /{slug}, instead of the
+	// requested path, so there's a bounded number of series
+
 	HTTPRequestsCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "http_requests_total",
 			Help: "Counter for total requests received",
 		},
-		[]string{"route", "status"},
+		[]string{"route", "method", "status"},
 	)
 
 	HTTPRequestsDurationHistogram = prometheus.NewHistogramVec(
@@ -20,7 +23,25 @@ var (
 			Help:    "Duration of HTTP requests in seconds",
 			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
 		},
-		[]string{"route", "status"},
+		[]string{"route", "method", "status"},
+	)
+
+	HTTPRequestSizeHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_request_size_bytes",
+			Help:    "Size of HTTP request bodies in bytes",
+			Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+		},
+		[]string{"route", "method"},
+	)
+
+	HTTPResponseSizeHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "Size of HTTP response bodies in bytes, streamed responses aren't observed",
+			Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+		},
+		[]string{"route", "method"},
 	)
 
 	DAOFindResultCounter = prometheus.NewCounterVec(
@@ -34,9 +55,9 @@ var (
 	DAOOperationsCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "dao_operations_total",
-			Help: "Total DAO operations by result (error/success)",
+			Help: "Total DAO operations by operation and result (success/not_found/timeout/canceled/other)",
 		},
-		[]string{"name", "result"},
+		[]string{"name", "operation", "result"},
 	)
 
 	DAOOperationsDurationHistogram = prometheus.NewHistogramVec(
@@ -47,6 +68,65 @@ var (
 		},
 		[]string{"name", "operation"},
 	)
+
+	AnalyticsClicksCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "analytics_clicks_total",
+			Help: "Total clicks handled by the analytics writer by result (written/dropped/error)",
+		},
+		[]string{"result"},
+	)
+
+	SlugAttemptsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "slug_generation_attempts_total",
+			Help: "Total slug generation attempts by slug size and result (unique/collision)",
+		},
+		[]string{"size", "result"},
+	)
+
+	SlugCollisionRateGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "slug_collision_rate",
+			Help: "Moving rate of generated slugs that collided with existing ones, for the current slug size",
+		},
+	)
+
+	SlugSizeGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "slug_size",
+			Help: "Current size of generated slugs",
+		},
+	)
+
+	RateLimitRequestsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_limit_requests_total",
+			Help: "Total rate limited requests by route and result (allowed/limited/error)",
+		},
+		[]string{"route", "result"},
+	)
+
+	RateLimitFallbackCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rate_limit_fallback_total",
+			Help: "Total requests limited by the in memory fallback, because redis failed",
+		},
+	)
+
+	LinkInvalidationsCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "link_invalidations_received_total",
+			Help: "Total links invalidated by other app instances, and evicted from the memory cache",
+		},
+	)
+
+	LinkInvalidationPurgesCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "link_invalidation_purges_total",
+			Help: "Total memory cache purges, done whenever the invalidations subscription is (re)established",
+		},
+	)
 )
 
 // Init register metrics to prometheus register
@@ -54,8 +134,18 @@ func Init() {
 	prometheus.MustRegister(
 		HTTPRequestsCounter,
 		HTTPRequestsDurationHistogram,
+		HTTPRequestSizeHistogram,
+		HTTPResponseSizeHistogram,
 		DAOFindResultCounter,
 		DAOOperationsCounter,
 		DAOOperationsDurationHistogram,
+		AnalyticsClicksCounter,
+		SlugAttemptsCounter,
+		SlugCollisionRateGauge,
+		SlugSizeGauge,
+		RateLimitRequestsCounter,
+		RateLimitFallbackCounter,
+		LinkInvalidationsCounter,
+		LinkInvalidationPurgesCounter,
 	)
 }
@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type slugMetrics struct{}
+
+// NewSlugMetrics returns a receiver of slug generation events, that reports
+// them as prometheus metrics
+func NewSlugMetrics() shortener.SlugMetrics {
+	return &slugMetrics{}
+}
+
+func (sm *slugMetrics) ObserveAttempt(size int, collided bool) {
+	result := "unique"
+	if collided {
+		result = "collision"
+	}
+
+	SlugAttemptsCounter.With(
+		prometheus.Labels{"size": strconv.Itoa(size), "result": result},
+	).Inc()
+}
+
+func (sm *slugMetrics) SetCollisionRate(rate float64) {
+	SlugCollisionRateGauge.Set(rate)
+}
+
+func (sm *slugMetrics) SetSize(size int) {
+	SlugSizeGauge.Set(float64(size))
+}
@@ -0,0 +1,43 @@
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/metrics"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSlugMetrics(t *testing.T) {
+	resetMetrics()
+
+	sm := metrics.NewSlugMetrics()
+	sm.ObserveAttempt(5, true)
+	sm.ObserveAttempt(5, true)
+	sm.ObserveAttempt(5, false)
+	sm.SetCollisionRate(0.25)
+	sm.SetSize(6)
+
+	metricsText := `
+		# HELP slug_generation_attempts_total Total slug generation attempts by slug size and result (unique/collision)
+		# TYPE slug_generation_attempts_total counter
+		slug_generation_attempts_total{result="collision",size="5"} 2
+		slug_generation_attempts_total{result="unique",size="5"} 1
+	`
+	err := promtest.CollectAndCompare(
+		metrics.SlugAttemptsCounter,
+		strings.NewReader(metricsText),
+		"slug_generation_attempts_total",
+	)
+	if err != nil {
+		t.Errorf("Error comparing slug_generation_attempts_total metric: %v", err)
+	}
+
+	if got := promtest.ToFloat64(metrics.SlugCollisionRateGauge); got != 0.25 {
+		t.Errorf("Wrong slug_collision_rate (want, got): (%v, %v)", 0.25, got)
+	}
+
+	if got := promtest.ToFloat64(metrics.SlugSizeGauge); got != 6 {
+		t.Errorf("Wrong slug_size (want, got): (%v, %v)", 6, got)
+	}
+}
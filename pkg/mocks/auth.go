@@ -0,0 +1,55 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+// FakeAuthService holds fake implementations for the AuthService interface
+type FakeAuthService struct {
+	AuthenticateFn     func(ctx context.Context, key string) (*shortener.APIKey, error)
+	AuthenticateCalled bool
+
+	CreateKeyFn     func(ctx context.Context, owner, name string) (string, *shortener.APIKey, error)
+	CreateKeyCalled bool
+}
+
+// ensures FakeAuthService implements AuthService interface
+var _ shortener.AuthService = &FakeAuthService{}
+
+// Authenticate returns the api key attributes, given the key
+func (as *FakeAuthService) Authenticate(ctx context.Context, key string) (*shortener.APIKey, error) {
+	as.AuthenticateCalled = true
+	return as.AuthenticateFn(ctx, key)
+}
+
+// CreateKey generates a new api key
+func (as *FakeAuthService) CreateKey(ctx context.Context, owner, name string) (string, *shortener.APIKey, error) {
+	as.CreateKeyCalled = true
+	return as.CreateKeyFn(ctx, owner, name)
+}
+
+// FakeAPIKeyDao holds fake implementations for the APIKeyDao interface
+type FakeAPIKeyDao struct {
+	FindFn     func(ctx context.Context, hash string) (*shortener.APIKey, error)
+	FindCalled bool
+
+	InsertFn     func(ctx context.Context, hash string, k *shortener.APIKey) (*shortener.APIKey, error)
+	InsertCalled bool
+}
+
+// ensures FakeAPIKeyDao implements APIKeyDao interface
+var _ shortener.APIKeyDao = &FakeAPIKeyDao{}
+
+// Find is a mock for Find method in api key dao
+func (d *FakeAPIKeyDao) Find(ctx context.Context, hash string) (*shortener.APIKey, error) {
+	d.FindCalled = true
+	return d.FindFn(ctx, hash)
+}
+
+// Insert is a mock for Insert method in api key dao
+func (d *FakeAPIKeyDao) Insert(ctx context.Context, hash string, k *shortener.APIKey) (*shortener.APIKey, error) {
+	d.InsertCalled = true
+	return d.InsertFn(ctx, hash, k)
+}
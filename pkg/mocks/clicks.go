@@ -0,0 +1,55 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+// FakeClickService holds fake implementations for the ClickService interface
+type FakeClickService struct {
+	RecordFn     func(c shortener.Click)
+	RecordCalled bool
+
+	StatsFn     func(ctx context.Context, slug string) (*shortener.LinkStats, error)
+	StatsCalled bool
+}
+
+// ensures FakeClickService implements ClickService interface
+var _ shortener.ClickService = &FakeClickService{}
+
+// Record stores a click
+func (cs *FakeClickService) Record(c shortener.Click) {
+	cs.RecordCalled = true
+	cs.RecordFn(c)
+}
+
+// Stats returns click statistics of a link
+func (cs *FakeClickService) Stats(ctx context.Context, slug string) (*shortener.LinkStats, error) {
+	cs.StatsCalled = true
+	return cs.StatsFn(ctx, slug)
+}
+
+// FakeClickDao holds fake implementations for the ClickDao interface
+type FakeClickDao struct {
+	InsertManyFn     func(ctx context.Context, clicks []shortener.Click) error
+	InsertManyCalled bool
+
+	StatsFn     func(ctx context.Context, slug string) (*shortener.LinkStats, error)
+	StatsCalled bool
+}
+
+// ensure FakeClickDao implements shortener.ClickDao
+var _ shortener.ClickDao = &FakeClickDao{}
+
+// InsertMany is a mock for InsertMany method in click dao
+func (cd *FakeClickDao) InsertMany(ctx context.Context, clicks []shortener.Click) error {
+	cd.InsertManyCalled = true
+	return cd.InsertManyFn(ctx, clicks)
+}
+
+// Stats is a mock for Stats method in click dao
+func (cd *FakeClickDao) Stats(ctx context.Context, slug string) (*shortener.LinkStats, error) {
+	cd.StatsCalled = true
+	return cd.StatsFn(ctx, slug)
+}
@@ -8,20 +8,35 @@ import (
 
 // FakeLinkDao holds fake implementations for the LinkDao interface
 type FakeLinkDao struct {
-	ListFn     func(ctx context.Context, limit, skip int) ([]shortener.Link, error)
+	ListFn     func(ctx context.Context, opts shortener.ListOptions) ([]shortener.Link, error)
 	ListCalled bool
 
 	FindFn     func(ctx context.Context, slug string) (*shortener.Link, error)
 	FindCalled bool
 
+	FindByURLFn     func(ctx context.Context, owner, url string) (*shortener.Link, error)
+	FindByURLCalled bool
+
+	ExistingSlugsFn     func(ctx context.Context, slugs []string) ([]string, error)
+	ExistingSlugsCalled bool
+
 	DeleteFn     func(ctx context.Context, slug string) error
 	DeleteCalled bool
 
 	InsertFn     func(ctx context.Context, l *shortener.Link) (*shortener.Link, error)
 	InsertCalled bool
 
+	InsertManyFn     func(ctx context.Context, links []shortener.Link) error
+	InsertManyCalled bool
+
 	UpdateFn     func(ctx context.Context, l *shortener.Link) error
 	UpdateCalled bool
+
+	IncrementClicksFn     func(ctx context.Context, slug string) (int, error)
+	IncrementClicksCalled bool
+
+	MarkNotFoundFn     func(ctx context.Context, slug string) error
+	MarkNotFoundCalled bool
 }
 
 // ensure FakeLinkDao implements shortener.LinkDao
@@ -33,6 +48,18 @@ func (lr *FakeLinkDao) Find(ctx context.Context, slug string) (*shortener.Link,
 	return lr.FindFn(ctx, slug)
 }
 
+// FindByURL is a mock for FindByURL method in link repository
+func (lr *FakeLinkDao) FindByURL(ctx context.Context, owner, url string) (*shortener.Link, error) {
+	lr.FindByURLCalled = true
+	return lr.FindByURLFn(ctx, owner, url)
+}
+
+// ExistingSlugs is a mock for ExistingSlugs method in link repository
+func (lr *FakeLinkDao) ExistingSlugs(ctx context.Context, slugs []string) ([]string, error) {
+	lr.ExistingSlugsCalled = true
+	return lr.ExistingSlugsFn(ctx, slugs)
+}
+
 // Delete is a mock for Delete method in link repository
 func (lr *FakeLinkDao) Delete(ctx context.Context, slug string) error {
 	lr.DeleteCalled = true
@@ -52,7 +79,25 @@ func (lr *FakeLinkDao) Update(ctx context.Context, l *shortener.Link) error {
 }
 
 // List returns a list of links
-func (lr *FakeLinkDao) List(ctx context.Context, skip, limit int) ([]shortener.Link, error) {
+func (lr *FakeLinkDao) List(ctx context.Context, opts shortener.ListOptions) ([]shortener.Link, error) {
 	lr.ListCalled = true
-	return lr.ListFn(ctx, limit, skip)
+	return lr.ListFn(ctx, opts)
+}
+
+// IncrementClicks is a mock for IncrementClicks method in link repository
+func (lr *FakeLinkDao) IncrementClicks(ctx context.Context, slug string) (int, error) {
+	lr.IncrementClicksCalled = true
+	return lr.IncrementClicksFn(ctx, slug)
+}
+
+// InsertMany is a mock for InsertMany method in link repository
+func (lr *FakeLinkDao) InsertMany(ctx context.Context, links []shortener.Link) error {
+	lr.InsertManyCalled = true
+	return lr.InsertManyFn(ctx, links)
+}
+
+// MarkNotFound is a mock for MarkNotFound method in link dao
+func (lr *FakeLinkDao) MarkNotFound(ctx context.Context, slug string) error {
+	lr.MarkNotFoundCalled = true
+	return lr.MarkNotFoundFn(ctx, slug)
 }
@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+// SlugAttempt holds the arguments of a SlugMetrics.ObserveAttempt call
+type SlugAttempt struct {
+	Size     int
+	Collided bool
+}
+
+// FakeSlugMetrics records every event received by the SlugMetrics interface
+type FakeSlugMetrics struct {
+	Attempts       []SlugAttempt
+	CollisionRates []float64
+	Sizes          []int
+}
+
+// ensures FakeSlugMetrics implements SlugMetrics interface
+var _ shortener.SlugMetrics = &FakeSlugMetrics{}
+
+// ObserveAttempt records a slug generation attempt
+func (sm *FakeSlugMetrics) ObserveAttempt(size int, collided bool) {
+	sm.Attempts = append(sm.Attempts, SlugAttempt{Size: size, Collided: collided})
+}
+
+// SetCollisionRate records the current collision rate
+func (sm *FakeSlugMetrics) SetCollisionRate(rate float64) {
+	sm.CollisionRates = append(sm.CollisionRates, rate)
+}
+
+// SetSize records the current slug size
+func (sm *FakeSlugMetrics) SetSize(size int) {
+	sm.Sizes = append(sm.Sizes, size)
+}
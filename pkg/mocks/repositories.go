@@ -8,20 +8,35 @@ import (
 
 // FakeLinkRepo holds fake implementations for the LinkRepository interface
 type FakeLinkRepo struct {
-	ListFn     func(ctx context.Context, limit, skip int) ([]shortener.Link, error)
+	ListFn     func(ctx context.Context, opts shortener.ListOptions) ([]shortener.Link, error)
 	ListCalled bool
 
 	FindFn     func(ctx context.Context, slug string) (*shortener.Link, error)
 	FindCalled bool
 
+	FindUncachedFn     func(ctx context.Context, slug string) (*shortener.Link, error)
+	FindUncachedCalled bool
+
+	FindByURLFn     func(ctx context.Context, owner, url string) (*shortener.Link, error)
+	FindByURLCalled bool
+
+	ExistingSlugsFn     func(ctx context.Context, slugs []string) ([]string, error)
+	ExistingSlugsCalled bool
+
 	DeleteFn     func(ctx context.Context, slug string) error
 	DeleteCalled bool
 
 	InsertFn     func(ctx context.Context, l *shortener.Link) (*shortener.Link, error)
 	InsertCalled bool
 
+	InsertManyFn     func(ctx context.Context, links []shortener.Link) error
+	InsertManyCalled bool
+
 	UpdateFn     func(ctx context.Context, l *shortener.Link) error
 	UpdateCalled bool
+
+	IncrementClicksFn     func(ctx context.Context, slug string) (int, error)
+	IncrementClicksCalled bool
 }
 
 // ensure FakeLinkRepo implements shortener.LinkRepository
@@ -33,6 +48,24 @@ func (lr *FakeLinkRepo) Find(ctx context.Context, slug string) (*shortener.Link,
 	return lr.FindFn(ctx, slug)
 }
 
+// FindUncached is a mock for FindUncached method in link repository
+func (lr *FakeLinkRepo) FindUncached(ctx context.Context, slug string) (*shortener.Link, error) {
+	lr.FindUncachedCalled = true
+	return lr.FindUncachedFn(ctx, slug)
+}
+
+// FindByURL is a mock for FindByURL method in link repository
+func (lr *FakeLinkRepo) FindByURL(ctx context.Context, owner, url string) (*shortener.Link, error) {
+	lr.FindByURLCalled = true
+	return lr.FindByURLFn(ctx, owner, url)
+}
+
+// ExistingSlugs is a mock for ExistingSlugs method in link repository
+func (lr *FakeLinkRepo) ExistingSlugs(ctx context.Context, slugs []string) ([]string, error) {
+	lr.ExistingSlugsCalled = true
+	return lr.ExistingSlugsFn(ctx, slugs)
+}
+
 // Delete is a mock for Delete method in link repository
 func (lr *FakeLinkRepo) Delete(ctx context.Context, slug string) error {
 	lr.DeleteCalled = true
@@ -52,7 +85,34 @@ func (lr *FakeLinkRepo) Update(ctx context.Context, l *shortener.Link) error {
 }
 
 // List is a mock for List method in link repository
-func (lr *FakeLinkRepo) List(ctx context.Context, limit, skip int) ([]shortener.Link, error) {
+func (lr *FakeLinkRepo) List(ctx context.Context, opts shortener.ListOptions) ([]shortener.Link, error) {
 	lr.ListCalled = true
-	return lr.ListFn(ctx, limit, skip)
+	return lr.ListFn(ctx, opts)
+}
+
+// IncrementClicks is a mock for IncrementClicks method in link repository
+func (lr *FakeLinkRepo) IncrementClicks(ctx context.Context, slug string) (int, error) {
+	lr.IncrementClicksCalled = true
+	return lr.IncrementClicksFn(ctx, slug)
+}
+
+// InsertMany is a mock for InsertMany method in link repository
+func (lr *FakeLinkRepo) InsertMany(ctx context.Context, links []shortener.Link) error {
+	lr.InsertManyCalled = true
+	return lr.InsertManyFn(ctx, links)
+}
+
+// FakeLinkInvalidator holds fake implementations for the LinkInvalidator interface
+type FakeLinkInvalidator struct {
+	InvalidateFn     func(ctx context.Context, slugs ...string) error
+	InvalidateCalled bool
+}
+
+// ensure FakeLinkInvalidator implements shortener.LinkInvalidator
+var _ shortener.LinkInvalidator = &FakeLinkInvalidator{}
+
+// Invalidate is a mock for Invalidate method in link invalidator
+func (li *FakeLinkInvalidator) Invalidate(ctx context.Context, slugs ...string) error {
+	li.InvalidateCalled = true
+	return li.InvalidateFn(ctx, slugs...)
 }
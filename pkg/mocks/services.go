@@ -8,35 +8,74 @@ import (
 
 // FakeLinkService holds fake implementations for the LinkService interface
 type FakeLinkService struct {
-	ListFn     func(ctx context.Context, limit, skip int) ([]shortener.Link, error)
+	ListFn     func(ctx context.Context, opts shortener.ListOptions) (*shortener.LinkPage, error)
 	ListCalled bool
 
-	GetURLFn     func(ctx context.Context, slug string) (string, error)
-	GetURLCalled bool
+	GetFn     func(ctx context.Context, slug string) (*shortener.Link, error)
+	GetCalled bool
 
-	CreateFn     func(ctx context.Context, URL string) (*shortener.Link, error)
+	ResolveFn     func(ctx context.Context, slug string) (*shortener.Link, error)
+	ResolveCalled bool
+
+	PreviewFn     func(ctx context.Context, slug string) (*shortener.Link, error)
+	PreviewCalled bool
+
+	CreateFn     func(ctx context.Context, l *shortener.Link) (*shortener.Link, error)
 	CreateCalled bool
 
+	UpdateFn     func(ctx context.Context, u *shortener.LinkUpdate) (*shortener.Link, error)
+	UpdateCalled bool
+
+	DeleteFn     func(ctx context.Context, slug string) error
+	DeleteCalled bool
+
 	GetNewSlugFn     func(ctx context.Context, size int) (string, error)
 	GetNewSlugCalled bool
 
-	GenerateSlugFn     func(size int) string
-	GenerateSlugCalled bool
+	ImportFn     func(ctx context.Context, links []shortener.Link, dryRun bool) ([]shortener.ImportResult, error)
+	ImportCalled bool
+
+	ExportFn     func(ctx context.Context, fn func(l shortener.Link) error) error
+	ExportCalled bool
 }
 
 // ensures FakeLinkService implements LinkService interface
 var _ shortener.LinkService = &FakeLinkService{}
 
-// GetURL returns an URL given a shortened url
-func (ls *FakeLinkService) GetURL(ctx context.Context, slug string) (string, error) {
-	ls.GetURLCalled = true
-	return ls.GetURLFn(ctx, slug)
+// Get returns a link, given its slug
+func (ls *FakeLinkService) Get(ctx context.Context, slug string) (*shortener.Link, error) {
+	ls.GetCalled = true
+	return ls.GetFn(ctx, slug)
+}
+
+// Resolve returns the link to redirect to, given a shortened url
+func (ls *FakeLinkService) Resolve(ctx context.Context, slug string) (*shortener.Link, error) {
+	ls.ResolveCalled = true
+	return ls.ResolveFn(ctx, slug)
+}
+
+// Preview is a mock for Preview method in link service
+func (ls *FakeLinkService) Preview(ctx context.Context, slug string) (*shortener.Link, error) {
+	ls.PreviewCalled = true
+	return ls.PreviewFn(ctx, slug)
 }
 
 // Create creates a searchable URL for a given code
-func (ls *FakeLinkService) Create(ctx context.Context, URL string) (*shortener.Link, error) {
+func (ls *FakeLinkService) Create(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
 	ls.CreateCalled = true
-	return ls.CreateFn(ctx, URL)
+	return ls.CreateFn(ctx, l)
+}
+
+// Update changes an existing link
+func (ls *FakeLinkService) Update(ctx context.Context, u *shortener.LinkUpdate) (*shortener.Link, error) {
+	ls.UpdateCalled = true
+	return ls.UpdateFn(ctx, u)
+}
+
+// Delete removes a link
+func (ls *FakeLinkService) Delete(ctx context.Context, slug string) error {
+	ls.DeleteCalled = true
+	return ls.DeleteFn(ctx, slug)
 }
 
 // GetNewSlug returns a slug that still doesn't exist in db
@@ -45,14 +84,20 @@ func (ls *FakeLinkService) GetNewSlug(ctx context.Context, size int) (string, er
 	return ls.GetNewSlugFn(ctx, size)
 }
 
-// GenerateSlug returns a random slug
-func (ls *FakeLinkService) GenerateSlug(size int) string {
-	ls.GenerateSlugCalled = true
-	return ls.GenerateSlugFn(size)
-}
-
 // List returns a list of links
-func (ls *FakeLinkService) List(ctx context.Context, limit, skip int) ([]shortener.Link, error) {
+func (ls *FakeLinkService) List(ctx context.Context, opts shortener.ListOptions) (*shortener.LinkPage, error) {
 	ls.ListCalled = true
-	return ls.ListFn(ctx, limit, skip)
+	return ls.ListFn(ctx, opts)
+}
+
+// Import validates and inserts links in bulk
+func (ls *FakeLinkService) Import(ctx context.Context, links []shortener.Link, dryRun bool) ([]shortener.ImportResult, error) {
+	ls.ImportCalled = true
+	return ls.ImportFn(ctx, links, dryRun)
+}
+
+// Export calls fn for every link
+func (ls *FakeLinkService) Export(ctx context.Context, fn func(l shortener.Link) error) error {
+	ls.ExportCalled = true
+	return ls.ExportFn(ctx, fn)
 }
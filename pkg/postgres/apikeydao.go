@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+type apiKeyDao struct {
+	conn *pgxpool.Pool
+}
+
+// NewAPIKeyDao instantiates a dao for api keys in postgres db
+func NewAPIKeyDao(conn *pgxpool.Pool) shortener.APIKeyDao {
+	return &apiKeyDao{
+		conn: conn,
+	}
+}
+
+func (d *apiKeyDao) Find(ctx context.Context, hash string) (*shortener.APIKey, error) {
+	k := shortener.APIKey{}
+	err := d.conn.QueryRow(
+		ctx,
+		"SELECT owner, name, createdAt FROM api_keys WHERE hash=$1",
+		hash,
+	).Scan(&k.Owner, &k.Name, &k.CreatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, shortener.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+
+	return &k, nil
+}
+
+func (d *apiKeyDao) Insert(ctx context.Context, hash string, k *shortener.APIKey) (*shortener.APIKey, error) {
+	err := d.conn.QueryRow(
+		ctx,
+		"INSERT INTO api_keys (hash, owner, name) VALUES ($1, $2, $3) RETURNING createdAt",
+		hash, k.Owner, k.Name,
+	).Scan(&k.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+func TestAPIKeyDao(t *testing.T) {
+	conn := GetConnection()
+	if err := truncateDB(conn); err != nil {
+		t.Fatalf("error truncating test database tables: %v", err)
+	}
+
+	dao := NewAPIKeyDao(conn)
+	hash := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	inserted, err := dao.Insert(context.Background(), hash, &shortener.APIKey{Owner: "alice", Name: "ci"})
+	if err != nil {
+		t.Fatalf("Unexpected error inserting api key: %v", err)
+	}
+
+	if inserted.CreatedAt.IsZero() {
+		t.Error("Expected api key creation date to be set")
+	}
+
+	found, err := dao.Find(context.Background(), hash)
+	if err != nil {
+		t.Fatalf("Unexpected error finding api key: %v", err)
+	}
+
+	if found.Owner != "alice" || found.Name != "ci" {
+		t.Errorf("Wrong api key found: %+v", found)
+	}
+
+	_, err = dao.Find(context.Background(), "unknown")
+	if !errors.Is(err, shortener.ErrAPIKeyNotFound) {
+		t.Errorf("Expected ErrAPIKeyNotFound, but got: %v", err)
+	}
+}
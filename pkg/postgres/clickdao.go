@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+type clickDao struct {
+	conn *pgxpool.Pool
+}
+
+// NewClickDao instantiates a dao for clicks in postgres db
+func NewClickDao(conn *pgxpool.Pool) shortener.ClickDao {
+	return &clickDao{
+		conn: conn,
+	}
+}
+
+func (d *clickDao) InsertMany(ctx context.Context, clicks []shortener.Click) error {
+	rows := make([][]interface{}, len(clicks))
+	for i, c := range clicks {
+		rows[i] = []interface{}{c.Slug, c.Referrer, c.UserAgent, c.RemoteAddr, c.CreatedAt}
+	}
+
+	// COPY quotes identifiers, and unquoted column names are stored lowercased
+	_, err := d.conn.CopyFrom(
+		ctx,
+		pgx.Identifier{"clicks"},
+		[]string{"slug", "referrer", "useragent", "remoteaddr", "createdat"},
+		pgx.CopyFromRows(rows),
+	)
+
+	return err
+}
+
+func (d *clickDao) Stats(ctx context.Context, slug string) (*shortener.LinkStats, error) {
+	rows, err := d.conn.Query(
+		ctx,
+		`SELECT date_trunc('day', createdAt AT TIME ZONE 'UTC') AS day, COUNT(*)
+		FROM clicks WHERE slug=$1 GROUP BY day ORDER BY day`,
+		slug,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := &shortener.LinkStats{Slug: slug, Daily: []shortener.DailyClicks{}}
+	for rows.Next() {
+		var day time.Time
+		var clicks int
+		err = rows.Scan(&day, &clicks)
+		if err != nil {
+			return nil, err
+		}
+
+		stats.TotalClicks += clicks
+		stats.Daily = append(stats.Daily, shortener.DailyClicks{Day: day.UTC(), Clicks: clicks})
+	}
+
+	return stats, rows.Err()
+}
@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+func TestInsertManyClicks(t *testing.T) {
+	conn := GetConnection()
+	if err := truncateDB(conn); err != nil {
+		t.Fatalf("error truncating test database tables: %v", err)
+	}
+
+	dao := NewClickDao(conn)
+
+	clicks := []shortener.Click{
+		{
+			Slug:       "a1CDz",
+			Referrer:   "https://www.google.com",
+			UserAgent:  "curl/7.68.0",
+			RemoteAddr: "127.0.0.1",
+			CreatedAt:  time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			Slug:       "a1CDz",
+			RemoteAddr: "::1",
+			CreatedAt:  time.Date(2020, 5, 1, 11, 0, 0, 0, time.UTC),
+		},
+	}
+
+	err := dao.InsertMany(context.Background(), clicks)
+	if err != nil {
+		t.Fatalf("failed to insert clicks: %v", err)
+	}
+
+	rows, err := conn.Query(
+		context.Background(),
+		"SELECT slug, referrer, userAgent, remoteAddr, createdAt FROM clicks ORDER BY createdAt",
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error querying inserted clicks: %v", err)
+	}
+	defer rows.Close()
+
+	got := []shortener.Click{}
+	for rows.Next() {
+		c := shortener.Click{}
+		err = rows.Scan(&c.Slug, &c.Referrer, &c.UserAgent, &c.RemoteAddr, &c.CreatedAt)
+		if err != nil {
+			t.Fatalf("Unexpected error scanning inserted click: %v", err)
+		}
+		got = append(got, c)
+	}
+
+	if diff := cmp.Diff(clicks, got); diff != "" {
+		t.Errorf("failed to insert expected clicks (-want +got):\n%s", diff)
+	}
+}
+
+func TestClickStats(t *testing.T) {
+	conn := GetConnection()
+	if err := truncateDB(conn); err != nil {
+		t.Fatalf("error truncating test database tables: %v", err)
+	}
+
+	dao := NewClickDao(conn)
+
+	err := dao.InsertMany(context.Background(), []shortener.Click{
+		{Slug: "a1CDz", CreatedAt: time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{Slug: "a1CDz", CreatedAt: time.Date(2020, 5, 2, 10, 0, 0, 0, time.UTC)},
+		{Slug: "a1CDz", CreatedAt: time.Date(2020, 5, 2, 23, 0, 0, 0, time.UTC)},
+		{Slug: "other", CreatedAt: time.Date(2020, 5, 2, 23, 0, 0, 0, time.UTC)},
+	})
+	if err != nil {
+		t.Fatalf("failed to seed clicks: %v", err)
+	}
+
+	tt := []struct {
+		Name string
+		Slug string
+		Want *shortener.LinkStats
+	}{
+		{
+			Name: "WithClicks",
+			Slug: "a1CDz",
+			Want: &shortener.LinkStats{
+				Slug:        "a1CDz",
+				TotalClicks: 3,
+				Daily: []shortener.DailyClicks{
+					{Day: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC), Clicks: 1},
+					{Day: time.Date(2020, 5, 2, 0, 0, 0, 0, time.UTC), Clicks: 2},
+				},
+			},
+		},
+		{
+			Name: "WithoutClicks",
+			Slug: "niull",
+			Want: &shortener.LinkStats{
+				Slug:        "niull",
+				TotalClicks: 0,
+				Daily:       []shortener.DailyClicks{},
+			},
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := dao.Stats(context.Background(), test.Slug)
+			if err != nil {
+				t.Fatalf("failed to get stats: %v", err)
+			}
+
+			if diff := cmp.Diff(test.Want, got); diff != "" {
+				t.Errorf("failed to fetch expected stats (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -53,3 +53,10 @@ func Connect() (func(), error) {
 func GetConnection() *pgxpool.Pool {
 	return conn
 }
+
+// Ping checks that the database is reachable, through a previously created
+// connection pool
+func Ping(ctx context.Context) error {
+	_, err := conn.Exec(ctx, "SELECT 1")
+	return err
+}
@@ -3,6 +3,8 @@ package postgres
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgconn"
@@ -26,9 +28,9 @@ func (d *dao) Find(ctx context.Context, slug string) (*shortener.Link, error) {
 	link := shortener.Link{}
 	err := d.conn.QueryRow(
 		ctx,
-		"SELECT url, slug, createdAt FROM links WHERE slug=$1",
+		"SELECT url, slug, createdAt, expiresAt, maxClicks, owner, tags, redirectType FROM links WHERE slug=$1",
 		slug,
-	).Scan(&link.URL, &link.Slug, &link.CreatedAt)
+	).Scan(&link.URL, &link.Slug, &link.CreatedAt, &link.ExpiresAt, &link.MaxClicks, &link.Owner, &link.Tags, &link.RedirectType)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -40,12 +42,59 @@ func (d *dao) Find(ctx context.Context, slug string) (*shortener.Link, error) {
 	return &link, nil
 }
 
+// FindByURL compares url hashes first, so the lookup uses the links_owner_url
+// index, which can't hold long urls themselves
+func (d *dao) FindByURL(ctx context.Context, owner, url string) (*shortener.Link, error) {
+	link := shortener.Link{}
+	err := d.conn.QueryRow(
+		ctx,
+		`SELECT url, slug, createdAt, expiresAt, maxClicks, owner, tags, redirectType FROM links
+		WHERE owner=$1 AND md5(url)=md5($2) AND url=$2 AND expiresAt IS NULL AND maxClicks=0 AND redirectType=0
+		ORDER BY createdAt, slug LIMIT 1`,
+		owner, url,
+	).Scan(&link.URL, &link.Slug, &link.CreatedAt, &link.ExpiresAt, &link.MaxClicks, &link.Owner, &link.Tags, &link.RedirectType)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, shortener.ErrLinkNotFound
+		}
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+func (d *dao) ExistingSlugs(ctx context.Context, slugs []string) ([]string, error) {
+	rows, err := d.conn.Query(ctx, "SELECT slug FROM links WHERE slug = ANY($1)", slugs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := []string{}
+	for rows.Next() {
+		var slug string
+		err = rows.Scan(&slug)
+		if err != nil {
+			return nil, err
+		}
+		existing = append(existing, slug)
+	}
+
+	return existing, rows.Err()
+}
+
+// MarkNotFound does nothing, the db is the source of truth for existing links
+func (d *dao) MarkNotFound(ctx context.Context, slug string) error {
+	return nil
+}
+
 func (d *dao) Insert(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
 	var createdAt time.Time
 	err := d.conn.QueryRow(
 		ctx,
-		"INSERT INTO links (slug, url) VALUES ($1, $2) RETURNING createdAt",
-		l.Slug, l.URL,
+		"INSERT INTO links (slug, url, expiresAt, maxClicks, owner, tags, redirectType) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING createdAt",
+		l.Slug, l.URL, l.ExpiresAt, l.MaxClicks, l.Owner, l.Tags, l.RedirectType,
 	).Scan(&createdAt)
 
 	if err != nil {
@@ -62,21 +111,108 @@ func (d *dao) Insert(ctx context.Context, l *shortener.Link) (*shortener.Link, e
 	return l, nil
 }
 
+// InsertMany writes all links with a single COPY, so either all of them are
+// inserted or none is. Links without CreatedAt are set to the current time
+func (d *dao) InsertMany(ctx context.Context, links []shortener.Link) error {
+	now := time.Now()
+	rows := make([][]interface{}, len(links))
+	for i := range links {
+		if links[i].CreatedAt.IsZero() {
+			links[i].CreatedAt = now
+		}
+
+		l := links[i]
+		rows[i] = []interface{}{l.Slug, l.URL, l.CreatedAt, l.ExpiresAt, l.MaxClicks, l.Owner, l.Tags, l.RedirectType}
+	}
+
+	// COPY quotes identifiers, and unquoted column names are stored lowercased
+	_, err := d.conn.CopyFrom(
+		ctx,
+		pgx.Identifier{"links"},
+		[]string{"slug", "url", "createdat", "expiresat", "maxclicks", "owner", "tags", "redirecttype"},
+		pgx.CopyFromRows(rows),
+	)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		// if is a unique constraint error code, from postgres
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return shortener.ErrLinkExists
+		}
+		return err
+	}
+
+	return nil
+}
+
 func (d *dao) Update(ctx context.Context, l *shortener.Link) error {
-	panic("not yet implemented")
+	tag, err := d.conn.Exec(
+		ctx,
+		"UPDATE links SET url=$2, expiresAt=$3, maxClicks=$4, tags=$5, redirectType=$6 WHERE slug=$1",
+		l.Slug, l.URL, l.ExpiresAt, l.MaxClicks, l.Tags, l.RedirectType,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return shortener.ErrLinkNotFound
+	}
+
+	return nil
 }
 
+// Delete removes the link along with its clicks, in the same transaction, so
+// a link later created with the same slug doesn't inherit them
 func (d *dao) Delete(ctx context.Context, slug string) error {
-	panic("not yet implemented")
+	tx, err := d.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, "DELETE FROM links WHERE slug=$1", slug)
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return shortener.ErrLinkNotFound
+	}
+
+	_, err = tx.Exec(ctx, "DELETE FROM clicks WHERE slug=$1", slug)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
-func (d *dao) List(ctx context.Context, limit int, skip int) ([]shortener.Link, error) {
-	rows, err := d.conn.Query(
+func (d *dao) IncrementClicks(ctx context.Context, slug string) (int, error) {
+	var clicks int
+	err := d.conn.QueryRow(
 		ctx,
-		`SELECT slug, url, createdAt FROM links LIMIT $1 OFFSET $2`,
-		limit,
-		skip,
-	)
+		"UPDATE links SET clicks=clicks+1 WHERE slug=$1 RETURNING clicks",
+		slug,
+	).Scan(&clicks)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, shortener.ErrLinkNotFound
+		}
+		return 0, err
+	}
+
+	return clicks, nil
+}
+
+// List returns the links matching the options filters, every link if none is
+// set
+func (d *dao) List(ctx context.Context, opts shortener.ListOptions) ([]shortener.Link, error) {
+	query, args := listQuery(opts)
+	rows, err := d.conn.Query(ctx, query, args...)
 
 	links := []shortener.Link{}
 	if err != nil {
@@ -85,7 +221,7 @@ func (d *dao) List(ctx context.Context, limit int, skip int) ([]shortener.Link,
 
 	for rows.Next() {
 		l := shortener.Link{}
-		err = rows.Scan(&l.Slug, &l.URL, &l.CreatedAt)
+		err = rows.Scan(&l.Slug, &l.URL, &l.CreatedAt, &l.ExpiresAt, &l.MaxClicks, &l.Owner, &l.Tags, &l.RedirectType, &l.ClickCount)
 		if err != nil {
 			break
 		}
@@ -94,3 +230,79 @@ func (d *dao) List(ctx context.Context, limit int, skip int) ([]shortener.Link,
 
 	return links, rows.Err()
 }
+
+// hostPattern captures the host of an url, skipping its user info
+const hostPattern = `^[^:]+://(?:[^/?#@]*@)?([^/?#:]+)`
+
+// listQuery builds the List query and its arguments, with only the conditions
+// of the filters set. The links.clicks column only counts clicks of links
+// limited by them, so sorting by clicks uses links.clickCount, which counts
+// every recorded click
+func listQuery(opts shortener.ListOptions) (string, []interface{}) {
+	args := []interface{}{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := []string{}
+	if opts.Owner != "" {
+		where = append(where, "owner = "+arg(opts.Owner))
+	}
+	if opts.Host != "" {
+		host := strings.ToLower(opts.Host)
+		// the substring match can use the trigram index, the exact match can't
+		where = append(where, fmt.Sprintf(
+			"url ILIKE %s AND lower(substring(url from '%s')) = %s",
+			arg("%"+escapeLike(host)+"%"), hostPattern, arg(host),
+		))
+	}
+	if opts.URLContains != "" {
+		where = append(where, "url ILIKE "+arg("%"+escapeLike(opts.URLContains)+"%"))
+	}
+	if opts.CreatedAfter != nil {
+		where = append(where, "createdAt >= "+arg(*opts.CreatedAfter))
+	}
+	if opts.CreatedBefore != nil {
+		where = append(where, "createdAt <= "+arg(*opts.CreatedBefore))
+	}
+	if opts.Tag != "" {
+		where = append(where, fmt.Sprintf("tags @> ARRAY[%s]::text[]", arg(opts.Tag)))
+	}
+
+	key := "createdAt"
+	if opts.Sort == shortener.SortClicks || opts.Sort == shortener.SortClicksDesc {
+		key = "clickCount"
+	}
+
+	direction, comparison := "ASC", ">"
+	if opts.Sort == shortener.SortCreatedAtDesc || opts.Sort == shortener.SortClicksDesc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if opts.After != nil {
+		var after string
+		if key == "createdAt" {
+			after = arg(opts.After.CreatedAt)
+		} else {
+			after = arg(opts.After.ClickCount)
+		}
+		where = append(where, fmt.Sprintf("(%s, slug) %s (%s, %s)", key, comparison, after, arg(opts.After.Slug)))
+	}
+
+	query := "SELECT slug, url, createdAt, expiresAt, maxClicks, owner, tags, redirectType, clickCount FROM links"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += fmt.Sprintf(
+		" ORDER BY %s %s, slug %s LIMIT %s OFFSET %s",
+		key, direction, direction, arg(opts.Limit), arg(opts.Skip),
+	)
+
+	return query, args
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern, so s is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -37,6 +37,19 @@ func testMain(m *testing.M) int {
 		return 1
 	}
 	defer closeDB()
+
+	migrator, err := NewMigrator(GetConnection())
+	if err != nil {
+		fmt.Printf("failed to load migrations: %v", err)
+		return 1
+	}
+
+	_, err = migrator.Up(context.Background())
+	if err != nil {
+		fmt.Printf("failed to migrate test database: %v", err)
+		return 1
+	}
+
 	return m.Run()
 }
 
@@ -50,7 +63,7 @@ func seedDB(conn *pgxpool.Pool) error {
 }
 
 func truncateDB(conn *pgxpool.Pool) error {
-	_, err := conn.Exec(context.Background(), "TRUNCATE TABLE links")
+	_, err := conn.Exec(context.Background(), "TRUNCATE TABLE links, clicks, api_keys")
 
 	return err
 }
@@ -112,6 +125,96 @@ func TestFind(t *testing.T) {
 	}
 }
 
+func TestFindByURL(t *testing.T) {
+	conn := GetConnection()
+	if err := truncateDB(conn); err != nil {
+		t.Fatalf("error truncating test database tables: %v", err)
+	}
+
+	ctx := context.Background()
+	for _, q := range []string{
+		"INSERT INTO links (slug, url, createdAt, owner) VALUES ('n3w3r', 'https://www.google.com', '2020-05-03T00:00:00.000Z', 'alice')",
+		"INSERT INTO links (slug, url, createdAt, owner) VALUES ('0ld3r', 'https://www.google.com', '2020-05-02T00:00:00.000Z', 'alice')",
+		"INSERT INTO links (slug, url, createdAt, owner, maxClicks) VALUES ('l1m1t', 'https://www.google.com', '2020-05-01T00:00:00.000Z', 'alice', 10)",
+		"INSERT INTO links (slug, url, createdAt, owner, redirectType) VALUES ('r3d1r', 'https://www.google.com', '2020-05-01T00:00:00.000Z', 'alice', 308)",
+		"INSERT INTO links (slug, url, createdAt, owner) VALUES ('b0bsl', 'https://www.google.com', '2020-05-01T00:00:00.000Z', 'bob')",
+	} {
+		if _, err := conn.Exec(ctx, q); err != nil {
+			t.Fatalf("failed to seed db: %v", err)
+		}
+	}
+
+	dao := NewLinkDao(conn)
+
+	tt := []struct {
+		Name  string
+		Owner string
+		URL   string
+		Want  *shortener.Link
+		Err   error
+	}{
+		{
+			Name:  "OldestWithoutExpiration",
+			Owner: "alice",
+			URL:   "https://www.google.com",
+			Want: &shortener.Link{
+				URL:       "https://www.google.com",
+				Slug:      "0ld3r",
+				CreatedAt: time.Date(2020, 5, 2, 0, 0, 0, 0, time.UTC),
+				Owner:     "alice",
+			},
+		},
+		{
+			Name:  "OtherURL",
+			Owner: "alice",
+			URL:   "https://www.duckduckgo.com",
+			Err:   shortener.ErrLinkNotFound,
+		},
+		{
+			Name:  "OtherOwner",
+			Owner: "carol",
+			URL:   "https://www.google.com",
+			Err:   shortener.ErrLinkNotFound,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := dao.FindByURL(ctx, test.Owner, test.URL)
+
+			if !errors.Is(err, test.Err) {
+				t.Fatalf("failed to find link by url: %v", err)
+			}
+
+			if diff := cmp.Diff(test.Want, got); diff != "" {
+				t.Errorf("failed to fetch expected link (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExistingSlugs(t *testing.T) {
+	conn := GetConnection()
+	if err := truncateDB(conn); err != nil {
+		t.Fatalf("error truncating test database tables: %v", err)
+	}
+
+	if err := seedDB(conn); err != nil {
+		t.Fatalf("error seeding database: %v", err)
+	}
+
+	dao := NewLinkDao(conn)
+
+	existing, err := dao.ExistingSlugs(context.Background(), []string{"a1CDz", "n0t3x1st"})
+	if err != nil {
+		t.Fatalf("Unexpected error checking existing slugs: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"a1CDz"}, existing); diff != "" {
+		t.Errorf("Existing slugs different from expected (-want +got):\n%s", diff)
+	}
+}
+
 func TestInsert(t *testing.T) {
 	conn := GetConnection()
 	if err := truncateDB(conn); err != nil {
@@ -178,6 +281,73 @@ func TestInsert(t *testing.T) {
 	}
 }
 
+func TestInsertMany(t *testing.T) {
+	conn := GetConnection()
+	if err := truncateDB(conn); err != nil {
+		t.Fatalf("error truncating test database tables: %v", err)
+	}
+
+	err := seedDB(conn)
+	if err != nil {
+		t.Fatalf("failed to seed db: %v", err)
+	}
+
+	dao := NewLinkDao(conn)
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		links := []shortener.Link{
+			{URL: "https://www.google.com?s=golang", Slug: "bulk1"},
+			{URL: "https://www.google.com?s=rust", Slug: "bulk2", ExpiresAt: &expiresAt, MaxClicks: 3, RedirectType: shortener.RedirectTemporary},
+		}
+
+		err := dao.InsertMany(context.Background(), links)
+		if err != nil {
+			t.Fatalf("Unexpected error inserting links: %v", err)
+		}
+
+		for _, want := range links {
+			got, err := dao.Find(context.Background(), want.Slug)
+			if err != nil {
+				t.Fatalf("Unexpected error finding inserted link: %v", err)
+			}
+
+			if diff := cmp.Diff(want.URL, got.URL); diff != "" {
+				t.Errorf("Inserted link url mismatch (-want +got):\n%s", diff)
+			}
+
+			if got.MaxClicks != want.MaxClicks {
+				t.Errorf("Wrong max clicks (want, got): (%d, %d)", want.MaxClicks, got.MaxClicks)
+			}
+
+			if got.RedirectType != want.RedirectType {
+				t.Errorf("Wrong redirect type (want, got): (%d, %d)", want.RedirectType, got.RedirectType)
+			}
+
+			if want.CreatedAt.IsZero() {
+				t.Error("Expected InsertMany to set link creation date")
+			}
+		}
+	})
+
+	t.Run("ConflictSlugInsertsNothing", func(t *testing.T) {
+		links := []shortener.Link{
+			{URL: "https://www.google.com?s=go", Slug: "bulk3"},
+			{URL: "https://www.google.com?s=go", Slug: "a1CDz"},
+		}
+
+		err := dao.InsertMany(context.Background(), links)
+		if !errors.Is(err, shortener.ErrLinkExists) {
+			t.Fatalf("Expected ErrLinkExists, but got: %v", err)
+		}
+
+		_, err = dao.Find(context.Background(), "bulk3")
+		if !errors.Is(err, shortener.ErrLinkNotFound) {
+			t.Errorf("Expected no link to be inserted, but got: %v", err)
+		}
+	})
+}
+
 func TestList(t *testing.T) {
 	conn := GetConnection()
 	if err := truncateDB(conn); err != nil {
@@ -189,6 +359,14 @@ func TestList(t *testing.T) {
 		t.Fatalf("failed to seed db: %v", err)
 	}
 
+	_, err = conn.Exec(
+		context.Background(),
+		"INSERT INTO links (slug, url, createdAt, owner) VALUES ('4l1c3', 'https://www.google.com', '2020-05-02T00:00:00.000Z', 'alice')",
+	)
+	if err != nil {
+		t.Fatalf("failed to seed owned link: %v", err)
+	}
+
 	dao := NewLinkDao(conn)
 
 	expectedLinks := []shortener.Link{
@@ -199,12 +377,23 @@ func TestList(t *testing.T) {
 		},
 	}
 
+	ownedLinks := []shortener.Link{
+		{
+			URL:       "https://www.google.com",
+			Slug:      "4l1c3",
+			CreatedAt: time.Date(2020, 5, 2, 0, 0, 0, 0, time.UTC),
+			Owner:     "alice",
+		},
+	}
+
 	tests := []struct {
 		Name           string
 		ExpectedErr    error
 		ExpectedResult []shortener.Link
+		Owner          string
 		Skip           int
 		Limit          int
+		After          *shortener.ListCursor
 	}{
 		{
 			Name:           "FirstPageSuccess",
@@ -214,17 +403,62 @@ func TestList(t *testing.T) {
 			Limit:          1,
 		},
 		{
-			Name:           "SecondPageEmpty",
+			Name:           "SecondPageOwned",
 			ExpectedErr:    nil,
-			ExpectedResult: []shortener.Link{},
+			ExpectedResult: ownedLinks,
 			Skip:           1,
 			Limit:          1,
 		},
+		{
+			Name:           "ThirdPageEmpty",
+			ExpectedErr:    nil,
+			ExpectedResult: []shortener.Link{},
+			Skip:           2,
+			Limit:          1,
+		},
+		{
+			Name:           "ScopedToOwner",
+			ExpectedErr:    nil,
+			ExpectedResult: ownedLinks,
+			Owner:          "alice",
+			Skip:           0,
+			Limit:          10,
+		},
+		{
+			Name:           "OtherOwnerEmpty",
+			ExpectedErr:    nil,
+			ExpectedResult: []shortener.Link{},
+			Owner:          "bob",
+			Skip:           0,
+			Limit:          10,
+		},
+		{
+			Name:           "AfterCursor",
+			ExpectedErr:    nil,
+			ExpectedResult: ownedLinks,
+			Limit:          10,
+			After:          &shortener.ListCursor{Sort: shortener.SortCreatedAt, CreatedAt: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC), Slug: "a1CDz"},
+		},
+		{
+			Name:           "AfterSameDateSortedBySlug",
+			ExpectedErr:    nil,
+			ExpectedResult: expectedLinks,
+			Limit:          10,
+			After:          &shortener.ListCursor{Sort: shortener.SortCreatedAt, CreatedAt: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC), Slug: "a1CD"},
+		},
+		{
+			Name:           "AfterLastLinkEmpty",
+			ExpectedErr:    nil,
+			ExpectedResult: []shortener.Link{},
+			Limit:          10,
+			After:          &shortener.ListCursor{Sort: shortener.SortCreatedAt, CreatedAt: time.Date(2020, 5, 2, 0, 0, 0, 0, time.UTC), Slug: "4l1c3"},
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.Name, func(t *testing.T) {
-			links, err := dao.List(context.Background(), tc.Limit, tc.Skip)
+			opts := shortener.ListOptions{Owner: tc.Owner, Limit: tc.Limit, Skip: tc.Skip, After: tc.After}
+			links, err := dao.List(context.Background(), opts)
 			if err != nil {
 				t.Errorf("Failed to List links: %v", err)
 			}
@@ -235,3 +469,279 @@ func TestList(t *testing.T) {
 		})
 	}
 }
+
+func TestListFilters(t *testing.T) {
+	conn := GetConnection()
+	if err := truncateDB(conn); err != nil {
+		t.Fatalf("error truncating test database tables: %v", err)
+	}
+
+	_, err := conn.Exec(
+		context.Background(),
+		`INSERT INTO links (slug, url, createdAt, owner, tags) VALUES
+		('first', 'https://www.google.com/search?q=100%25', '2020-05-01T00:00:00.000Z', 'alice', '{sale}'),
+		('secnd', 'https://news.google.com/home', '2020-05-02T00:00:00.000Z', 'alice', NULL),
+		('third', 'https://user@www.google.com:8080/maps', '2020-05-03T00:00:00.000Z', 'bob', '{sale,print}')`,
+	)
+	if err != nil {
+		t.Fatalf("failed to seed links: %v", err)
+	}
+
+	_, err = conn.Exec(
+		context.Background(),
+		"INSERT INTO clicks (slug) VALUES ('secnd'), ('secnd'), ('third')",
+	)
+	if err != nil {
+		t.Fatalf("failed to seed clicks: %v", err)
+	}
+
+	dao := NewLinkDao(conn)
+	after := time.Date(2020, 5, 2, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2020, 5, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		Name      string
+		Opts      shortener.ListOptions
+		WantSlugs []string
+	}{
+		{
+			Name:      "Host",
+			Opts:      shortener.ListOptions{Host: "WWW.google.com"},
+			WantSlugs: []string{"first", "third"},
+		},
+		{
+			Name:      "URLContains",
+			Opts:      shortener.ListOptions{URLContains: "/MAPS"},
+			WantSlugs: []string{"third"},
+		},
+		{
+			Name:      "URLContainsWildcardLiterally",
+			Opts:      shortener.ListOptions{URLContains: "q_1"},
+			WantSlugs: []string{},
+		},
+		{
+			Name:      "CreatedRange",
+			Opts:      shortener.ListOptions{CreatedAfter: &after, CreatedBefore: &before},
+			WantSlugs: []string{"secnd"},
+		},
+		{
+			Name:      "TagAndOwner",
+			Opts:      shortener.ListOptions{Tag: "sale", Owner: "alice"},
+			WantSlugs: []string{"first"},
+		},
+		{
+			Name:      "CreatedAtDesc",
+			Opts:      shortener.ListOptions{Sort: shortener.SortCreatedAtDesc},
+			WantSlugs: []string{"third", "secnd", "first"},
+		},
+		{
+			Name:      "CreatedAtDescAfterCursor",
+			Opts:      shortener.ListOptions{Sort: shortener.SortCreatedAtDesc, After: &shortener.ListCursor{Sort: shortener.SortCreatedAtDesc, CreatedAt: after, Slug: "secnd"}},
+			WantSlugs: []string{"first"},
+		},
+		{
+			Name:      "ClicksDesc",
+			Opts:      shortener.ListOptions{Sort: shortener.SortClicksDesc},
+			WantSlugs: []string{"secnd", "third", "first"},
+		},
+		{
+			Name:      "ClicksDescAfterCursor",
+			Opts:      shortener.ListOptions{Sort: shortener.SortClicksDesc, After: &shortener.ListCursor{Sort: shortener.SortClicksDesc, ClickCount: 1, Slug: "third"}},
+			WantSlugs: []string{"first"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			tc.Opts.Limit = 10
+			links, err := dao.List(context.Background(), tc.Opts)
+			if err != nil {
+				t.Fatalf("Failed to List links: %v", err)
+			}
+
+			slugs := []string{}
+			for _, l := range links {
+				slugs = append(slugs, l.Slug)
+			}
+
+			if diff := cmp.Diff(tc.WantSlugs, slugs); diff != "" {
+				t.Errorf("Listed links are not the expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("ClickCounts", func(t *testing.T) {
+		links, err := dao.List(context.Background(), shortener.ListOptions{Sort: shortener.SortClicksDesc, Limit: 10})
+		if err != nil {
+			t.Fatalf("Failed to List links: %v", err)
+		}
+
+		counts := map[string]int64{}
+		for _, l := range links {
+			counts[l.Slug] = l.ClickCount
+		}
+
+		want := map[string]int64{"first": 0, "secnd": 2, "third": 1}
+		if diff := cmp.Diff(want, counts); diff != "" {
+			t.Errorf("Listed click counts are not the expected (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	conn := GetConnection()
+	if err := truncateDB(conn); err != nil {
+		t.Fatalf("error truncating test database tables: %v", err)
+	}
+
+	err := seedDB(conn)
+	if err != nil {
+		t.Fatalf("failed to seed db: %v", err)
+	}
+
+	dao := NewLinkDao(conn)
+
+	tt := []struct {
+		Name  string
+		Link  *shortener.Link
+		Error error
+	}{
+		{
+			Name: "Success",
+			Link: &shortener.Link{
+				URL:       "https://www.duckduckgo.com",
+				Slug:      "a1CDz",
+				CreatedAt: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC),
+			},
+			Error: nil,
+		},
+		{
+			Name: "NotFound",
+			Link: &shortener.Link{
+				URL:  "https://www.duckduckgo.com",
+				Slug: "niull",
+			},
+			Error: shortener.ErrLinkNotFound,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Name, func(t *testing.T) {
+			err := dao.Update(context.Background(), test.Link)
+
+			if !errors.Is(err, test.Error) {
+				t.Fatalf("failed to update link: %v", err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			updated := shortener.Link{}
+			err = conn.QueryRow(
+				context.Background(),
+				"SELECT slug, url, createdAt FROM links WHERE slug=$1",
+				test.Link.Slug,
+			).Scan(&updated.Slug, &updated.URL, &updated.CreatedAt)
+
+			if err != nil {
+				t.Fatalf("Unexpected error querying updated link: %v", err)
+			}
+
+			if diff := cmp.Diff(test.Link, &updated); diff != "" {
+				t.Errorf("failed to fetch expected link (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	conn := GetConnection()
+	if err := truncateDB(conn); err != nil {
+		t.Fatalf("error truncating test database tables: %v", err)
+	}
+
+	err := seedDB(conn)
+	if err != nil {
+		t.Fatalf("failed to seed db: %v", err)
+	}
+
+	_, err = conn.Exec(context.Background(), "INSERT INTO clicks (slug) VALUES ('a1CDz'), ('a1CDz')")
+	if err != nil {
+		t.Fatalf("failed to seed clicks: %v", err)
+	}
+
+	dao := NewLinkDao(conn)
+
+	tt := []struct {
+		Name  string
+		Slug  string
+		Error error
+	}{
+		{
+			Name:  "DeleteFound",
+			Slug:  "a1CDz",
+			Error: nil,
+		},
+		{
+			Name:  "DeleteNotFound",
+			Slug:  "niull",
+			Error: shortener.ErrLinkNotFound,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Name, func(t *testing.T) {
+			err := dao.Delete(context.Background(), test.Slug)
+
+			if !errors.Is(err, test.Error) {
+				t.Fatalf("failed to delete link: %v", err)
+			}
+
+			_, err = dao.Find(context.Background(), test.Slug)
+			if !errors.Is(err, shortener.ErrLinkNotFound) {
+				t.Errorf("link should have been deleted, but got err: %v", err)
+			}
+
+			var clicks int
+			err = conn.QueryRow(context.Background(), "SELECT count(*) FROM clicks WHERE slug=$1", test.Slug).Scan(&clicks)
+			if err != nil {
+				t.Fatalf("failed to count clicks: %v", err)
+			}
+
+			if clicks != 0 {
+				t.Errorf("clicks should have been deleted along with the link, but got %d", clicks)
+			}
+		})
+	}
+}
+
+func TestIncrementClicks(t *testing.T) {
+	conn := GetConnection()
+	if err := truncateDB(conn); err != nil {
+		t.Fatalf("error truncating test database tables: %v", err)
+	}
+
+	err := seedDB(conn)
+	if err != nil {
+		t.Fatalf("failed to seed db: %v", err)
+	}
+
+	dao := NewLinkDao(conn)
+
+	for want := 1; want <= 2; want++ {
+		clicks, err := dao.IncrementClicks(context.Background(), "a1CDz")
+		if err != nil {
+			t.Fatalf("failed to increment clicks: %v", err)
+		}
+
+		if clicks != want {
+			t.Errorf("wrong clicks count (want, got): (%d, %d)", want, clicks)
+		}
+	}
+
+	_, err = dao.IncrementClicks(context.Background(), "niull")
+	if !errors.Is(err, shortener.ErrLinkNotFound) {
+		t.Errorf("expected ErrLinkNotFound, but got: %v", err)
+	}
+}
@@ -0,0 +1,279 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// migrationsLockID identifies the advisory lock held while migrating, so
+// concurrent app instances don't run the same migrations
+const migrationsLockID int64 = 7246738926
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migration files are named like 0001_create_links.up.sql
+var migrationFileRegexp = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a versioned change to the db schema
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus tells if and when a migration was applied
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies and reverts schema migrations embedded in the binary
+type Migrator struct {
+	conn       *pgxpool.Pool
+	migrations []Migration
+}
+
+// NewMigrator instantiates a Migrator for the embedded migrations
+func NewMigrator(conn *pgxpool.Pool) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationsFS)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		conn:       conn,
+		migrations: migrations,
+	}, nil
+}
+
+// loadMigrations reads migrations from the migrations dir, sorted by version
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		matches := migrationFileRegexp.FindStringSubmatch(e.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", e.Name())
+		}
+
+		version, _ := strconv.Atoi(matches[1])
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = m
+		}
+
+		if m.Name != matches[2] {
+			return nil, fmt.Errorf("migration version %d has different names: %s, %s", version, m.Name, matches[2])
+		}
+
+		content, err := fs.ReadFile(fsys, "migrations/"+e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		if matches[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// withLock runs fn in a single connection, holding the migrations advisory lock
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.conn.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationsLockID)
+	if err != nil {
+		return err
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationsLockID)
+
+	_, err = conn.Exec(
+		ctx,
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY NOT NULL,
+			name VARCHAR(200) NOT NULL,
+			appliedAt TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+	)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// inTx runs fn inside a transaction, committing only if fn succeeds
+func inTx(ctx context.Context, conn *pgxpool.Conn, fn func(tx pgx.Tx) error) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func appliedMigrations(ctx context.Context, conn *pgxpool.Conn) (map[int]time.Time, error) {
+	rows, err := conn.Query(ctx, "SELECT version, appliedAt FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// Up applies every pending migration, in version order. Returns the applied ones
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	done := []Migration{}
+
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			err = inTx(ctx, conn, func(tx pgx.Tx) error {
+				_, err := tx.Exec(ctx, migration.Up)
+				if err != nil {
+					return err
+				}
+
+				_, err = tx.Exec(
+					ctx,
+					"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)",
+					migration.Version, migration.Name,
+				)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Down reverts the last steps applied migrations. Returns the reverted ones
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	done := []Migration{}
+
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+
+			err = inTx(ctx, conn, func(tx pgx.Tx) error {
+				_, err := tx.Exec(ctx, migration.Down)
+				if err != nil {
+					return err
+				}
+
+				_, err = tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version=$1", migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to revert migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Status lists every known migration, and when it was applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	statuses := []MigrationStatus{}
+
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Migration: migration}
+			if appliedAt, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
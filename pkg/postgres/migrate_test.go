@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("Embedded", func(t *testing.T) {
+		migrations, err := loadMigrations(migrationsFS)
+		if err != nil {
+			t.Fatalf("failed to load embedded migrations: %v", err)
+		}
+
+		for i, m := range migrations {
+			if m.Version != i+1 {
+				t.Errorf("migration versions should be sequential, want %d, got %d", i+1, m.Version)
+			}
+		}
+	})
+
+	tt := []struct {
+		Name string
+		FS   fstest.MapFS
+	}{
+		{
+			Name: "InvalidFileName",
+			FS: fstest.MapFS{
+				"migrations/create_links.sql": {Data: []byte("SELECT 1")},
+			},
+		},
+		{
+			Name: "MissingDown",
+			FS: fstest.MapFS{
+				"migrations/0001_create_links.up.sql": {Data: []byte("SELECT 1")},
+			},
+		},
+		{
+			Name: "NameMismatch",
+			FS: fstest.MapFS{
+				"migrations/0001_create_links.up.sql":    {Data: []byte("SELECT 1")},
+				"migrations/0001_create_clicks.down.sql": {Data: []byte("SELECT 1")},
+			},
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := loadMigrations(test.FS)
+			if err == nil {
+				t.Error("expected loading migrations to fail, but got err: nil")
+			}
+		})
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	migrator, err := NewMigrator(GetConnection())
+	if err != nil {
+		t.Fatalf("failed to instantiate migrator: %v", err)
+	}
+
+	// test main already applied every migration
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("failed to migrate up: %v", err)
+	}
+
+	if len(applied) != 0 {
+		t.Errorf("expected no pending migrations, but applied %v", applied)
+	}
+
+	reverted, err := migrator.Down(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to migrate down: %v", err)
+	}
+
+	last := migrator.migrations[len(migrator.migrations)-1]
+	if len(reverted) != 1 || reverted[0].Version != last.Version {
+		t.Errorf("expected only the last migration to be reverted, but got %v", reverted)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("failed to get migrations status: %v", err)
+	}
+
+	for _, s := range statuses {
+		pending := s.AppliedAt == nil
+		if pending != (s.Version == last.Version) {
+			t.Errorf("wrong status for migration %d, pending = %v", s.Version, pending)
+		}
+	}
+
+	applied, err = migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("failed to migrate up: %v", err)
+	}
+
+	if len(applied) != 1 || applied[0].Version != last.Version {
+		t.Errorf("expected only the last migration to be applied, but got %v", applied)
+	}
+}
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS links (
+  slug CHAR(5) PRIMARY KEY NOT NULL,
+  url VARCHAR(200) NOT NULL,
+  createdAt TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
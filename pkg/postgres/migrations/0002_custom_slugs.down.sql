@@ -0,0 +1,2 @@
+-- fails if there are slugs longer than 5 chars, they must be removed first
+ALTER TABLE links ALTER COLUMN slug TYPE CHAR(5);
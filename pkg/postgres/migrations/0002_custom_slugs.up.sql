@@ -0,0 +1,2 @@
+-- custom slugs and grown generated slugs are longer than 5 chars
+ALTER TABLE links ALTER COLUMN slug TYPE VARCHAR(32);
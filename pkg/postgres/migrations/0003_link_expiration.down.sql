@@ -0,0 +1,3 @@
+ALTER TABLE links DROP COLUMN IF EXISTS clicks;
+ALTER TABLE links DROP COLUMN IF EXISTS maxClicks;
+ALTER TABLE links DROP COLUMN IF EXISTS expiresAt;
@@ -0,0 +1,5 @@
+-- links may expire by date or after a maximum amount of clicks. A maxClicks
+-- of 0 means the link has no click limit
+ALTER TABLE links ADD COLUMN IF NOT EXISTS expiresAt TIMESTAMP WITH TIME ZONE;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS maxClicks INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS clicks INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,11 @@
+-- every redirect is recorded as a click, for analytics purposes
+CREATE TABLE IF NOT EXISTS clicks (
+  id BIGSERIAL PRIMARY KEY,
+  slug VARCHAR(32) NOT NULL,
+  referrer TEXT NOT NULL DEFAULT '',
+  userAgent TEXT NOT NULL DEFAULT '',
+  remoteAddr VARCHAR(45) NOT NULL DEFAULT '',
+  createdAt TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS clicks_slug_createdAt_idx ON clicks (slug, createdAt);
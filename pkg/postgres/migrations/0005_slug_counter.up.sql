@@ -0,0 +1,2 @@
+-- source of unique numbers for the counter slug generator
+CREATE SEQUENCE IF NOT EXISTS slug_counter_seq;
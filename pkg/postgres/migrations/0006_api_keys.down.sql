@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS links_owner_idx;
+ALTER TABLE links DROP COLUMN IF EXISTS owner;
+DROP TABLE IF EXISTS api_keys;
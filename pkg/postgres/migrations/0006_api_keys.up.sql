@@ -0,0 +1,12 @@
+-- only the sha256 hash of api keys is stored, keys are shown once on creation
+CREATE TABLE IF NOT EXISTS api_keys (
+  hash CHAR(64) PRIMARY KEY NOT NULL,
+  owner VARCHAR(64) NOT NULL,
+  name VARCHAR(200) NOT NULL DEFAULT '',
+  createdAt TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- links created before api keys existed have no owner, and are only visible
+-- to admins
+ALTER TABLE links ADD COLUMN IF NOT EXISTS owner VARCHAR(64) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS links_owner_idx ON links (owner);
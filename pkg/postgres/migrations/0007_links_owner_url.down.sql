@@ -0,0 +1 @@
+DROP INDEX IF EXISTS links_owner_url_idx;
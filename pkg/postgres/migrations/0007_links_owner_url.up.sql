@@ -0,0 +1,3 @@
+-- used to find existing links to the same url, when deduping. Urls can be too
+-- long for a btree index, so their hash is indexed instead
+CREATE INDEX IF NOT EXISTS links_owner_url_idx ON links (owner, md5(url));
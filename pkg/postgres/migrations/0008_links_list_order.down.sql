@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS links_owner_createdat_slug_idx;
+DROP INDEX IF EXISTS links_createdat_slug_idx;
@@ -0,0 +1,4 @@
+-- links are listed sorted by creation date and slug, so pages can be fetched
+-- after a cursor without scanning the previous ones
+CREATE INDEX IF NOT EXISTS links_createdat_slug_idx ON links (createdAt, slug);
+CREATE INDEX IF NOT EXISTS links_owner_createdat_slug_idx ON links (owner, createdAt, slug);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS links_url_trgm_idx;
+DROP INDEX IF EXISTS links_tags_idx;
+ALTER TABLE links DROP COLUMN IF EXISTS tags;
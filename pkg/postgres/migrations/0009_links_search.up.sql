@@ -0,0 +1,13 @@
+-- links may be tagged, to be found by tag when listing
+ALTER TABLE links ADD COLUMN IF NOT EXISTS tags TEXT[];
+CREATE INDEX IF NOT EXISTS links_tags_idx ON links USING gin (tags);
+-- trigrams make url substring searches use an index, instead of a full scan.
+-- Creating extensions requires a superuser, see docker/postgres/init.sql
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm') THEN
+    RAISE EXCEPTION 'extension pg_trgm is missing, a superuser must run CREATE EXTENSION pg_trgm';
+  END IF;
+END
+$$;
+CREATE INDEX IF NOT EXISTS links_url_trgm_idx ON links USING gin (url gin_trgm_ops);
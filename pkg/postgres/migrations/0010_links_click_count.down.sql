@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS links_owner_clickcount_slug_idx;
+DROP INDEX IF EXISTS links_clickcount_slug_idx;
+DROP TRIGGER IF EXISTS links_count_clicks ON clicks;
+DROP FUNCTION IF EXISTS links_count_clicks();
+ALTER TABLE links DROP COLUMN IF EXISTS clickCount;
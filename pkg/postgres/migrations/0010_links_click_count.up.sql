@@ -0,0 +1,25 @@
+-- links keep the count of their recorded clicks, so listing them by clicks
+-- doesn't count the clicks of every link. A trigger counts inserted clicks,
+-- it's created before counting the existing ones, since it locks inserts out
+ALTER TABLE links ADD COLUMN IF NOT EXISTS clickCount BIGINT NOT NULL DEFAULT 0;
+
+CREATE OR REPLACE FUNCTION links_count_clicks() RETURNS trigger AS $$
+BEGIN
+  UPDATE links SET clickCount = links.clickCount + inserted.clicks
+  FROM (SELECT slug, count(*) AS clicks FROM new_clicks GROUP BY slug) AS inserted
+  WHERE links.slug = inserted.slug;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS links_count_clicks ON clicks;
+CREATE TRIGGER links_count_clicks AFTER INSERT ON clicks
+  REFERENCING NEW TABLE AS new_clicks
+  FOR EACH STATEMENT EXECUTE FUNCTION links_count_clicks();
+
+UPDATE links SET clickCount = counted.clicks
+FROM (SELECT slug, count(*) AS clicks FROM clicks GROUP BY slug) AS counted
+WHERE links.slug = counted.slug;
+
+CREATE INDEX IF NOT EXISTS links_clickcount_slug_idx ON links (clickCount, slug);
+CREATE INDEX IF NOT EXISTS links_owner_clickcount_slug_idx ON links (owner, clickCount, slug);
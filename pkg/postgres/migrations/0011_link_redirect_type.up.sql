@@ -0,0 +1,3 @@
+-- links may redirect with a status code of their own. A redirectType of 0
+-- means the link redirects with the configured default
+ALTER TABLE links ADD COLUMN IF NOT EXISTS redirectType SMALLINT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- fails while there are links with urls longer than 200 chars
+ALTER TABLE links ALTER COLUMN url TYPE VARCHAR(200);
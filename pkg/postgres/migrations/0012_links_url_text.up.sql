@@ -0,0 +1,3 @@
+-- urls were limited to 200 chars, shorter than many real world urls. Their
+-- length is now checked by the app instead
+ALTER TABLE links ALTER COLUMN url TYPE TEXT;
@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+type slugCounter struct {
+	conn *pgxpool.Pool
+}
+
+// NewSlugCounter instantiates a counter for generating slugs, backed by a
+// postgres sequence
+func NewSlugCounter(conn *pgxpool.Pool) shortener.SlugCounter {
+	return &slugCounter{
+		conn: conn,
+	}
+}
+
+func (c *slugCounter) Next(ctx context.Context) (uint64, error) {
+	var n int64
+	err := c.conn.QueryRow(ctx, "SELECT nextval('slug_counter_seq')").Scan(&n)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(n), nil
+}
@@ -0,0 +1,24 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSlugCounterNext(t *testing.T) {
+	counter := NewSlugCounter(GetConnection())
+
+	first, err := counter.Next(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get next counter value: %v", err)
+	}
+
+	second, err := counter.Next(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get next counter value: %v", err)
+	}
+
+	if second <= first {
+		t.Errorf("counter should be increasing, but got %d after %d", second, first)
+	}
+}
@@ -0,0 +1,206 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// ErrInvalidOptions is returned when QR code options are out of bounds
+var ErrInvalidOptions = errors.New("QR code options are not valid")
+
+// Format is the image format of a rendered QR code
+type Format string
+
+// Supported formats
+const (
+	PNG Format = "png"
+	SVG Format = "svg"
+)
+
+// ContentType is the media type of the format
+func (f Format) ContentType() string {
+	if f == SVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// Level is the error correction level, higher levels survive more damage to
+// the printed code, at the cost of denser codes
+type Level string
+
+// Error correction levels, recovering 7%, 15%, 25% and 30% of the code
+const (
+	LevelLow      Level = "L"
+	LevelMedium   Level = "M"
+	LevelQuartile Level = "Q"
+	LevelHigh     Level = "H"
+)
+
+var recoveryLevels = map[Level]qrcode.RecoveryLevel{
+	LevelLow:      qrcode.Low,
+	LevelMedium:   qrcode.Medium,
+	LevelQuartile: qrcode.High,
+	LevelHigh:     qrcode.Highest,
+}
+
+// bounds of the options, large images are expensive to render and to cache
+const (
+	MinSize   = 64
+	MaxSize   = 2048
+	MaxMargin = 16
+)
+
+// Options configure how a QR code is rendered
+type Options struct {
+	Format Format
+	// Size is the width and height of the image, in pixels
+	Size int
+	// Margin is the blank border around the code, in modules. Scanners need
+	// 4 modules, unless the code is printed over a blank background
+	Margin     int
+	Level      Level
+	Foreground color.RGBA
+	Background color.RGBA
+}
+
+// DefaultOptions render black on white 256px PNGs, with medium error correction
+func DefaultOptions() Options {
+	return Options{
+		Format:     PNG,
+		Size:       256,
+		Margin:     4,
+		Level:      LevelMedium,
+		Foreground: color.RGBA{A: 0xff},
+		Background: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+}
+
+// Validate checks if the options are within bounds
+func (o *Options) Validate() error {
+	if o.Format != PNG && o.Format != SVG {
+		return fmt.Errorf("%w: format must be png or svg", ErrInvalidOptions)
+	}
+
+	if o.Size < MinSize || o.Size > MaxSize {
+		return fmt.Errorf("%w: size must be between %d and %d", ErrInvalidOptions, MinSize, MaxSize)
+	}
+
+	if o.Margin < 0 || o.Margin > MaxMargin {
+		return fmt.Errorf("%w: margin must be between 0 and %d", ErrInvalidOptions, MaxMargin)
+	}
+
+	if _, ok := recoveryLevels[o.Level]; !ok {
+		return fmt.Errorf("%w: level must be one of L, M, Q or H", ErrInvalidOptions)
+	}
+
+	return nil
+}
+
+// ParseColor parses hex colors in the rgb or rrggbb forms, with or without a
+// leading #
+func ParseColor(s string) (color.RGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if len(s) != 6 || err != nil {
+		return color.RGBA{}, fmt.Errorf("%w: colors must be hex, like 000 or ff8800", ErrInvalidOptions)
+	}
+
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// Render encodes content as a QR code image
+func Render(content string, opts Options) ([]byte, error) {
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := qrcode.New(content, recoveryLevels[opts.Level])
+	if err != nil {
+		return nil, err
+	}
+	// the margin is drawn here, since the encoder's one has a fixed size
+	code.DisableBorder = true
+	modules := code.Bitmap()
+
+	side := len(modules) + 2*opts.Margin
+	if opts.Size < side {
+		return nil, fmt.Errorf("%w: size must be at least %d for this code", ErrInvalidOptions, side)
+	}
+
+	if opts.Format == SVG {
+		return renderSVG(modules, opts, side), nil
+	}
+	return renderPNG(modules, opts, side)
+}
+
+// renderPNG scales modules by whole pixels, so they stay sharp, and centers
+// the code in the remaining space
+func renderPNG(modules [][]bool, opts Options, side int) ([]byte, error) {
+	scale := opts.Size / side
+	offset := (opts.Size-scale*side)/2 + scale*opts.Margin
+
+	img := image.NewPaletted(
+		image.Rect(0, 0, opts.Size, opts.Size),
+		color.Palette{opts.Background, opts.Foreground},
+	)
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex(offset+x*scale+dx, offset+y*scale+dy, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderSVG draws a module per unit of the view box, scaled to the size
+func renderSVG(modules [][]bool, opts Options, side int) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(
+		&buf,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, side, side,
+	)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`, side, side, hex(opts.Background))
+
+	fmt.Fprintf(&buf, `<path fill="%s" d="`, hex(opts.Foreground))
+	for y, row := range modules {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x+opts.Margin, y+opts.Margin)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+
+	return buf.Bytes()
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,155 @@
+package qr_test
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/joao-fontenele/go-url-shortener/pkg/qr"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		Input   string
+		Want    color.RGBA
+		WantErr error
+	}{
+		{Input: "ff8800", Want: color.RGBA{R: 0xff, G: 0x88, A: 0xff}},
+		{Input: "#FF8800", Want: color.RGBA{R: 0xff, G: 0x88, A: 0xff}},
+		{Input: "f80", Want: color.RGBA{R: 0xff, G: 0x88, A: 0xff}},
+		{Input: "red", WantErr: qr.ErrInvalidOptions},
+		{Input: "ff88", WantErr: qr.ErrInvalidOptions},
+		{Input: "", WantErr: qr.ErrInvalidOptions},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Input, func(t *testing.T) {
+			got, err := qr.ParseColor(tc.Input)
+			if !errors.Is(err, tc.WantErr) {
+				t.Fatalf("Wrong error (want, got): (%v, %v)", tc.WantErr, err)
+			}
+
+			if diff := cmp.Diff(tc.Want, got); diff != "" {
+				t.Errorf("Wrong color (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	red := color.RGBA{R: 0xff, A: 0xff}
+
+	t.Run("PNG", func(t *testing.T) {
+		opts := qr.DefaultOptions()
+		opts.Size = 300
+		opts.Margin = 2
+		opts.Foreground = red
+
+		b, err := qr.Render("https://sho.rt/a1CDz", opts)
+		if err != nil {
+			t.Fatalf("Unexpected error rendering png: %v", err)
+		}
+
+		img, err := png.Decode(bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("Rendered image isn't a valid png: %v", err)
+		}
+
+		if size := img.Bounds().Size(); size.X != 300 || size.Y != 300 {
+			t.Errorf("Wrong image size (want, got): (300x300, %dx%d)", size.X, size.Y)
+		}
+
+		// the corner is blank margin, and the diagonal reaches the finder
+		// pattern after the margin
+		if got := color.RGBAModel.Convert(img.At(0, 0)); got != opts.Background {
+			t.Errorf("Expected corner to be background, but got: %v", got)
+		}
+		marginEnd := -1
+		for i := 0; i < 300 && marginEnd < 0; i++ {
+			if color.RGBAModel.Convert(img.At(i, i)) == red {
+				marginEnd = i
+			}
+		}
+		if marginEnd < 2 || marginEnd > 300/4 {
+			t.Errorf("Expected the foreground after a margin, but it starts at: %d", marginEnd)
+		}
+	})
+
+	t.Run("SVG", func(t *testing.T) {
+		opts := qr.DefaultOptions()
+		opts.Format = qr.SVG
+		opts.Background = red
+
+		b, err := qr.Render("https://sho.rt/a1CDz", opts)
+		if err != nil {
+			t.Fatalf("Unexpected error rendering svg: %v", err)
+		}
+
+		svg := string(b)
+		for _, want := range []string{`<svg `, `width="256"`, `viewBox="0 0 `, `fill="#ff0000"`, `fill="#000000"`, `M4 4h1v1h-1z`} {
+			if !strings.Contains(svg, want) {
+				t.Errorf("Expected svg to contain %s, but got: %s", want, svg)
+			}
+		}
+	})
+
+	t.Run("InvalidOptions", func(t *testing.T) {
+		tests := map[string]func(o *qr.Options){
+			"Format":    func(o *qr.Options) { o.Format = "gif" },
+			"SizeSmall": func(o *qr.Options) { o.Size = 10 },
+			"SizeLarge": func(o *qr.Options) { o.Size = 10000 },
+			"Margin":    func(o *qr.Options) { o.Margin = -1 },
+			"Level":     func(o *qr.Options) { o.Level = "X" },
+		}
+
+		for name, change := range tests {
+			opts := qr.DefaultOptions()
+			change(&opts)
+
+			_, err := qr.Render("https://sho.rt/a1CDz", opts)
+			if !errors.Is(err, qr.ErrInvalidOptions) {
+				t.Errorf("%s: expected ErrInvalidOptions, but got: %v", name, err)
+			}
+		}
+
+		// long urls need more modules than the smallest size has pixels
+		opts := qr.DefaultOptions()
+		opts.Size = qr.MinSize
+		_, err := qr.Render("https://sho.rt/"+strings.Repeat("a", 200), opts)
+		if !errors.Is(err, qr.ErrInvalidOptions) {
+			t.Errorf("TooDense: expected ErrInvalidOptions, but got: %v", err)
+		}
+	})
+}
+
+func TestRendererCache(t *testing.T) {
+	r := qr.NewRenderer(1)
+	opts := qr.DefaultOptions()
+
+	first, err := r.Render("https://sho.rt/first", opts)
+	if err != nil {
+		t.Fatalf("Unexpected error rendering: %v", err)
+	}
+
+	cached, _ := r.Render("https://sho.rt/first", opts)
+	if &cached[0] != &first[0] {
+		t.Error("Expected the second render to be cached")
+	}
+
+	opts.Size = 512
+	resized, _ := r.Render("https://sho.rt/first", opts)
+	if &resized[0] == &first[0] {
+		t.Error("Expected codes with other options to be rendered")
+	}
+
+	// the cache holds a single code, so the first one was evicted
+	opts.Size = 256
+	evicted, _ := r.Render("https://sho.rt/first", opts)
+	if &evicted[0] == &first[0] {
+		t.Error("Expected the least recently used code to be evicted")
+	}
+}
@@ -0,0 +1,91 @@
+package qr
+
+import (
+	"container/list"
+	"sync"
+)
+
+type cacheKey struct {
+	content string
+	opts    Options
+}
+
+type cacheEntry struct {
+	key   cacheKey
+	image []byte
+}
+
+// Renderer renders QR codes, caching the images of the most recently rendered
+// ones, since the same codes tend to be requested repeatedly. It's safe for
+// concurrent use
+type Renderer struct {
+	size int
+
+	mu      sync.Mutex
+	entries map[cacheKey]*list.Element
+	// lru holds entries from the most to the least recently used
+	lru *list.List
+}
+
+// NewRenderer instantiates a Renderer caching at most size images, a non
+// positive size disables the cache
+func NewRenderer(size int) *Renderer {
+	return &Renderer{
+		size:    size,
+		entries: make(map[cacheKey]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Render encodes content as a QR code image, see Render. The returned bytes
+// are shared, callers must not change them
+func (r *Renderer) Render(content string, opts Options) ([]byte, error) {
+	key := cacheKey{content: content, opts: opts}
+	if image, ok := r.get(key); ok {
+		return image, nil
+	}
+
+	image, err := Render(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	r.put(key, image)
+	return image, nil
+}
+
+func (r *Renderer) get(key cacheKey) ([]byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	el, ok := r.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	r.lru.MoveToFront(el)
+	return el.Value.(*cacheEntry).image, true
+}
+
+func (r *Renderer) put(key cacheKey, image []byte) {
+	if r.size <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// a concurrent render of the same code may have cached it already
+	if el, ok := r.entries[key]; ok {
+		r.lru.MoveToFront(el)
+		return
+	}
+
+	for r.lru.Len() >= r.size {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.entries, oldest.Value.(*cacheEntry).key)
+	}
+
+	r.entries[key] = r.lru.PushFront(&cacheEntry{key: key, image: image})
+}
@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/logger"
+	"github.com/joao-fontenele/go-url-shortener/pkg/metrics"
+	"go.uber.org/zap"
+)
+
+// fallbackCooldown is how long the fallback is used after the primary limiter
+// fails, so a limiter that is down doesn't slow every request
+const fallbackCooldown = 10 * time.Second
+
+type fallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+
+	mu          sync.Mutex
+	failedUntil time.Time
+}
+
+// NewFallbackLimiter instantiates a Limiter that uses primary, unless it fails,
+// in which case fallback is used for a while
+func NewFallbackLimiter(primary, fallback Limiter) Limiter {
+	return &fallbackLimiter{
+		primary:  primary,
+		fallback: fallback,
+	}
+}
+
+func (f *fallbackLimiter) Allow(ctx context.Context, key string, l Limit) (*Result, error) {
+	f.mu.Lock()
+	failed := time.Now().Before(f.failedUntil)
+	f.mu.Unlock()
+
+	if !failed {
+		r, err := f.primary.Allow(ctx, key, l)
+		if err == nil {
+			return r, nil
+		}
+
+		logger.Get().Warn("Rate limiter failed, using fallback", zap.Error(err))
+		f.mu.Lock()
+		f.failedUntil = time.Now().Add(fallbackCooldown)
+		f.mu.Unlock()
+	}
+
+	metrics.RateLimitFallbackCounter.Inc()
+	return f.fallback.Allow(ctx, key, l)
+}
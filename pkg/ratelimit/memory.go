@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often stale windows are removed from memory
+const sweepInterval = time.Minute
+
+type counter struct {
+	start    time.Time
+	window   time.Duration
+	previous int
+	current  int
+}
+
+type memoryLimiter struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryLimiter instantiates a Limiter that counts requests in memory, so
+// limits are enforced per app instance only
+func NewMemoryLimiter() Limiter {
+	return newMemoryLimiter(time.Now)
+}
+
+func newMemoryLimiter(now func() time.Time) *memoryLimiter {
+	return &memoryLimiter{
+		counters:  make(map[string]*counter),
+		lastSweep: now(),
+		now:       now,
+	}
+}
+
+func (m *memoryLimiter) Allow(ctx context.Context, key string, l Limit) (*Result, error) {
+	now := m.now()
+	start, elapsed := windowStart(now, l.Window)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+
+	c, ok := m.counters[key]
+	if !ok || c.window != l.Window {
+		c = &counter{start: start, window: l.Window}
+		m.counters[key] = c
+	}
+
+	// slide the windows forward
+	if !c.start.Equal(start) {
+		if c.start.Add(l.Window).Equal(start) {
+			c.previous = c.current
+		} else {
+			c.previous = 0
+		}
+		c.current = 0
+		c.start = start
+	}
+
+	r := Evaluate(l, c.previous, c.current, elapsed)
+	if r.Allowed {
+		c.current++
+	}
+
+	return r, nil
+}
+
+// sweep removes counters that can't affect any limit anymore
+func (m *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+
+	for key, c := range m.counters {
+		if now.Sub(c.start) >= 2*c.window {
+			delete(m.counters, key)
+		}
+	}
+	m.lastSweep = now
+}
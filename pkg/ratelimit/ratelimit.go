@@ -0,0 +1,83 @@
+// Package ratelimit limits how many requests a client may do in a time window.
+// Limiters use a sliding window counter: the count of the previous window is
+// weighted by how much of it still overlaps the sliding window, and added to
+// the count of the current window
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit is the amount of requests allowed in a window
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Result tells if a request is allowed, and how much of the limit is left
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter is when the current window ends
+	ResetAfter time.Duration
+	// RetryAfter is how long until a request would be allowed, if it's not
+	RetryAfter time.Duration
+}
+
+// Limiter counts requests by key, and tells if a new one is allowed
+type Limiter interface {
+	Allow(ctx context.Context, key string, l Limit) (*Result, error)
+}
+
+// windowStart aligns now to the beginning of its window, and returns how much
+// of the window has elapsed
+func windowStart(now time.Time, window time.Duration) (time.Time, time.Duration) {
+	start := now.Truncate(window)
+	return start, now.Sub(start)
+}
+
+// Evaluate decides if a new request is allowed, given the counts of the
+// previous and current windows, before counting the new request
+func Evaluate(l Limit, previous, current int, elapsed time.Duration) *Result {
+	window := float64(l.Window)
+	weight := (window - float64(elapsed)) / window
+	count := float64(previous)*weight + float64(current)
+
+	r := &Result{
+		Limit:      l.Requests,
+		ResetAfter: l.Window - elapsed,
+	}
+
+	if count+1 <= float64(l.Requests) {
+		r.Allowed = true
+		r.Remaining = int(math.Floor(float64(l.Requests) - count - 1))
+		return r
+	}
+
+	r.RetryAfter = retryAfter(l, previous, current, elapsed)
+	return r
+}
+
+// retryAfter is how long until the weighted count has room for a request
+func retryAfter(l Limit, previous, current int, elapsed time.Duration) time.Duration {
+	window := float64(l.Window)
+	room := float64(l.Requests - current - 1)
+
+	// there's room in the current window, once enough of the previous one
+	// slides out: previous * (window - elapsed - t) / window <= room
+	if room >= 0 && previous > 0 {
+		t := window - float64(elapsed) - room*window/float64(previous)
+		return time.Duration(math.Ceil(math.Max(t, 0)))
+	}
+
+	// otherwise wait for the next window, where the current one becomes the
+	// previous: current * (window - t) / window <= requests - 1
+	t := window
+	if current > 0 {
+		t = window * (1 - float64(l.Requests-1)/float64(current))
+	}
+	return l.Window - elapsed + time.Duration(math.Ceil(math.Max(t, 0)))
+}
@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEvaluate(t *testing.T) {
+	l := Limit{Requests: 10, Window: time.Minute}
+
+	tests := []struct {
+		Name     string
+		Previous int
+		Current  int
+		Elapsed  time.Duration
+		Want     *Result
+	}{
+		{
+			Name:    "Empty",
+			Elapsed: 15 * time.Second,
+			Want:    &Result{Allowed: true, Limit: 10, Remaining: 9, ResetAfter: 45 * time.Second},
+		},
+		{
+			Name:     "WeightedPrevious",
+			Previous: 8,
+			Current:  2,
+			Elapsed:  30 * time.Second,
+			Want:     &Result{Allowed: true, Limit: 10, Remaining: 3, ResetAfter: 30 * time.Second},
+		},
+		{
+			Name:     "LimitedUntilPreviousSlidesOut",
+			Previous: 10,
+			Current:  5,
+			Elapsed:  15 * time.Second,
+			// 10 * (45s - t) / 60s <= 4, so t >= 21s
+			Want: &Result{Allowed: false, Limit: 10, ResetAfter: 45 * time.Second, RetryAfter: 21 * time.Second},
+		},
+		{
+			Name:    "LimitedUntilNextWindow",
+			Current: 10,
+			Elapsed: 45 * time.Second,
+			// next window starts in 15s, then 10 * (60s - t) / 60s <= 9, so t >= 6s
+			Want: &Result{Allowed: false, Limit: 10, ResetAfter: 15 * time.Second, RetryAfter: 21 * time.Second},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			got := Evaluate(l, tc.Previous, tc.Current, tc.Elapsed)
+			if diff := cmp.Diff(tc.Want, got); diff != "" {
+				t.Errorf("Evaluate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	now := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	m := newMemoryLimiter(func() time.Time { return now })
+	l := Limit{Requests: 3, Window: time.Minute}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		r, _ := m.Allow(ctx, "key", l)
+		if !r.Allowed {
+			t.Fatalf("Expected request %d to be allowed", i)
+		}
+
+		if r.Remaining != 2-i {
+			t.Errorf("Wrong remaining requests (want, got): (%d, %d)", 2-i, r.Remaining)
+		}
+	}
+
+	if r, _ := m.Allow(ctx, "key", l); r.Allowed {
+		t.Error("Expected request over the limit to be denied")
+	}
+
+	if r, _ := m.Allow(ctx, "other", l); !r.Allowed {
+		t.Error("Expected requests of other keys to be counted separately")
+	}
+
+	// half of the previous window still counts, 3 * 0.5 = 1.5 requests
+	now = now.Add(90 * time.Second)
+	if r, _ := m.Allow(ctx, "key", l); !r.Allowed || r.Remaining != 0 {
+		t.Errorf("Expected one request to be allowed in the sliding window, but got: %+v", r)
+	}
+
+	if r, _ := m.Allow(ctx, "key", l); r.Allowed {
+		t.Error("Expected request over the sliding window limit to be denied")
+	}
+
+	// stale counters are swept
+	now = now.Add(10 * time.Minute)
+	m.Allow(ctx, "key", l)
+	if len(m.counters) != 1 {
+		t.Errorf("Expected stale counters to be removed, but got: %v", m.counters)
+	}
+}
+
+type fakeLimiter struct {
+	calls int
+	err   error
+}
+
+func (f *fakeLimiter) Allow(ctx context.Context, key string, l Limit) (*Result, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &Result{Allowed: true}, nil
+}
+
+func TestFallbackLimiter(t *testing.T) {
+	primary := &fakeLimiter{err: errors.New("ConnectionRefused")}
+	fallback := &fakeLimiter{}
+	f := NewFallbackLimiter(primary, fallback)
+	l := Limit{Requests: 3, Window: time.Minute}
+
+	for i := 0; i < 3; i++ {
+		r, err := f.Allow(context.Background(), "key", l)
+		if err != nil || !r.Allowed {
+			t.Fatalf("Expected fallback to allow request, but got: %v, %v", r, err)
+		}
+	}
+
+	if primary.calls != 1 {
+		t.Errorf("Expected failing primary to be skipped during cooldown, but it was called %d times", primary.calls)
+	}
+
+	if fallback.calls != 3 {
+		t.Errorf("Expected fallback to be called 3 times, but got %d", fallback.calls)
+	}
+}
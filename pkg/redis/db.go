@@ -41,3 +41,9 @@ func Connect() (func() error, error) {
 func GetConnection() *redis.Client {
 	return rdb
 }
+
+// Ping checks that redis is reachable, through a previously created connection
+// pool
+func Ping(ctx context.Context) error {
+	return rdb.Ping(ctx).Err()
+}
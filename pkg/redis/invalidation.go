@@ -0,0 +1,192 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/joao-fontenele/go-url-shortener/pkg/configger"
+	"github.com/joao-fontenele/go-url-shortener/pkg/logger"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+	"go.uber.org/zap"
+)
+
+const (
+	// invalidationPingInterval is how long the subscription can be idle before
+	// its connection is checked. A ping without answer in that time means the
+	// connection is broken
+	invalidationPingInterval = 30 * time.Second
+	invalidationMaxBackoff   = 5 * time.Second
+)
+
+var errSubscriberClosed = errors.New("invalidation subscriber is closed")
+
+func formatInvalidationChannel() string {
+	prefix := configger.Get().Cache.CachePrefix
+	return fmt.Sprintf("%s^invalidations", prefix)
+}
+
+type linkInvalidator struct {
+	conn *redis.Client
+}
+
+// NewLinkInvalidator instantiates a LinkInvalidator that publishes the slugs to
+// a redis channel, read by SubscribeInvalidations in every app instance
+func NewLinkInvalidator(conn *redis.Client) shortener.LinkInvalidator {
+	return &linkInvalidator{
+		conn: conn,
+	}
+}
+
+// Invalidate publishes all slugs in a single message, one per line, since
+// slugs can't have line breaks
+func (li *linkInvalidator) Invalidate(ctx context.Context, slugs ...string) error {
+	if len(slugs) == 0 {
+		return nil
+	}
+
+	return li.conn.Publish(ctx, formatInvalidationChannel(), strings.Join(slugs, "\n")).Err()
+}
+
+// Subscriber receives link invalidations published by the app instances
+type Subscriber struct {
+	conn  *redis.Client
+	evict func(slugs []string)
+	purge func()
+
+	mu     sync.Mutex
+	pubsub *redis.PubSub
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// SubscribeInvalidations calls evict with the slugs of every invalidation, in
+// the background, until the Subscriber is closed. When the connection fails,
+// it reconnects with backoff. Invalidations published while disconnected are
+// lost, so purge is called every time the subscription is established
+func SubscribeInvalidations(conn *redis.Client, evict func(slugs []string), purge func()) *Subscriber {
+	s := &Subscriber{
+		conn:  conn,
+		evict: evict,
+		purge: purge,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	go s.run()
+	return s
+}
+
+// Close stops receiving invalidations, and waits for the subscription to end
+func (s *Subscriber) Close() error {
+	s.mu.Lock()
+	close(s.stop)
+	var err error
+	if s.pubsub != nil {
+		err = s.pubsub.Close()
+		s.pubsub = nil
+	}
+	s.mu.Unlock()
+
+	<-s.done
+	return err
+}
+
+func (s *Subscriber) run() {
+	defer close(s.done)
+
+	failures := 0
+	for {
+		subscribed, err := s.listen()
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+
+		if subscribed {
+			failures = 0
+		}
+		failures++
+
+		backoff := time.Duration(failures*failures) * 100 * time.Millisecond
+		if backoff > invalidationMaxBackoff {
+			backoff = invalidationMaxBackoff
+		}
+
+		logger.Get().Warn(
+			"Link invalidations subscription failed, reconnecting",
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// listen receives invalidations until the connection fails. Returns whether
+// the subscription was established before failing
+func (s *Subscriber) listen() (bool, error) {
+	ctx := context.Background()
+
+	pubsub := s.conn.Subscribe(ctx, formatInvalidationChannel())
+
+	s.mu.Lock()
+	select {
+	case <-s.stop:
+		s.mu.Unlock()
+		pubsub.Close()
+		return false, errSubscriberClosed
+	default:
+	}
+	s.pubsub = pubsub
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		// Close may have closed it already
+		if s.pubsub == pubsub {
+			s.pubsub = nil
+			pubsub.Close()
+		}
+	}()
+
+	subscribed := false
+	pinged := false
+	for {
+		msg, err := pubsub.ReceiveTimeout(ctx, invalidationPingInterval)
+		if err != nil {
+			var netErr net.Error
+			if !pinged && errors.As(err, &netErr) && netErr.Timeout() {
+				pinged = true
+				err = pubsub.Ping(ctx)
+				if err == nil {
+					continue
+				}
+			}
+			return subscribed, err
+		}
+
+		pinged = false
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			if msg.Kind == "subscribe" {
+				subscribed = true
+				s.purge()
+			}
+		case *redis.Message:
+			s.evict(strings.Split(msg.Payload, "\n"))
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInvalidations(t *testing.T) {
+	conn := GetConnection()
+
+	evicted := make(chan []string, 1)
+	purged := make(chan struct{}, 1)
+	s := SubscribeInvalidations(
+		conn,
+		func(slugs []string) { evicted <- slugs },
+		func() { purged <- struct{}{} },
+	)
+	defer s.Close()
+
+	select {
+	case <-purged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected cache to be purged when subscribed")
+	}
+
+	err := NewLinkInvalidator(conn).Invalidate(context.Background(), "a1CDz", "b2DEz")
+	if err != nil {
+		t.Fatalf("failed to publish invalidation: %v", err)
+	}
+
+	select {
+	case got := <-evicted:
+		if diff := cmp.Diff([]string{"a1CDz", "b2DEz"}, got); diff != "" {
+			t.Errorf("evicted slugs different from expected (-want +got):\n%s", diff)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected invalidated slugs to be evicted")
+	}
+}
@@ -23,41 +23,121 @@ func NewLinkDao(conn *redis.Client) shortener.LinkDao {
 	}
 }
 
+// notFoundValue is cached for slugs that don't exist, so lookups for them don't
+// reach the db. It isn't valid json, so it can't be mistaken by a link
+const notFoundValue = "!"
+
 func formatCacheString(slug string) string {
 	prefix := configger.Get().Cache.CachePrefix
 	return fmt.Sprintf("%s^l^%s", prefix, slug)
 }
 
+// linkTTL is the cache expiration for a link, it shouldn't outlive the link
+// itself. Returns a non positive duration if the link is already expired
+func linkTTL(l *shortener.Link) time.Duration {
+	ttl := time.Duration(configger.Get().Cache.LinksTTLSeconds) * time.Second
+
+	if l.ExpiresAt != nil {
+		remaining := time.Until(*l.ExpiresAt)
+		if remaining < ttl {
+			return remaining
+		}
+	}
+
+	return ttl
+}
+
 func (d *dao) Find(ctx context.Context, slug string) (*shortener.Link, error) {
 	link := shortener.Link{}
 	str, err := d.conn.Get(ctx, formatCacheString(slug)).Result()
 
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
-			return nil, shortener.ErrLinkNotFound
+			return nil, shortener.ErrCacheMiss
 		}
 		return nil, err
 	}
 
+	if str == notFoundValue {
+		return nil, shortener.ErrLinkNotFound
+	}
+
 	json.Unmarshal([]byte(str), &link)
 
 	return &link, nil
 }
 
+// MarkNotFound caches that a slug doesn't exist, for cache.notFoundTTLSeconds.
+// Inserting the slug overwrites the mark. A non positive ttl disables it
+func (d *dao) MarkNotFound(ctx context.Context, slug string) error {
+	ttl := time.Duration(configger.Get().Cache.NotFoundTTLSeconds) * time.Second
+	if ttl <= 0 {
+		return nil
+	}
+
+	return d.conn.Set(ctx, formatCacheString(slug), notFoundValue, ttl).Err()
+}
+
 func (d *dao) Insert(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
+	ttl := linkTTL(l)
+	if ttl <= 0 {
+		// there's no point in caching expired links
+		return l, nil
+	}
+
 	val, _ := json.Marshal(l)
 	err := d.conn.Set(
 		ctx,
 		formatCacheString(l.Slug),
 		val,
-		time.Duration(configger.Get().Cache.LinksTTLSeconds)*time.Second,
+		ttl,
 	).Err()
 
 	return l, err
 }
 
+// InsertMany caches all links in a single round trip, using a pipeline
+func (d *dao) InsertMany(ctx context.Context, links []shortener.Link) error {
+	pipe := d.conn.Pipeline()
+	for i := range links {
+		ttl := linkTTL(&links[i])
+		if ttl <= 0 {
+			continue
+		}
+
+		val, _ := json.Marshal(links[i])
+		pipe.Set(ctx, formatCacheString(links[i].Slug), val, ttl)
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
 func (d *dao) Update(ctx context.Context, l *shortener.Link) error {
-	panic("not yet implemented")
+	ttl := linkTTL(l)
+	if ttl <= 0 {
+		return d.Delete(ctx, l.Slug)
+	}
+
+	val, _ := json.Marshal(l)
+
+	// only overwrite links that are already cached
+	updated, err := d.conn.SetXX(
+		ctx,
+		formatCacheString(l.Slug),
+		val,
+		ttl,
+	).Result()
+
+	if err != nil {
+		return err
+	}
+
+	if !updated {
+		return shortener.ErrLinkNotFound
+	}
+
+	return nil
 }
 
 func (d *dao) Delete(ctx context.Context, slug string) error {
@@ -71,6 +151,21 @@ func (d *dao) Delete(ctx context.Context, slug string) error {
 	return err
 }
 
-func (d *dao) List(ctx context.Context, skip, limit int) ([]shortener.Link, error) {
+// FindByURL always misses, since links are only cached by slug
+func (d *dao) FindByURL(ctx context.Context, owner, url string) (*shortener.Link, error) {
+	return nil, shortener.ErrCacheMiss
+}
+
+// ExistingSlugs always misses, since the cache only holds some of the links
+func (d *dao) ExistingSlugs(ctx context.Context, slugs []string) ([]string, error) {
+	return nil, shortener.ErrCacheMiss
+}
+
+// IncrementClicks always misses, since click counters are only kept in the db
+func (d *dao) IncrementClicks(ctx context.Context, slug string) (int, error) {
+	return 0, shortener.ErrCacheMiss
+}
+
+func (d *dao) List(ctx context.Context, opts shortener.ListOptions) ([]shortener.Link, error) {
 	panic("not yet implemented")
 }
@@ -119,14 +119,24 @@ func TestFind(t *testing.T) {
 			Error: nil,
 		},
 		{
-			Name:  "NotFoundSlug",
+			Name:  "NotCachedSlug",
 			Slug:  "niull",
 			Want:  nil,
+			Error: shortener.ErrCacheMiss,
+		},
+		{
+			Name:  "CachedAsNotFoundSlug",
+			Slug:  "n0tFd",
+			Want:  nil,
 			Error: shortener.ErrLinkNotFound,
 		},
 	}
 
 	dao := NewLinkDao(conn)
+	err = dao.MarkNotFound(context.Background(), "n0tFd")
+	if err != nil {
+		t.Fatalf("error caching slug as not found: %v", err)
+	}
 
 	for _, test := range tt {
 		t.Run(test.Name, func(t *testing.T) {
@@ -143,6 +153,80 @@ func TestFind(t *testing.T) {
 	}
 }
 
+func TestFindByURL(t *testing.T) {
+	conn := GetConnection()
+	err := truncateDB(conn)
+	if err != nil {
+		t.Fatalf("error truncating test database: %v", err)
+	}
+
+	err = seedDB(conn)
+	if err != nil {
+		t.Fatalf("error seeding database: %v", err)
+	}
+
+	dao := NewLinkDao(conn)
+	got, err := dao.FindByURL(context.Background(), "", "https://www.google.com")
+	if !errors.Is(err, shortener.ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss finding by url, but got: %v", err)
+	}
+
+	if got != nil {
+		t.Errorf("Expected no link finding by url, but got: %v", got)
+	}
+}
+
+func TestIncrementClicks(t *testing.T) {
+	conn := GetConnection()
+	err := truncateDB(conn)
+	if err != nil {
+		t.Fatalf("error truncating test database: %v", err)
+	}
+
+	err = seedDB(conn)
+	if err != nil {
+		t.Fatalf("error seeding database: %v", err)
+	}
+
+	dao := NewLinkDao(conn)
+	_, err = dao.IncrementClicks(context.Background(), "a1CDz")
+	if !errors.Is(err, shortener.ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss incrementing clicks, but got: %v", err)
+	}
+}
+
+func TestMarkNotFound(t *testing.T) {
+	conn := GetConnection()
+	ctx := context.Background()
+	dao := NewLinkDao(conn)
+
+	err := dao.MarkNotFound(ctx, "m4rkd")
+	if err != nil {
+		t.Fatalf("failed to cache slug as not found: %v", err)
+	}
+
+	currTTL, err := conn.TTL(ctx, formatCacheString("m4rkd")).Result()
+	if err != nil {
+		t.Fatalf("failed to query for marked key ttl: %v", err)
+	}
+
+	expectedDur := time.Duration(configger.Get().Cache.NotFoundTTLSeconds) * time.Second
+	if currTTL <= 0 || currTTL > expectedDur {
+		t.Errorf("ttl is set to the wrong value, (want, got): (%v, %v)", expectedDur, currTTL)
+	}
+
+	// inserting the slug overwrites the mark
+	_, err = dao.Insert(ctx, &shortener.Link{Slug: "m4rkd", URL: "https://www.google.com"})
+	if err != nil {
+		t.Fatalf("failed to insert marked link: %v", err)
+	}
+
+	_, err = dao.Find(ctx, "m4rkd")
+	if err != nil {
+		t.Errorf("expected inserted link to be found, but got: %v", err)
+	}
+}
+
 func TestInsert(t *testing.T) {
 	conn := GetConnection()
 
@@ -227,3 +311,156 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdate(t *testing.T) {
+	conn := GetConnection()
+	err := truncateDB(conn)
+	if err != nil {
+		t.Fatalf("error truncating test database: %v", err)
+	}
+
+	err = seedDB(conn)
+	if err != nil {
+		t.Fatalf("error seeding database: %v", err)
+	}
+
+	dao := NewLinkDao(conn)
+
+	tt := []struct {
+		Name  string
+		Link  *shortener.Link
+		Error error
+	}{
+		{
+			Name: "UpdateCached",
+			Link: &shortener.Link{
+				Slug:      "a1CDz",
+				URL:       "https://wwww.duckduckgo.com",
+				CreatedAt: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC),
+			},
+			Error: nil,
+		},
+		{
+			Name: "UpdateNotCached",
+			Link: &shortener.Link{
+				Slug:      "h3ll0",
+				URL:       "https://wwww.duckduckgo.com",
+				CreatedAt: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC),
+			},
+			Error: shortener.ErrLinkNotFound,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx := context.Background()
+
+			err := dao.Update(ctx, test.Link)
+			if !errors.Is(err, test.Error) {
+				t.Fatalf("failed to update link: %v", err)
+			}
+
+			got, err := dao.Find(ctx, test.Link.Slug)
+			if test.Error != nil {
+				if !errors.Is(err, shortener.ErrLinkNotFound) {
+					t.Errorf("link should not have been cached, but got err: %v", err)
+				}
+				return
+			}
+
+			if diff := cmp.Diff(test.Link, got); diff != "" {
+				t.Errorf("failed to update link correctly (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestInsertExpiringLink(t *testing.T) {
+	conn := GetConnection()
+	ctx := context.Background()
+	dao := NewLinkDao(conn)
+
+	t.Run("ExpiresBeforeCacheTTL", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Minute)
+		inserted := &shortener.Link{
+			Slug:      "3xp1r",
+			URL:       "https://wwww.duckduckgo.com",
+			CreatedAt: time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC),
+			ExpiresAt: &expiresAt,
+		}
+
+		_, err := dao.Insert(ctx, inserted)
+		if err != nil {
+			t.Fatalf("failed to insert new link: %v", err)
+		}
+
+		currTTL, err := conn.TTL(ctx, formatCacheString(inserted.Slug)).Result()
+		if err != nil {
+			t.Fatalf("failed to query for inserted key ttl: %v", err)
+		}
+
+		if currTTL <= 0 || currTTL > time.Minute {
+			t.Errorf("ttl should be at most the link remaining lifetime, but got: %v", currTTL)
+		}
+	})
+
+	t.Run("AlreadyExpired", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Minute)
+		inserted := &shortener.Link{
+			Slug:      "3xp1d",
+			URL:       "https://wwww.duckduckgo.com",
+			CreatedAt: time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC),
+			ExpiresAt: &expiresAt,
+		}
+
+		_, err := dao.Insert(ctx, inserted)
+		if err != nil {
+			t.Fatalf("failed to insert new link: %v", err)
+		}
+
+		_, err = conn.Get(ctx, formatCacheString(inserted.Slug)).Result()
+		if !errors.Is(err, redis.Nil) {
+			t.Errorf("expired link should not have been cached, but got err: %v", err)
+		}
+	})
+}
+
+func TestInsertMany(t *testing.T) {
+	conn := GetConnection()
+	ctx := context.Background()
+	dao := NewLinkDao(conn)
+
+	expiredAt := time.Now().Add(-time.Minute)
+	links := []shortener.Link{
+		{
+			Slug:      "bu1k1",
+			URL:       "https://wwww.duckduckgo.com",
+			CreatedAt: time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Slug:      "bu1k2",
+			URL:       "https://wwww.duckduckgo.com",
+			CreatedAt: time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC),
+			ExpiresAt: &expiredAt,
+		},
+	}
+
+	err := dao.InsertMany(ctx, links)
+	if err != nil {
+		t.Fatalf("failed to insert new links: %v", err)
+	}
+
+	got, err := dao.Find(ctx, links[0].Slug)
+	if err != nil {
+		t.Fatalf("failed to find inserted link: %v", err)
+	}
+
+	if diff := cmp.Diff(&links[0], got); diff != "" {
+		t.Errorf("inserted link is different from expected (-want +got):\n%s", diff)
+	}
+
+	_, err = dao.Find(ctx, links[1].Slug)
+	if !errors.Is(err, shortener.ErrLinkNotFound) {
+		t.Errorf("expired links should not be cached, but got: %v", err)
+	}
+}
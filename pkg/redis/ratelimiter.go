@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/joao-fontenele/go-url-shortener/pkg/configger"
+	"github.com/joao-fontenele/go-url-shortener/pkg/ratelimit"
+)
+
+// slidingWindowScript atomically checks and counts a request, see
+// ratelimit.Evaluate. KEYS are the current and previous window counters, ARGV
+// the limit, the window size and how much of it has elapsed, in milliseconds
+var slidingWindowScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local previous = tonumber(redis.call('GET', KEYS[2]) or '0')
+local window = tonumber(ARGV[2])
+local count = previous * (window - tonumber(ARGV[3])) / window + current
+
+if count + 1 <= tonumber(ARGV[1]) then
+	redis.call('INCR', KEYS[1])
+	redis.call('PEXPIRE', KEYS[1], window * 2)
+	return {1, previous, current}
+end
+
+return {0, previous, current}
+`)
+
+type rateLimiter struct {
+	conn *redis.Client
+}
+
+// NewRateLimiter instantiates a Limiter that counts requests in redis, so
+// limits are shared by all app instances
+func NewRateLimiter(conn *redis.Client) ratelimit.Limiter {
+	return &rateLimiter{
+		conn: conn,
+	}
+}
+
+func formatRateLimitKey(key string, windowStart time.Time) string {
+	prefix := configger.Get().Cache.CachePrefix
+	return fmt.Sprintf("%s^rl^%s^%d", prefix, key, windowStart.UnixNano()/int64(time.Millisecond))
+}
+
+func (d *rateLimiter) Allow(ctx context.Context, key string, l ratelimit.Limit) (*ratelimit.Result, error) {
+	now := time.Now()
+	start := now.Truncate(l.Window)
+	elapsed := now.Sub(start)
+
+	res, err := slidingWindowScript.Run(
+		ctx,
+		d.conn,
+		[]string{formatRateLimitKey(key, start), formatRateLimitKey(key, start.Add(-l.Window))},
+		l.Requests,
+		l.Window.Milliseconds(),
+		elapsed.Milliseconds(),
+	).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 3 {
+		return nil, fmt.Errorf("unexpected rate limit script result: %v", res)
+	}
+
+	counts := make([]int64, len(values))
+	for i, v := range values {
+		counts[i], ok = v.(int64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected rate limit script result: %v", res)
+		}
+	}
+
+	r := ratelimit.Evaluate(l, int(counts[1]), int(counts[2]), elapsed)
+	// the script has the final word, since it's what actually counted the request
+	r.Allowed = counts[0] == 1
+	if !r.Allowed {
+		r.Remaining = 0
+	}
+	return r, nil
+}
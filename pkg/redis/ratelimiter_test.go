@@ -0,0 +1,54 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/ratelimit"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	conn := GetConnection()
+	err := truncateDB(conn)
+	if err != nil {
+		t.Fatalf("error truncating test database: %v", err)
+	}
+
+	limiter := NewRateLimiter(conn)
+	l := ratelimit.Limit{Requests: 3, Window: time.Hour}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		r, err := limiter.Allow(ctx, "test^client", l)
+		if err != nil {
+			t.Fatalf("failed to rate limit request: %v", err)
+		}
+
+		if !r.Allowed {
+			t.Fatalf("expected request %d to be allowed", i)
+		}
+
+		if r.Remaining != 2-i {
+			t.Errorf("wrong remaining requests (want, got): (%d, %d)", 2-i, r.Remaining)
+		}
+	}
+
+	r, err := limiter.Allow(ctx, "test^client", l)
+	if err != nil {
+		t.Fatalf("failed to rate limit request: %v", err)
+	}
+
+	if r.Allowed || r.RetryAfter <= 0 {
+		t.Errorf("expected request over the limit to be denied with a retry after, but got: %+v", r)
+	}
+
+	r, err = limiter.Allow(ctx, "test^other", l)
+	if err != nil {
+		t.Fatalf("failed to rate limit request: %v", err)
+	}
+
+	if !r.Allowed {
+		t.Error("expected requests of other keys to be counted separately")
+	}
+}
@@ -0,0 +1,90 @@
+package shortener
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// apiKeySize is the amount of random bytes in an api key
+const apiKeySize = 32
+
+// maxOwnerSize is the size limit of owner IDs, as stored in the db
+const maxOwnerSize = 64
+
+// APIKey holds the attributes of an api key, but not the key itself
+type APIKey struct {
+	Owner     string    `json:"owner"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// APIKeyDao represents a contract to access api keys, identified by their hash
+type APIKeyDao interface {
+	Find(ctx context.Context, hash string) (*APIKey, error)
+	Insert(ctx context.Context, hash string, k *APIKey) (*APIKey, error)
+}
+
+// AuthService authenticates callers by their api keys
+type AuthService interface {
+	Authenticate(ctx context.Context, key string) (*APIKey, error)
+	CreateKey(ctx context.Context, owner, name string) (string, *APIKey, error)
+}
+
+type authService struct {
+	dao APIKeyDao
+}
+
+// NewAuthService instantiates an AuthService, given where api keys are stored
+func NewAuthService(dao APIKeyDao) AuthService {
+	return &authService{
+		dao: dao,
+	}
+}
+
+// hashAPIKey returns the hex encoded sha256 of a key. Keys are long random
+// strings, so a fast hash is enough, and allows finding keys by their hash
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *authService) Authenticate(ctx context.Context, key string) (*APIKey, error) {
+	if key == "" {
+		return nil, ErrUnauthorized
+	}
+
+	k, err := s.dao.Find(ctx, hashAPIKey(key))
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return nil, ErrUnauthorized
+	}
+
+	return k, err
+}
+
+// CreateKey generates a new api key for owner. The key itself is only
+// returned here, since only its hash is stored
+func (s *authService) CreateKey(ctx context.Context, owner, name string) (string, *APIKey, error) {
+	if owner == "" || len(owner) > maxOwnerSize {
+		return "", nil, fmt.Errorf("%w: owner must have between 1 and %d characters", ErrInvalidAPIKey, maxOwnerSize)
+	}
+
+	b := make([]byte, apiKeySize)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", nil, err
+	}
+	key := base64.RawURLEncoding.EncodeToString(b)
+
+	k, err := s.dao.Insert(ctx, hashAPIKey(key), &APIKey{Owner: owner, Name: name})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return key, k, nil
+}
@@ -0,0 +1,80 @@
+package shortener_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/mocks"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+func TestCreateKeyAndAuthenticate(t *testing.T) {
+	stored := map[string]shortener.APIKey{}
+	dao := &mocks.FakeAPIKeyDao{
+		FindFn: func(ctx context.Context, hash string) (*shortener.APIKey, error) {
+			k, ok := stored[hash]
+			if !ok {
+				return nil, shortener.ErrAPIKeyNotFound
+			}
+			return &k, nil
+		},
+		InsertFn: func(ctx context.Context, hash string, k *shortener.APIKey) (*shortener.APIKey, error) {
+			stored[hash] = *k
+			return k, nil
+		},
+	}
+	s := shortener.NewAuthService(dao)
+
+	key, created, err := s.CreateKey(context.Background(), "alice", "ci")
+	if err != nil {
+		t.Fatalf("Unexpected error creating key: %v", err)
+	}
+
+	if created.Owner != "alice" || created.Name != "ci" {
+		t.Errorf("Wrong created key attributes: %+v", created)
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	if _, ok := stored[hex.EncodeToString(sum[:])]; !ok || len(stored) != 1 {
+		t.Errorf("Expected only the key hash to be stored, but got: %v", stored)
+	}
+
+	k, err := s.Authenticate(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Unexpected error authenticating: %v", err)
+	}
+
+	if k.Owner != "alice" {
+		t.Errorf("Wrong authenticated owner (want, got): (alice, %s)", k.Owner)
+	}
+
+	for _, invalid := range []string{"", "wrong", hex.EncodeToString(sum[:])} {
+		_, err = s.Authenticate(context.Background(), invalid)
+		if !errors.Is(err, shortener.ErrUnauthorized) {
+			t.Errorf("Expected ErrUnauthorized for key %q, but got: %v", invalid, err)
+		}
+	}
+
+	_, _, err = s.CreateKey(context.Background(), "", "ci")
+	if !errors.Is(err, shortener.ErrInvalidAPIKey) {
+		t.Errorf("Expected ErrInvalidAPIKey for empty owner, but got: %v", err)
+	}
+}
+
+func TestAuthenticateDaoError(t *testing.T) {
+	unexpectedErr := errors.New("UnexpectedError")
+	dao := &mocks.FakeAPIKeyDao{
+		FindFn: func(ctx context.Context, hash string) (*shortener.APIKey, error) {
+			return nil, unexpectedErr
+		},
+	}
+	s := shortener.NewAuthService(dao)
+
+	_, err := s.Authenticate(context.Background(), "key")
+	if !errors.Is(err, unexpectedErr) {
+		t.Errorf("Expected dao error, but got: %v", err)
+	}
+}
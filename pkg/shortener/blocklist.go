@@ -0,0 +1,136 @@
+package shortener
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// FileBlocklist is a DomainList read from a file, with a domain per line. Blank
+// lines and lines starting with # are ignored. The file is checked for changes
+// periodically and reloaded, so domains can be blocked without a restart
+type FileBlocklist struct {
+	path string
+
+	mu      sync.RWMutex
+	domains domainSet
+	modTime time.Time
+	size    int64
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+var _ DomainList = &FileBlocklist{}
+
+// NewFileBlocklist loads the blocklist at path, and starts checking it for
+// changes every reloadInterval. A reloadInterval of 0 disables reloading
+func NewFileBlocklist(path string, reloadInterval time.Duration) (*FileBlocklist, error) {
+	b := &FileBlocklist{
+		path:    path,
+		domains: make(domainSet),
+		done:    make(chan struct{}),
+	}
+
+	_, err := b.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	if reloadInterval > 0 {
+		go b.watch(reloadInterval)
+	}
+
+	return b, nil
+}
+
+// Contains tells if host, or one of its parent domains, is in the blocklist
+func (b *FileBlocklist) Contains(host string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.domains.Contains(host)
+}
+
+// Close stops checking the file for changes
+func (b *FileBlocklist) Close() {
+	b.closeOnce.Do(func() {
+		close(b.done)
+	})
+}
+
+func (b *FileBlocklist) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			reloaded, err := b.reload()
+			if err != nil {
+				// keep the previous domains, a broken file shouldn't unblock everything
+				logger.Get().Error("Failed to reload url blocklist", zap.String("path", b.path), zap.Error(err))
+				continue
+			}
+
+			if reloaded {
+				logger.Get().Info("Reloaded url blocklist", zap.String("path", b.path), zap.Int("domains", b.len()))
+			}
+		}
+	}
+}
+
+func (b *FileBlocklist) len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.domains)
+}
+
+// reload reads the file again if it changed since it was last read, and tells
+// if it did
+func (b *FileBlocklist) reload() (bool, error) {
+	info, err := os.Stat(b.path)
+	if err != nil {
+		return false, err
+	}
+
+	b.mu.RLock()
+	unchanged := info.ModTime().Equal(b.modTime) && info.Size() == b.size
+	b.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	f, err := os.Open(b.path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	domains := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains = append(domains, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+
+	b.mu.Lock()
+	b.domains = newDomainSet(domains)
+	b.modTime = info.ModTime()
+	b.size = info.Size()
+	b.mu.Unlock()
+
+	return true, nil
+}
@@ -0,0 +1,69 @@
+package shortener_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+func TestFileBlocklist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blocklist")
+	if err != nil {
+		t.Fatalf("Unexpected error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "blocklist.txt")
+	err = ioutil.WriteFile(path, []byte("# phishing\nevil.com\n\n  *.tk  \n"), 0644)
+	if err != nil {
+		t.Fatalf("Unexpected error writing blocklist: %v", err)
+	}
+
+	b, err := shortener.NewFileBlocklist(path, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Unexpected error loading blocklist: %v", err)
+	}
+	defer b.Close()
+
+	for _, host := range []string{"evil.com", "www.evil.com", "free.tk"} {
+		if !b.Contains(host) {
+			t.Errorf("Expected %s to be blocked", host)
+		}
+	}
+
+	if b.Contains("# phishing") || b.Contains("google.com") {
+		t.Error("Expected comments and other domains to not be blocked")
+	}
+
+	err = ioutil.WriteFile(path, []byte("malware.net\n"), 0644)
+	if err != nil {
+		t.Fatalf("Unexpected error writing blocklist: %v", err)
+	}
+	// make sure the change is noticed on file systems with coarse mod times
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !b.Contains("malware.net") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !b.Contains("malware.net") {
+		t.Error("Expected blocklist to be reloaded")
+	}
+
+	if b.Contains("evil.com") {
+		t.Error("Expected domains removed from the file to not be blocked anymore")
+	}
+
+	t.Run("MissingFile", func(t *testing.T) {
+		_, err := shortener.NewFileBlocklist(filepath.Join(dir, "missing.txt"), 0)
+		if err == nil {
+			t.Error("Expected error loading missing blocklist")
+		}
+	})
+}
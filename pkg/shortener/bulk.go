@@ -0,0 +1,270 @@
+package shortener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// exportPageSize is the amount of links fetched at once while exporting
+const exportPageSize = 1000
+
+// ImportResult is the outcome of importing a single link. Err is nil when the
+// link is valid, in which case Link holds the link as it was (or would be) saved
+type ImportResult struct {
+	Link *Link
+	Err  error
+}
+
+// Import validates every link, and inserts the valid ones in a single batch.
+// Results are in the same order as links. Invalid links don't prevent the valid
+// ones from being imported, but a failure inserting the batch is returned as
+// an error, and nothing is imported. With dryRun links are only validated.
+// Slugs are checked against the db in batches, instead of once per link, and
+// slugs taken meanwhile by other writers fail the insert with ErrLinkExists
+func (ls *linkService) Import(ctx context.Context, links []Link, dryRun bool) ([]ImportResult, error) {
+	results := make([]ImportResult, len(links))
+	prepared := make([]*Link, len(links))
+	seen := make(map[string]bool)
+	checked := make(map[string]error)
+	custom := []string{}
+	generated := 0
+
+	now := time.Now()
+	for i := range links {
+		link, err := ls.prepareImport(&links[i], now, seen, checked)
+		if err != nil && !isImportRowErr(err) {
+			return nil, err
+		}
+
+		results[i].Err = err
+		if err != nil {
+			continue
+		}
+
+		link.Owner = OwnerFromContext(ctx)
+		prepared[i] = link
+		if link.Slug == "" {
+			generated++
+		} else {
+			seen[link.Slug] = true
+			custom = append(custom, link.Slug)
+		}
+	}
+
+	taken, err := ls.existingSlugs(ctx, custom)
+	if err != nil {
+		return nil, err
+	}
+
+	slugs, err := ls.generateSlugs(ctx, generated, seen)
+	if err != nil {
+		return nil, err
+	}
+
+	valid := []Link{}
+	validIdx := []int{}
+	for i, link := range prepared {
+		if link == nil {
+			continue
+		}
+
+		if link.Slug == "" {
+			link.Slug, slugs = slugs[0], slugs[1:]
+		} else if taken[link.Slug] {
+			results[i].Err = fmt.Errorf("%w: slug '%s' is already in use", ErrLinkExists, link.Slug)
+			continue
+		}
+
+		valid = append(valid, *link)
+		validIdx = append(validIdx, i)
+	}
+
+	if !dryRun && len(valid) > 0 {
+		err := ls.repo.InsertMany(ctx, valid)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, idx := range validIdx {
+		link := valid[i]
+		results[idx].Link = &link
+	}
+
+	return results, nil
+}
+
+// isImportRowErr tells if an error only invalidates a single imported link,
+// instead of the whole import
+func isImportRowErr(err error) bool {
+	return errors.Is(err, ErrInvalidLink) ||
+		errors.Is(err, ErrInvalidSlug) ||
+		errors.Is(err, ErrURLBlocked) ||
+		errors.Is(err, ErrLinkExists)
+}
+
+// prepareImport validates a link to be imported, without checking if its slug
+// is in use. seen holds the slugs used by previous links of the same import,
+// and checked the url policy results of the previous links
+func (ls *linkService) prepareImport(l *Link, now time.Time, seen map[string]bool, checked map[string]error) (*Link, error) {
+	canonicalURL, err := ls.prepareImportURL(l.URL, checked)
+	if err != nil {
+		return nil, err
+	}
+
+	link := &Link{
+		URL:          canonicalURL,
+		Slug:         l.Slug,
+		ExpiresAt:    l.ExpiresAt,
+		MaxClicks:    l.MaxClicks,
+		Tags:         l.Tags,
+		RedirectType: l.RedirectType,
+	}
+
+	err = link.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if link.Expired(now) {
+		return nil, fmt.Errorf("%w: Link expiration date must be in the future", ErrInvalidLink)
+	}
+
+	if link.Slug == "" {
+		return link, nil
+	}
+
+	err = ls.validateCustomSlug(link.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	if seen[link.Slug] {
+		return nil, fmt.Errorf("%w: slug '%s' is repeated in the import", ErrLinkExists, link.Slug)
+	}
+
+	return link, nil
+}
+
+// prepareImportURL is like prepareURL, but urls with the same scheme and host
+// are checked against the url policy only once per import, since policies may
+// resolve hosts. checked holds the results by scheme and host
+func (ls *linkService) prepareImportURL(rawURL string, checked map[string]error) (string, error) {
+	canonical, err := CanonicalizeURL(rawURL, ls.canonical)
+	if err != nil {
+		return "", err
+	}
+
+	if ls.urlPolicy == nil {
+		return canonical, nil
+	}
+
+	u, err := url.Parse(canonical)
+	if err != nil {
+		return "", fmt.Errorf("%w: Parsing Link.URL generated error", ErrInvalidLink)
+	}
+
+	key := u.Scheme + "://" + u.Host
+	err, ok := checked[key]
+	if !ok {
+		err = ls.urlPolicy.Check(canonical)
+		checked[key] = err
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return canonical, nil
+}
+
+// existingSlugs tells which of slugs are in use, with a single query
+func (ls *linkService) existingSlugs(ctx context.Context, slugs []string) (map[string]bool, error) {
+	taken := make(map[string]bool)
+	if len(slugs) == 0 {
+		return taken, nil
+	}
+
+	existing, err := ls.repo.ExistingSlugs(ctx, slugs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, slug := range existing {
+		taken[slug] = true
+	}
+	return taken, nil
+}
+
+// generateSlugs generates count slugs that aren't in use, nor in seen, like
+// newGeneratedSlug, but checking all the candidates of each attempt at once.
+// The generated slugs are added to seen
+func (ls *linkService) generateSlugs(ctx context.Context, count int, seen map[string]bool) ([]string, error) {
+	slugs := []string{}
+	attempt := 0
+	for len(slugs) < count {
+		attempt++
+		size := ls.slugSizer.current()
+
+		candidates := []string{}
+		for len(slugs)+len(candidates) < count {
+			slug, err := ls.slugGenerator.Generate(ctx, size)
+			if err != nil {
+				return nil, err
+			}
+
+			if !seen[slug] {
+				seen[slug] = true
+				candidates = append(candidates, slug)
+			}
+		}
+
+		taken, err := ls.existingSlugs(ctx, candidates)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, slug := range candidates {
+			ls.slugSizer.observe(size, taken[slug])
+			if !taken[slug] {
+				slugs = append(slugs, slug)
+			}
+		}
+
+		if len(taken) > 0 && attempt > maxNewSlugAttempts {
+			if size >= maxSlugSize {
+				return nil, ErrLinkExists
+			}
+			ls.slugSizer.grow(size)
+			attempt = 0
+		}
+	}
+
+	return slugs, nil
+}
+
+// Export calls fn for every link of the caller, in a stable order, stopping on
+// the first error
+func (ls *linkService) Export(ctx context.Context, fn func(l Link) error) error {
+	opts := ListOptions{Owner: OwnerFromContext(ctx), Sort: SortCreatedAt, Limit: exportPageSize}
+	for {
+		links, err := ls.repo.List(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		for _, l := range links {
+			err = fn(l)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(links) < exportPageSize {
+			return nil
+		}
+		opts.After = cursorAfter(links[len(links)-1], opts.Sort)
+	}
+}
@@ -0,0 +1,218 @@
+package shortener_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/mocks"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+func TestImport(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	links := []shortener.Link{
+		{URL: "https://www.google.com", Slug: "custom"},
+		{URL: "https://www.google.com"},
+		{URL: "not a link"},
+		{URL: "https://www.google.com", Slug: "taken"},
+		{URL: "https://www.google.com", Slug: "custom"},
+		{URL: "https://www.google.com", Slug: "links"},
+		{URL: "https://www.google.com", ExpiresAt: &past},
+	}
+	wantErrs := []error{
+		nil,
+		nil,
+		shortener.ErrInvalidLink,
+		shortener.ErrLinkExists,
+		shortener.ErrLinkExists,
+		shortener.ErrInvalidSlug,
+		shortener.ErrInvalidLink,
+	}
+
+	newRepo := func() *mocks.FakeLinkRepo {
+		return &mocks.FakeLinkRepo{
+			ExistingSlugsFn: func(ctx context.Context, slugs []string) ([]string, error) {
+				existing := []string{}
+				for _, slug := range slugs {
+					if slug == "taken" {
+						existing = append(existing, slug)
+					}
+				}
+				return existing, nil
+			},
+			InsertManyFn: func(ctx context.Context, links []shortener.Link) error {
+				return nil
+			},
+		}
+	}
+
+	for _, dryRun := range []bool{false, true} {
+		t.Run(fmt.Sprintf("DryRun%t", dryRun), func(t *testing.T) {
+			repo := newRepo()
+			var inserted []shortener.Link
+			repo.InsertManyFn = func(ctx context.Context, links []shortener.Link) error {
+				inserted = links
+				return nil
+			}
+			s := shortener.NewLinkService(repo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+
+			results, err := s.Import(context.Background(), links, dryRun)
+			if err != nil {
+				t.Fatalf("Unexpected error importing links: %v", err)
+			}
+
+			if len(results) != len(links) {
+				t.Fatalf("Expected %d results, but got %d", len(links), len(results))
+			}
+
+			for i, r := range results {
+				if !errors.Is(r.Err, wantErrs[i]) || (wantErrs[i] == nil) != (r.Err == nil) {
+					t.Errorf("Wrong error for link %d (want, got): (%v, %v)", i, wantErrs[i], r.Err)
+				}
+
+				if (r.Err == nil) != (r.Link != nil) {
+					t.Errorf("Expected link %d to be returned only when valid, got: %v", i, r.Link)
+				}
+			}
+
+			if results[1].Link == nil || results[1].Link.Slug == "" {
+				t.Errorf("Expected a slug to be generated for link without one, but got: %v", results[1].Link)
+			}
+
+			if repo.FindCalled {
+				t.Error("Expected slugs to be checked in batches, instead of found one by one")
+			}
+
+			if dryRun {
+				if repo.InsertManyCalled {
+					t.Error("Expected dry run to not insert links")
+				}
+				return
+			}
+
+			if len(inserted) != 2 {
+				t.Errorf("Expected only the 2 valid links to be inserted, but got: %v", inserted)
+			}
+		})
+	}
+
+	t.Run("InsertError", func(t *testing.T) {
+		repo := newRepo()
+		repo.InsertManyFn = func(ctx context.Context, links []shortener.Link) error {
+			return shortener.ErrLinkExists
+		}
+		s := shortener.NewLinkService(repo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+
+		_, err := s.Import(context.Background(), links, false)
+		if !errors.Is(err, shortener.ErrLinkExists) {
+			t.Errorf("Expected ErrLinkExists, but got: %v", err)
+		}
+	})
+
+	t.Run("RepoError", func(t *testing.T) {
+		unexpectedErr := errors.New("UnexpectedError")
+		repo := newRepo()
+		repo.ExistingSlugsFn = func(ctx context.Context, slugs []string) ([]string, error) {
+			return nil, unexpectedErr
+		}
+		s := shortener.NewLinkService(repo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+
+		_, err := s.Import(context.Background(), links, false)
+		if !errors.Is(err, unexpectedErr) {
+			t.Errorf("Expected repository error, but got: %v", err)
+		}
+
+		if repo.InsertManyCalled {
+			t.Error("Expected no links to be inserted")
+		}
+	})
+}
+
+// countingPolicy allows every url, counting the checks
+type countingPolicy struct {
+	checks int
+}
+
+func (p *countingPolicy) Check(rawURL string) error {
+	p.checks++
+	return nil
+}
+
+func TestImportChecksEachHostOnce(t *testing.T) {
+	links := []shortener.Link{
+		{URL: "https://www.google.com/search"},
+		{URL: "https://www.google.com/maps"},
+		{URL: "http://www.google.com"},
+		{URL: "https://duckduckgo.com"},
+	}
+	repo := &mocks.FakeLinkRepo{
+		ExistingSlugsFn: func(ctx context.Context, slugs []string) ([]string, error) {
+			return []string{}, nil
+		},
+	}
+	policy := &countingPolicy{}
+	s := shortener.NewLinkService(repo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{URLPolicy: policy})
+
+	if _, err := s.Import(context.Background(), links, true); err != nil {
+		t.Fatalf("Unexpected error importing links: %v", err)
+	}
+
+	if policy.checks != 3 {
+		t.Errorf("Expected a policy check per scheme and host, 3, but got %d", policy.checks)
+	}
+}
+
+func TestExport(t *testing.T) {
+	const total = 2500
+	var pages []string
+	repo := &mocks.FakeLinkRepo{
+		ListFn: func(ctx context.Context, opts shortener.ListOptions) ([]shortener.Link, error) {
+			start := 0
+			after := ""
+			if opts.After != nil {
+				after = opts.After.Slug
+				fmt.Sscanf(after, "s%d", &start)
+				start++
+			}
+			pages = append(pages, after)
+
+			links := []shortener.Link{}
+			for i := start; i < total && i < start+opts.Limit; i++ {
+				links = append(links, shortener.Link{Slug: fmt.Sprintf("s%d", i)})
+			}
+			return links, nil
+		},
+	}
+	s := shortener.NewLinkService(repo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+
+	exported := 0
+	err := s.Export(context.Background(), func(l shortener.Link) error {
+		if l.Slug != fmt.Sprintf("s%d", exported) {
+			t.Errorf("Wrong exported link (want, got): (s%d, %s)", exported, l.Slug)
+		}
+		exported++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error exporting links: %v", err)
+	}
+
+	if exported != total {
+		t.Errorf("Wrong amount of exported links (want, got): (%d, %d)", total, exported)
+	}
+
+	if len(pages) != 3 {
+		t.Errorf("Expected links to be listed in 3 pages, but got: %v", pages)
+	}
+
+	writeErr := errors.New("WriteError")
+	err = s.Export(context.Background(), func(l shortener.Link) error {
+		return writeErr
+	})
+	if !errors.Is(err, writeErr) {
+		t.Errorf("Expected export to stop with callback error, but got: %v", err)
+	}
+}
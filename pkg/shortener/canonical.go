@@ -0,0 +1,165 @@
+package shortener
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// defaultTrackingParams are removed from urls when tracking params are stripped
+// and no other params are configured. A trailing * matches any suffix
+var defaultTrackingParams = []string{
+	"utm_*",
+	"fbclid",
+	"gclid",
+	"dclid",
+	"msclkid",
+	"mc_cid",
+	"mc_eid",
+	"yclid",
+	"_hsenc",
+	"_hsmi",
+}
+
+// defaultPorts are stripped from urls, since they're implied by the scheme
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// CanonicalOptions configures how destination urls are canonicalized
+type CanonicalOptions struct {
+	// StripTrackingParams removes TrackingParams from the query
+	StripTrackingParams bool
+	// TrackingParams defaults to common analytics params, like utm_*
+	TrackingParams []string
+}
+
+// asciiHost converts an internationalized host to punycode, e.g. bücher.de
+// becomes xn--bcher-kva.de. IPs and ascii hosts are kept as is, since lookup
+// rules would reject hosts that resolve, like ones with underscores
+func asciiHost(host string) (string, error) {
+	if net.ParseIP(host) != nil || isASCII(host) {
+		return host, nil
+	}
+	return idna.Lookup.ToASCII(host)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// CanonicalizeURL rewrites a url so equivalent urls are written the same way:
+// scheme and host are lowercased, internationalized hosts are converted to
+// punycode, default ports are removed and query params are sorted by name. The
+// path, fragment and escaping of params are kept as is
+func CanonicalizeURL(rawURL string, opts CanonicalOptions) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: Parsing Link.URL generated error", ErrInvalidLink)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	// urls like mailto: or javascript: have no host to canonicalize
+	if u.Opaque != "" || u.Host == "" {
+		return u.String(), nil
+	}
+
+	host, err := asciiHost(strings.TrimSuffix(strings.ToLower(u.Hostname()), "."))
+	if err != nil {
+		return "", fmt.Errorf("%w: Link URL host is not valid", ErrInvalidLink)
+	}
+
+	port := u.Port()
+	if port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+
+	var tracking []string
+	if opts.StripTrackingParams {
+		tracking = opts.TrackingParams
+		if len(tracking) == 0 {
+			tracking = defaultTrackingParams
+		}
+	}
+	u.RawQuery = canonicalQuery(u.RawQuery, tracking)
+	u.ForceQuery = false
+
+	return u.String(), nil
+}
+
+// canonicalQuery sorts query params by name, keeping the order of repeated
+// params, and removes empty and tracking params
+func canonicalQuery(rawQuery string, tracking []string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	type param struct {
+		name string
+		raw  string
+	}
+
+	params := []param{}
+	for _, raw := range strings.Split(rawQuery, "&") {
+		if raw == "" {
+			continue
+		}
+
+		name := raw
+		if i := strings.IndexByte(raw, '='); i >= 0 {
+			name = raw[:i]
+		}
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+
+		if isTrackingParam(name, tracking) {
+			continue
+		}
+		params = append(params, param{name: name, raw: raw})
+	}
+
+	sort.SliceStable(params, func(i, j int) bool {
+		return params[i].name < params[j].name
+	})
+
+	raws := make([]string, len(params))
+	for i, p := range params {
+		raws[i] = p.raw
+	}
+	return strings.Join(raws, "&")
+}
+
+func isTrackingParam(name string, tracking []string) bool {
+	name = strings.ToLower(name)
+	for _, t := range tracking {
+		t = strings.ToLower(t)
+		if strings.HasSuffix(t, "*") {
+			if strings.HasPrefix(name, strings.TrimSuffix(t, "*")) {
+				return true
+			}
+		} else if name == t {
+			return true
+		}
+	}
+	return false
+}
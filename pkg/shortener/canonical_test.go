@@ -0,0 +1,129 @@
+package shortener_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+func TestCanonicalizeURL(t *testing.T) {
+	tests := []struct {
+		Name    string
+		URL     string
+		Opts    shortener.CanonicalOptions
+		Want    string
+		WantErr error
+	}{
+		{
+			Name: "AlreadyCanonical",
+			URL:  "https://example.com/a?a=2&b=1",
+			Want: "https://example.com/a?a=2&b=1",
+		},
+		{
+			Name: "HostCaseDefaultPortAndParamsOrder",
+			URL:  "HTTPS://Example.COM:443/a?b=1&a=2",
+			Want: "https://example.com/a?a=2&b=1",
+		},
+		{
+			Name: "PathCaseIsKept",
+			URL:  "https://example.com/Path/To?Q=A",
+			Want: "https://example.com/Path/To?Q=A",
+		},
+		{
+			Name: "NonDefaultPortIsKept",
+			URL:  "http://example.com:8080/",
+			Want: "http://example.com:8080/",
+		},
+		{
+			Name: "HTTPDefaultPort",
+			URL:  "http://example.com:80/",
+			Want: "http://example.com/",
+		},
+		{
+			Name: "TrailingDot",
+			URL:  "https://example.com./",
+			Want: "https://example.com/",
+		},
+		{
+			Name: "IDN",
+			URL:  "https://Bücher.de/katalog",
+			Want: "https://xn--bcher-kva.de/katalog",
+		},
+		{
+			Name: "EscapedIDN",
+			URL:  "https://m%C3%BCnchen.example/",
+			Want: "https://xn--mnchen-3ya.example/",
+		},
+		{
+			Name: "FullwidthIDN",
+			URL:  "https://ｂücher.de/katalog",
+			Want: "https://xn--bcher-kva.de/katalog",
+		},
+		{
+			Name: "IPv6",
+			URL:  "http://[2001:DB8::1]:80/",
+			Want: "http://[2001:db8::1]/",
+		},
+		{
+			Name: "RepeatedParamsKeepOrder",
+			URL:  "https://example.com/?b=2&a=1&b=1&&",
+			Want: "https://example.com/?a=1&b=2&b=1",
+		},
+		{
+			Name: "ParamsEscapingIsKept",
+			URL:  "https://example.com/?q=a%20b&flag",
+			Want: "https://example.com/?flag&q=a%20b",
+		},
+		{
+			Name: "FragmentIsKept",
+			URL:  "https://example.com/#Section",
+			Want: "https://example.com/#Section",
+		},
+		{
+			Name: "TrackingParamsKeptByDefault",
+			URL:  "https://example.com/?utm_source=news&id=1",
+			Want: "https://example.com/?id=1&utm_source=news",
+		},
+		{
+			Name: "DefaultTrackingParams",
+			URL:  "https://example.com/?utm_source=news&UTM_Medium=email&fbclid=abc&id=1",
+			Opts: shortener.CanonicalOptions{StripTrackingParams: true},
+			Want: "https://example.com/?id=1",
+		},
+		{
+			Name: "CustomTrackingParams",
+			URL:  "https://example.com/?ref=home&utm_source=news&id=1",
+			Opts: shortener.CanonicalOptions{StripTrackingParams: true, TrackingParams: []string{"ref"}},
+			Want: "https://example.com/?id=1&utm_source=news",
+		},
+		{
+			Name: "OpaqueURL",
+			URL:  "MAILTO:someone@example.com",
+			Want: "mailto:someone@example.com",
+		},
+		{
+			Name:    "InvalidIDN",
+			URL:     "https://-bücher.de/",
+			WantErr: shortener.ErrInvalidLink,
+		},
+		{
+			Name:    "Unparseable",
+			URL:     "https://exa mple.com/%zz",
+			WantErr: shortener.ErrInvalidLink,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			got, err := shortener.CanonicalizeURL(tc.URL, tc.Opts)
+			if !errors.Is(err, tc.WantErr) || (tc.WantErr == nil) != (err == nil) {
+				t.Fatalf("Wrong error (want, got): (%v, %v)", tc.WantErr, err)
+			}
+
+			if got != tc.Want {
+				t.Errorf("Wrong canonical url (want, got): (%s, %s)", tc.Want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package shortener
+
+import (
+	"context"
+	"time"
+)
+
+// Click holds the attributes of a single visit to a shortened link
+type Click struct {
+	Slug       string    `json:"slug"`
+	Referrer   string    `json:"referrer"`
+	UserAgent  string    `json:"userAgent"`
+	RemoteAddr string    `json:"remoteAddr"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// DailyClicks holds the amount of clicks a link received in a single day
+type DailyClicks struct {
+	Day    time.Time `json:"day"`
+	Clicks int       `json:"clicks"`
+}
+
+// LinkStats holds aggregated click statistics of a link
+type LinkStats struct {
+	Slug        string        `json:"slug"`
+	TotalClicks int           `json:"totalClicks"`
+	Daily       []DailyClicks `json:"daily"`
+}
+
+// ClickDao represents a contract to access clicks in a single datastore
+type ClickDao interface {
+	InsertMany(ctx context.Context, clicks []Click) error
+	Stats(ctx context.Context, slug string) (*LinkStats, error)
+}
+
+// ClickRecorder represents a contract to store clicks without blocking the caller
+type ClickRecorder interface {
+	Record(c Click)
+}
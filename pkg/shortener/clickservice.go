@@ -0,0 +1,44 @@
+package shortener
+
+import (
+	"context"
+)
+
+// ClickService will hold the businesses logic to handle link visits analytics
+type ClickService interface {
+	Record(c Click)
+	Stats(ctx context.Context, slug string) (*LinkStats, error)
+}
+
+type clickService struct {
+	repo     LinkRepository
+	dao      ClickDao
+	recorder ClickRecorder
+}
+
+// NewClickService instantiates a ClickService, given a LinkRepository, a
+// ClickDao to query stats from, and a ClickRecorder to store new clicks
+func NewClickService(repo LinkRepository, dao ClickDao, recorder ClickRecorder) ClickService {
+	return &clickService{
+		repo:     repo,
+		dao:      dao,
+		recorder: recorder,
+	}
+}
+
+func (cs *clickService) Record(c Click) {
+	cs.recorder.Record(c)
+}
+
+func (cs *clickService) Stats(ctx context.Context, slug string) (*LinkStats, error) {
+	l, err := cs.repo.Find(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canAccess(ctx, l) {
+		return nil, ErrLinkNotFound
+	}
+
+	return cs.dao.Stats(ctx, slug)
+}
@@ -0,0 +1,96 @@
+package shortener_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/mocks"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+type fakeRecorder struct {
+	clicks []shortener.Click
+}
+
+func (r *fakeRecorder) Record(c shortener.Click) {
+	r.clicks = append(r.clicks, c)
+}
+
+func TestRecord(t *testing.T) {
+	recorder := &fakeRecorder{}
+	s := shortener.NewClickService(&mocks.FakeLinkRepo{}, &mocks.FakeClickDao{}, recorder)
+
+	s.Record(shortener.Click{Slug: "aaaaa"})
+
+	if len(recorder.clicks) != 1 || recorder.clicks[0].Slug != "aaaaa" {
+		t.Errorf("Expected click to have been recorded, but got: %v", recorder.clicks)
+	}
+}
+
+func TestStats(t *testing.T) {
+	t.Run("LinkNotFound", func(t *testing.T) {
+		repo := &mocks.FakeLinkRepo{
+			FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+				return nil, shortener.ErrLinkNotFound
+			},
+		}
+		dao := &mocks.FakeClickDao{}
+		s := shortener.NewClickService(repo, dao, &fakeRecorder{})
+
+		_, err := s.Stats(context.Background(), "aaaaa")
+
+		if !errors.Is(err, shortener.ErrLinkNotFound) {
+			t.Errorf("Expected ErrLinkNotFound, but got: %v", err)
+		}
+
+		if dao.StatsCalled {
+			t.Error("Expected dao Stats to not have been called")
+		}
+	})
+
+	t.Run("OtherOwnerLink", func(t *testing.T) {
+		repo := &mocks.FakeLinkRepo{
+			FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+				return &shortener.Link{Slug: slug, Owner: "alice"}, nil
+			},
+		}
+		dao := &mocks.FakeClickDao{}
+		s := shortener.NewClickService(repo, dao, &fakeRecorder{})
+
+		ctx := context.WithValue(context.Background(), shortener.OwnerKey, "bob")
+		_, err := s.Stats(ctx, "aaaaa")
+
+		if !errors.Is(err, shortener.ErrLinkNotFound) {
+			t.Errorf("Expected ErrLinkNotFound, but got: %v", err)
+		}
+
+		if dao.StatsCalled {
+			t.Error("Expected dao Stats to not have been called")
+		}
+	})
+
+	t.Run("LinkFound", func(t *testing.T) {
+		repo := &mocks.FakeLinkRepo{
+			FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+				return &shortener.Link{Slug: slug}, nil
+			},
+		}
+		dao := &mocks.FakeClickDao{
+			StatsFn: func(ctx context.Context, slug string) (*shortener.LinkStats, error) {
+				return &shortener.LinkStats{Slug: slug, TotalClicks: 2}, nil
+			},
+		}
+		s := shortener.NewClickService(repo, dao, &fakeRecorder{})
+
+		stats, err := s.Stats(context.Background(), "aaaaa")
+
+		if err != nil {
+			t.Fatalf("Unexpected error getting stats: %v", err)
+		}
+
+		if stats.Slug != "aaaaa" || stats.TotalClicks != 2 {
+			t.Errorf("Unexpected stats: %v", stats)
+		}
+	})
+}
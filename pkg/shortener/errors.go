@@ -8,6 +8,17 @@ var (
 	ErrLinkNotFound Error = Error("Link not found")
 	ErrLinkExists   Error = Error("Link's slug already exists")
 	ErrInvalidLink  Error = Error("Link is not valid")
+	ErrInvalidSlug  Error = Error("Slug is not valid")
+	ErrLinkExpired  Error = Error("Link has expired")
+	ErrURLBlocked   Error = Error("Link URL is not allowed")
+	ErrCacheMiss    Error = Error("Link is not cached")
+
+	ErrInvalidCursor      Error = Error("List cursor is not valid")
+	ErrInvalidListOptions Error = Error("List options are not valid")
+
+	ErrUnauthorized   Error = Error("API key is missing or not valid")
+	ErrAPIKeyNotFound Error = Error("API key not found")
+	ErrInvalidAPIKey  Error = Error("API key attributes are not valid")
 )
 
 func (e Error) Error() string {
@@ -0,0 +1,59 @@
+package shortener
+
+import "sync"
+
+// generation counts the invalidations of a slug while it's being looked up
+type generation struct {
+	lookups int
+	n       uint64
+}
+
+// generations tells if a slug was invalidated while it was looked up in the db,
+// so the stale result isn't left in the caches. Only slugs with lookups in
+// progress are tracked
+type generations struct {
+	mu    sync.Mutex
+	slugs map[string]*generation
+}
+
+// start tracks a lookup of slug, returning its current generation
+func (g *generations) start(slug string) uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.slugs == nil {
+		g.slugs = make(map[string]*generation)
+	}
+
+	gen, ok := g.slugs[slug]
+	if !ok {
+		gen = &generation{}
+		g.slugs[slug] = gen
+	}
+	gen.lookups++
+	return gen.n
+}
+
+// finish ends a lookup started at generation n, telling if slug was
+// invalidated since then
+func (g *generations) finish(slug string, n uint64) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	gen := g.slugs[slug]
+	gen.lookups--
+	if gen.lookups == 0 {
+		delete(g.slugs, slug)
+	}
+	return gen.n != n
+}
+
+// bump invalidates the lookups of slug in progress
+func (g *generations) bump(slug string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if gen, ok := g.slugs[slug]; ok {
+		gen.n++
+	}
+}
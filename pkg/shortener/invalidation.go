@@ -0,0 +1,74 @@
+package shortener
+
+import (
+	"context"
+)
+
+// LinkInvalidator tells the other app instances that links changed, so they
+// evict them from caches that aren't shared, like the in memory one
+type LinkInvalidator interface {
+	Invalidate(ctx context.Context, slugs ...string) error
+}
+
+type invalidatingLinkRepository struct {
+	LinkRepository
+	invalidator LinkInvalidator
+}
+
+// NewInvalidatingLinkRepository wraps repo, so every link inserted, updated or
+// deleted through it is invalidated in the other instances. Inserts are
+// invalidated too, since the slug may be cached as not found. Invalidation
+// errors are ignored, the other instances caches expire eventually
+func NewInvalidatingLinkRepository(repo LinkRepository, invalidator LinkInvalidator) LinkRepository {
+	return &invalidatingLinkRepository{
+		LinkRepository: repo,
+		invalidator:    invalidator,
+	}
+}
+
+var _ LinkRepository = &invalidatingLinkRepository{}
+
+func (ir *invalidatingLinkRepository) Insert(ctx context.Context, l *Link) (*Link, error) {
+	link, err := ir.LinkRepository.Insert(ctx, l)
+	if err != nil {
+		return link, err
+	}
+
+	ir.invalidator.Invalidate(ctx, l.Slug)
+	return link, nil
+}
+
+func (ir *invalidatingLinkRepository) InsertMany(ctx context.Context, links []Link) error {
+	err := ir.LinkRepository.InsertMany(ctx, links)
+	if err != nil || len(links) == 0 {
+		return err
+	}
+
+	slugs := make([]string, len(links))
+	for i := range links {
+		slugs[i] = links[i].Slug
+	}
+
+	ir.invalidator.Invalidate(ctx, slugs...)
+	return nil
+}
+
+func (ir *invalidatingLinkRepository) Update(ctx context.Context, l *Link) error {
+	err := ir.LinkRepository.Update(ctx, l)
+	if err != nil {
+		return err
+	}
+
+	ir.invalidator.Invalidate(ctx, l.Slug)
+	return nil
+}
+
+func (ir *invalidatingLinkRepository) Delete(ctx context.Context, slug string) error {
+	err := ir.LinkRepository.Delete(ctx, slug)
+	if err != nil {
+		return err
+	}
+
+	ir.invalidator.Invalidate(ctx, slug)
+	return nil
+}
@@ -0,0 +1,112 @@
+package shortener_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/joao-fontenele/go-url-shortener/pkg/mocks"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+func TestInvalidatingLinkRepository(t *testing.T) {
+	sampleLink := &shortener.Link{Slug: "aaaaa", URL: "https://www.google.com"}
+	unexpectedErr := errors.New("UnexpectedErr")
+
+	newRepo := func(err error) *mocks.FakeLinkRepo {
+		return &mocks.FakeLinkRepo{
+			InsertFn: func(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
+				return l, err
+			},
+			InsertManyFn: func(ctx context.Context, links []shortener.Link) error {
+				return err
+			},
+			UpdateFn: func(ctx context.Context, l *shortener.Link) error {
+				return err
+			},
+			DeleteFn: func(ctx context.Context, slug string) error {
+				return err
+			},
+			FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+				return sampleLink, err
+			},
+		}
+	}
+
+	tests := []struct {
+		Name      string
+		Call      func(r shortener.LinkRepository) error
+		WantSlugs []string
+	}{
+		{
+			Name: "Insert",
+			Call: func(r shortener.LinkRepository) error {
+				_, err := r.Insert(context.Background(), sampleLink)
+				return err
+			},
+			WantSlugs: []string{"aaaaa"},
+		},
+		{
+			Name: "InsertMany",
+			Call: func(r shortener.LinkRepository) error {
+				return r.InsertMany(context.Background(), []shortener.Link{*sampleLink, {Slug: "bbbbb"}})
+			},
+			WantSlugs: []string{"aaaaa", "bbbbb"},
+		},
+		{
+			Name: "Update",
+			Call: func(r shortener.LinkRepository) error {
+				return r.Update(context.Background(), sampleLink)
+			},
+			WantSlugs: []string{"aaaaa"},
+		},
+		{
+			Name: "Delete",
+			Call: func(r shortener.LinkRepository) error {
+				return r.Delete(context.Background(), "aaaaa")
+			},
+			WantSlugs: []string{"aaaaa"},
+		},
+		{
+			Name: "Find",
+			Call: func(r shortener.LinkRepository) error {
+				_, err := r.Find(context.Background(), "aaaaa")
+				return err
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			var gotSlugs []string
+			invalidator := &mocks.FakeLinkInvalidator{
+				InvalidateFn: func(ctx context.Context, slugs ...string) error {
+					gotSlugs = slugs
+					return unexpectedErr
+				},
+			}
+
+			r := shortener.NewInvalidatingLinkRepository(newRepo(nil), invalidator)
+			err := tc.Call(r)
+			if err != nil {
+				t.Errorf("Expected invalidation errors to be ignored, but got: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.WantSlugs, gotSlugs); diff != "" {
+				t.Errorf("Invalidated slugs different from expected (-want +got):\n%s", diff)
+			}
+
+			invalidator.InvalidateCalled = false
+			r = shortener.NewInvalidatingLinkRepository(newRepo(unexpectedErr), invalidator)
+			err = tc.Call(r)
+			if !errors.Is(err, unexpectedErr) {
+				t.Errorf("Expected repository error, but got: %v", err)
+			}
+
+			if invalidator.InvalidateCalled {
+				t.Error("Expected failed changes to not be invalidated")
+			}
+		})
+	}
+}
@@ -4,23 +4,69 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"strings"
 	"time"
 )
 
 // Link holds the attributes related to shortened link urls
 type Link struct {
-	Slug      string    `json:"slug"`
-	URL       string    `json:"url"`
-	CreatedAt time.Time `json:"createdAt"`
+	Slug      string     `json:"slug"`
+	URL       string     `json:"url"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	MaxClicks int        `json:"maxClicks,omitempty"`
+	Owner     string     `json:"owner,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
+	// RedirectType is 0 when the link uses the service default
+	RedirectType RedirectType `json:"redirectType,omitempty"`
+	// ClickCount is the amount of recorded clicks, only set when listing
+	ClickCount int64 `json:"clickCount,omitempty"`
 }
 
-// LinkDao represents a contract to access a single datastore
+// LinkUpdate changes some attributes of the link with Slug, the ones left nil
+// or zero are kept
+type LinkUpdate struct {
+	Slug string
+	URL  string
+	// ExpiresAt changes the expiration date, ClearExpiresAt removes it
+	ExpiresAt      *time.Time
+	ClearExpiresAt bool
+	// MaxClicks changes the click limit, pointing to 0 removes it
+	MaxClicks *int
+	// Tags replace the tags, an empty but not nil list removes them
+	Tags         []string
+	RedirectType RedirectType
+}
+
+// maxURLLength is the longest url a link may redirect to
+const maxURLLength = 2048
+
+// limits of the tags of a single link
+const (
+	maxTags      = 10
+	maxTagLength = 32
+)
+
+// LinkDao represents a contract to access a single datastore. Caches return
+// ErrCacheMiss from Find when they don't know about a slug, and ErrLinkNotFound
+// when they know it doesn't exist, see MarkNotFound. Caches also return
+// ErrCacheMiss from the lookups they can't answer, like FindByURL,
+// ExistingSlugs and IncrementClicks
 type LinkDao interface {
-	List(ctx context.Context, limit int, skip int) ([]Link, error)
+	// List returns links sorted by creation date, and then by slug
+	List(ctx context.Context, opts ListOptions) ([]Link, error)
 	Find(ctx context.Context, slug string) (*Link, error)
+	// FindByURL finds the oldest link of owner to url, that has no expiration
+	FindByURL(ctx context.Context, owner, url string) (*Link, error)
+	// ExistingSlugs returns which of slugs are in use
+	ExistingSlugs(ctx context.Context, slugs []string) ([]string, error)
 	Insert(ctx context.Context, l *Link) (*Link, error)
+	InsertMany(ctx context.Context, links []Link) error
 	Update(ctx context.Context, l *Link) error
 	Delete(ctx context.Context, slug string) error
+	IncrementClicks(ctx context.Context, slug string) (int, error)
+	// MarkNotFound remembers that slug doesn't exist, until it's inserted
+	MarkNotFound(ctx context.Context, slug string) error
 }
 
 // Validate checks if a link is valid
@@ -29,6 +75,10 @@ func (l *Link) Validate() error {
 		return fmt.Errorf("%w: Link should not be nil", ErrInvalidLink)
 	}
 
+	if len(l.URL) > maxURLLength {
+		return fmt.Errorf("%w: Link URL must have at most %d chars", ErrInvalidLink, maxURLLength)
+	}
+
 	u, err := url.Parse(l.URL)
 
 	if err != nil {
@@ -39,5 +89,40 @@ func (l *Link) Validate() error {
 		return fmt.Errorf("%w: Link URL is malformed", ErrInvalidLink)
 	}
 
+	if l.MaxClicks < 0 {
+		return fmt.Errorf("%w: Link max clicks must not be negative", ErrInvalidLink)
+	}
+
+	if l.RedirectType != 0 && !l.RedirectType.Valid() {
+		return fmt.Errorf("%w: Link redirect type must be 301, 302, 307 or 308", ErrInvalidLink)
+	}
+
+	if len(l.Tags) > maxTags {
+		return fmt.Errorf("%w: Link must have at most %d tags", ErrInvalidLink, maxTags)
+	}
+
+	for _, tag := range l.Tags {
+		if tag == "" || len(tag) > maxTagLength {
+			return fmt.Errorf("%w: Link tags must have 1 to %d chars", ErrInvalidLink, maxTagLength)
+		}
+	}
+
 	return err
 }
+
+// Expired checks if a link expiration date is due, at the given moment
+func (l *Link) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// SplitTags parses comma separated tags, skipping blank ones. The result is
+// never nil, so a string without tags removes them when used in a LinkUpdate
+func SplitTags(s string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
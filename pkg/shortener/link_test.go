@@ -2,9 +2,11 @@ package shortener_test
 
 import (
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
 )
 
@@ -46,6 +48,25 @@ func TestValidate(t *testing.T) {
 			},
 			WantErr: shortener.ErrInvalidLink,
 		},
+		{
+			Name: "InvalidNegativeMaxClicks",
+			Input: &shortener.Link{
+				Slug:      "aaaaa",
+				CreatedAt: time.Now(),
+				URL:       "https://www.google.com",
+				MaxClicks: -1,
+			},
+			WantErr: shortener.ErrInvalidLink,
+		},
+		{
+			Name: "InvalidTooLongURL",
+			Input: &shortener.Link{
+				Slug:      "aaaaa",
+				CreatedAt: time.Now(),
+				URL:       "https://www.google.com/" + strings.Repeat("a", 2048),
+			},
+			WantErr: shortener.ErrInvalidLink,
+		},
 		{
 			Name: "InvalidNoHostRL",
 			Input: &shortener.Link{
@@ -55,6 +76,56 @@ func TestValidate(t *testing.T) {
 			},
 			WantErr: shortener.ErrInvalidLink,
 		},
+		{
+			Name: "ValidTags",
+			Input: &shortener.Link{
+				Slug:      "aaaaa",
+				CreatedAt: time.Now(),
+				URL:       "https://www.google.com",
+				Tags:      []string{"sale", "print"},
+			},
+			WantErr: nil,
+		},
+		{
+			Name: "InvalidEmptyTag",
+			Input: &shortener.Link{
+				Slug:      "aaaaa",
+				CreatedAt: time.Now(),
+				URL:       "https://www.google.com",
+				Tags:      []string{""},
+			},
+			WantErr: shortener.ErrInvalidLink,
+		},
+		{
+			Name: "InvalidTooManyTags",
+			Input: &shortener.Link{
+				Slug:      "aaaaa",
+				CreatedAt: time.Now(),
+				URL:       "https://www.google.com",
+				Tags:      []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"},
+			},
+			WantErr: shortener.ErrInvalidLink,
+		},
+		{
+			Name: "ValidRedirectType",
+			Input: &shortener.Link{
+				Slug:         "aaaaa",
+				CreatedAt:    time.Now(),
+				URL:          "https://www.google.com",
+				RedirectType: shortener.RedirectPermanent,
+			},
+			WantErr: nil,
+		},
+		{
+			Name: "InvalidRedirectType",
+			Input: &shortener.Link{
+				Slug:         "aaaaa",
+				CreatedAt:    time.Now(),
+				URL:          "https://www.google.com",
+				RedirectType: 303,
+			},
+			WantErr: shortener.ErrInvalidLink,
+		},
 	}
 
 	for _, tc := range tests {
@@ -68,3 +139,54 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestExpired(t *testing.T) {
+	now := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	tests := []struct {
+		Name      string
+		ExpiresAt *time.Time
+		Want      bool
+	}{
+		{Name: "NoExpiration", ExpiresAt: nil, Want: false},
+		{Name: "ExpiresInTheFuture", ExpiresAt: &future, Want: false},
+		{Name: "ExpiresNow", ExpiresAt: &now, Want: true},
+		{Name: "ExpiredInThePast", ExpiresAt: &past, Want: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			l := &shortener.Link{Slug: "aaaaa", URL: "https://www.google.com", ExpiresAt: tc.ExpiresAt}
+			if got := l.Expired(now); got != tc.Want {
+				t.Errorf("Wrong expiration (want, got): (%v, %v)", tc.Want, got)
+			}
+		})
+	}
+}
+
+func TestSplitTags(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Input string
+		Want  []string
+	}{
+		{Name: "Empty", Input: "", Want: []string{}},
+		{Name: "OnlyBlanks", Input: " , ,", Want: []string{}},
+		{Name: "Trimmed", Input: "sale, print ,", Want: []string{"sale", "print"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			got := shortener.SplitTags(tc.Input)
+			if got == nil {
+				t.Error("Expected tags to not be nil")
+			}
+
+			if diff := cmp.Diff(tc.Want, got); diff != "" {
+				t.Errorf("Tags different from expected (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package shortener
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// default limits of listed links, when the service isn't configured with any
+const (
+	defaultListLimit = 20
+	defaultMaxLimit  = 100
+)
+
+// ListSort is the order links are listed in. Links with the same sort key are
+// sorted by slug, in the same direction
+type ListSort string
+
+// Link list orders, a leading - means descending
+const (
+	SortCreatedAt     ListSort = "createdAt"
+	SortCreatedAtDesc ListSort = "-createdAt"
+	SortClicks        ListSort = "clicks"
+	SortClicksDesc    ListSort = "-clicks"
+)
+
+var listSorts = map[ListSort]bool{
+	SortCreatedAt:     true,
+	SortCreatedAtDesc: true,
+	SortClicks:        true,
+	SortClicksDesc:    true,
+}
+
+// ListCursor is a position in the list of links sorted by Sort. Only the sort
+// key of Sort is set, along with Slug, so cursors can't be used with other sorts
+type ListCursor struct {
+	Sort       ListSort
+	CreatedAt  time.Time
+	ClickCount int64
+	Slug       string
+}
+
+// byClicks tells if s sorts links by their click count
+func (s ListSort) byClicks() bool {
+	return s == SortClicks || s == SortClicksDesc
+}
+
+// ListOptions selects a page of links, every filter set must match
+type ListOptions struct {
+	// Owner restricts the links listed, empty lists the links of every owner
+	Owner string
+	// Host matches the destination url host exactly, without subdomains
+	Host string
+	// URLContains matches a case insensitive substring of the destination url
+	URLContains string
+	// CreatedAfter and CreatedBefore are inclusive bounds of the creation date
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Tag           string
+	// Sort defaults to SortCreatedAt
+	Sort  ListSort
+	Limit int
+	Skip  int
+	// After lists only links after the cursor. Unlike Skip, it's as fast for
+	// deep pages as for the first one, and pages don't shift when links are
+	// created or deleted
+	After *ListCursor
+}
+
+// Validate checks if the filters and sort of the options are valid
+func (o *ListOptions) Validate() error {
+	if o.Sort != "" && !listSorts[o.Sort] {
+		return fmt.Errorf("%w: sort must be one of createdAt, -createdAt, clicks or -clicks", ErrInvalidListOptions)
+	}
+
+	if o.CreatedAfter != nil && o.CreatedBefore != nil && o.CreatedBefore.Before(*o.CreatedAfter) {
+		return fmt.Errorf("%w: createdBefore must not be before createdAfter", ErrInvalidListOptions)
+	}
+
+	sort := o.Sort
+	if sort == "" {
+		sort = SortCreatedAt
+	}
+	if o.After != nil && o.After.Sort != sort {
+		return fmt.Errorf("%w: cursor was listed with sort %s", ErrInvalidCursor, o.After.Sort)
+	}
+
+	return nil
+}
+
+// LinkPage is a page of listed links. NextCursor is empty on the last page
+type LinkPage struct {
+	Items      []Link `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// cursorAfter is the cursor pointing to the links after l, sorted by sort
+func cursorAfter(l Link, sort ListSort) *ListCursor {
+	if sort.byClicks() {
+		return &ListCursor{Sort: sort, ClickCount: l.ClickCount, Slug: l.Slug}
+	}
+	return &ListCursor{Sort: sort, CreatedAt: l.CreatedAt, Slug: l.Slug}
+}
+
+// EncodeListCursor returns an opaque representation of c, to be sent to clients
+func EncodeListCursor(c ListCursor) string {
+	key := c.CreatedAt.UTC().Format(time.RFC3339Nano)
+	if c.Sort.byClicks() {
+		key = strconv.FormatInt(c.ClickCount, 10)
+	}
+
+	raw := fmt.Sprintf("%s %s %s", c.Sort, key, c.Slug)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeListCursor parses a cursor encoded by EncodeListCursor
+func DecodeListCursor(s string) (*ListCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidCursor)
+	}
+
+	// neither sorts, keys nor slugs have spaces
+	parts := strings.SplitN(string(raw), " ", 3)
+	if len(parts) != 3 || parts[2] == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidCursor)
+	}
+
+	c := &ListCursor{Sort: ListSort(parts[0]), Slug: parts[2]}
+	if !listSorts[c.Sort] {
+		return nil, fmt.Errorf("%w: malformed cursor sort", ErrInvalidCursor)
+	}
+
+	if c.Sort.byClicks() {
+		c.ClickCount, err = strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor click count", ErrInvalidCursor)
+		}
+		return c, nil
+	}
+
+	c.CreatedAt, err = time.Parse(time.RFC3339Nano, parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor date", ErrInvalidCursor)
+	}
+
+	return c, nil
+}
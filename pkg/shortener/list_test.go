@@ -0,0 +1,188 @@
+package shortener_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/joao-fontenele/go-url-shortener/pkg/mocks"
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+func TestListCursor(t *testing.T) {
+	cursors := []shortener.ListCursor{
+		{
+			Sort:      shortener.SortCreatedAt,
+			CreatedAt: time.Date(2020, 5, 1, 12, 30, 0, 123456000, time.UTC),
+			Slug:      "a1CDz",
+		},
+		{
+			Sort:       shortener.SortClicksDesc,
+			ClickCount: 42,
+			Slug:       "a1CDz",
+		},
+	}
+
+	for _, cursor := range cursors {
+		decoded, err := shortener.DecodeListCursor(shortener.EncodeListCursor(cursor))
+		if err != nil {
+			t.Fatalf("Unexpected error decoding cursor: %v", err)
+		}
+
+		if diff := cmp.Diff(&cursor, decoded); diff != "" {
+			t.Errorf("Decoded cursor is not equal to encoded (-want +got):\n%s", diff)
+		}
+	}
+
+	invalid := []string{
+		"$$$",
+		"bm9zcGFjZQ", // "nospace"
+		"Y3JlYXRlZEF0IDIwMjAtMDUtMDFUMDA6MDA6MDBa", // "createdAt 2020-05-01T00:00:00Z"
+		"Y3JlYXRlZEF0IG5vdC1hLWRhdGUgYTFDRHo",      // "createdAt not-a-date a1CDz"
+		"c2x1ZyBhMUNEeiBhMUNEeg",                   // "slug a1CDz a1CDz"
+		"Y2xpY2tzIG1hbnkgYTFDRHo",                  // "clicks many a1CDz"
+		"MjAyMC0wNS0wMVQwMDowMDowMFogYTFDRHo",      // "2020-05-01T00:00:00Z a1CDz"
+	}
+	for _, s := range invalid {
+		_, err := shortener.DecodeListCursor(s)
+		if !errors.Is(err, shortener.ErrInvalidCursor) {
+			t.Errorf("Expected ErrInvalidCursor decoding %s, but got: %v", s, err)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	links := []shortener.Link{}
+	for i := 0; i < 5; i++ {
+		links = append(links, shortener.Link{
+			Slug:      fmt.Sprintf("s%d", i),
+			CreatedAt: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC),
+		})
+	}
+
+	var listed shortener.ListOptions
+	repo := &mocks.FakeLinkRepo{
+		ListFn: func(ctx context.Context, opts shortener.ListOptions) ([]shortener.Link, error) {
+			listed = opts
+			if opts.Limit > len(links) {
+				return links, nil
+			}
+			return links[:opts.Limit], nil
+		},
+	}
+	s := shortener.NewLinkService(
+		repo,
+		shortener.NewRandomSlugGenerator(),
+		&mocks.FakeSlugMetrics{},
+		shortener.LinkServiceOptions{ListLimit: 2, ListMaxLimit: 3},
+	)
+
+	tests := []struct {
+		Name           string
+		Limit          int
+		WantRepoLimit  int
+		WantItems      int
+		WantNextCursor string
+	}{
+		{
+			Name:           "DefaultLimit",
+			Limit:          0,
+			WantRepoLimit:  3,
+			WantItems:      2,
+			WantNextCursor: shortener.EncodeListCursor(shortener.ListCursor{Sort: shortener.SortCreatedAt, CreatedAt: links[1].CreatedAt, Slug: "s1"}),
+		},
+		{
+			Name:           "MaxLimit",
+			Limit:          10,
+			WantRepoLimit:  4,
+			WantItems:      3,
+			WantNextCursor: shortener.EncodeListCursor(shortener.ListCursor{Sort: shortener.SortCreatedAt, CreatedAt: links[2].CreatedAt, Slug: "s2"}),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			page, err := s.List(context.Background(), shortener.ListOptions{Limit: tc.Limit})
+			if err != nil {
+				t.Fatalf("Unexpected error listing links: %v", err)
+			}
+
+			if listed.Limit != tc.WantRepoLimit {
+				t.Errorf("Wrong limit listed from repository (want, got): (%d, %d)", tc.WantRepoLimit, listed.Limit)
+			}
+
+			if len(page.Items) != tc.WantItems {
+				t.Errorf("Wrong amount of listed links (want, got): (%d, %d)", tc.WantItems, len(page.Items))
+			}
+
+			if page.NextCursor != tc.WantNextCursor {
+				t.Errorf("Wrong next cursor (want, got): (%s, %s)", tc.WantNextCursor, page.NextCursor)
+			}
+		})
+	}
+
+	t.Run("NoNextCursorOnLastPage", func(t *testing.T) {
+		repo.ListFn = func(ctx context.Context, opts shortener.ListOptions) ([]shortener.Link, error) {
+			return links[:2], nil
+		}
+
+		page, err := s.List(context.Background(), shortener.ListOptions{Limit: 2})
+		if err != nil {
+			t.Fatalf("Unexpected error listing links: %v", err)
+		}
+
+		if diff := cmp.Diff(&shortener.LinkPage{Items: links[:2]}, page); diff != "" {
+			t.Errorf("Wrong last page (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("EmptyPage", func(t *testing.T) {
+		repo.ListFn = func(ctx context.Context, opts shortener.ListOptions) ([]shortener.Link, error) {
+			return nil, nil
+		}
+
+		page, err := s.List(context.Background(), shortener.ListOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error listing links: %v", err)
+		}
+
+		if diff := cmp.Diff(&shortener.LinkPage{Items: []shortener.Link{}}, page); diff != "" {
+			t.Errorf("Wrong empty page (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestListOptionsValidate(t *testing.T) {
+	day := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	nextDay := day.Add(24 * time.Hour)
+	createdAtCursor := &shortener.ListCursor{Sort: shortener.SortCreatedAt, CreatedAt: day, Slug: "a1CDz"}
+	clicksCursor := &shortener.ListCursor{Sort: shortener.SortClicksDesc, ClickCount: 1, Slug: "a1CDz"}
+
+	tests := []struct {
+		Name    string
+		Opts    shortener.ListOptions
+		WantErr error
+	}{
+		{Name: "NoOptions", Opts: shortener.ListOptions{}, WantErr: nil},
+		{Name: "ValidSort", Opts: shortener.ListOptions{Sort: shortener.SortClicksDesc}, WantErr: nil},
+		{Name: "InvalidSort", Opts: shortener.ListOptions{Sort: "slug"}, WantErr: shortener.ErrInvalidListOptions},
+		{Name: "ValidRange", Opts: shortener.ListOptions{CreatedAfter: &day, CreatedBefore: &nextDay}, WantErr: nil},
+		{Name: "InvalidRange", Opts: shortener.ListOptions{CreatedAfter: &nextDay, CreatedBefore: &day}, WantErr: shortener.ErrInvalidListOptions},
+		{Name: "DefaultSortCursor", Opts: shortener.ListOptions{After: createdAtCursor}, WantErr: nil},
+		{Name: "SameSortCursor", Opts: shortener.ListOptions{Sort: shortener.SortClicksDesc, After: clicksCursor}, WantErr: nil},
+		{Name: "OtherSortCursor", Opts: shortener.ListOptions{Sort: shortener.SortClicks, After: clicksCursor}, WantErr: shortener.ErrInvalidCursor},
+		{Name: "DefaultSortOtherCursor", Opts: shortener.ListOptions{After: clicksCursor}, WantErr: shortener.ErrInvalidCursor},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Opts.Validate()
+			if !errors.Is(err, tc.WantErr) {
+				t.Errorf("Wrong error (want, got): (%v, %v)", tc.WantErr, err)
+			}
+		})
+	}
+}
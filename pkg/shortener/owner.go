@@ -0,0 +1,20 @@
+package shortener
+
+import "context"
+
+// OwnerKey is the context key holding the ID of who is making a request. It's
+// a plain string, since that's the only kind of key fasthttp contexts support
+const OwnerKey = "shortener.owner"
+
+// OwnerFromContext returns the owner attached to ctx. An empty owner means the
+// caller isn't scoped to any owner, like admin tools
+func OwnerFromContext(ctx context.Context) string {
+	owner, _ := ctx.Value(OwnerKey).(string)
+	return owner
+}
+
+// canAccess tells if the owner in ctx may see or change a link
+func canAccess(ctx context.Context, l *Link) bool {
+	owner := OwnerFromContext(ctx)
+	return owner == "" || owner == l.Owner
+}
@@ -0,0 +1,59 @@
+package shortener
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// RedirectType is the http status code a link redirects with. Links without
+// one redirect with the service default, see LinkServiceOptions
+type RedirectType int
+
+// Link redirect types. Browsers cache permanent redirects, so repeated clicks
+// never reach the server, and later changes to the link url are missed
+const (
+	RedirectMovedPermanently RedirectType = http.StatusMovedPermanently
+	RedirectFound            RedirectType = http.StatusFound
+	RedirectTemporary        RedirectType = http.StatusTemporaryRedirect
+	RedirectPermanent        RedirectType = http.StatusPermanentRedirect
+)
+
+// DefaultRedirectType is used when the service isn't configured with any.
+// Temporary redirects keep every click counted
+const DefaultRedirectType = RedirectFound
+
+// Valid tells if t is one of the supported redirect types
+func (t RedirectType) Valid() bool {
+	switch t {
+	case RedirectMovedPermanently, RedirectFound, RedirectTemporary, RedirectPermanent:
+		return true
+	}
+	return false
+}
+
+// Permanent tells if browsers and proxies may cache redirects of type t
+func (t RedirectType) Permanent() bool {
+	return t == RedirectMovedPermanently || t == RedirectPermanent
+}
+
+// CacheControl is the Cache-Control header of a redirect to l, which must have
+// a RedirectType. Only permanent redirects are cached, up to maxAge, and never
+// beyond the link expiration. Links limited by clicks aren't cached, or clicks
+// would be missed
+func (l *Link) CacheControl(now time.Time, maxAge time.Duration) string {
+	if !l.RedirectType.Permanent() || l.MaxClicks > 0 {
+		return "private, no-store"
+	}
+
+	if l.ExpiresAt != nil && l.ExpiresAt.Sub(now) < maxAge {
+		maxAge = l.ExpiresAt.Sub(now)
+	}
+
+	seconds := int(maxAge / time.Second)
+	if seconds <= 0 {
+		return "private, no-store"
+	}
+
+	return fmt.Sprintf("public, max-age=%d", seconds)
+}
@@ -0,0 +1,45 @@
+package shortener_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+func TestCacheControl(t *testing.T) {
+	now := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	soon := now.Add(time.Hour)
+	past := now.Add(-time.Minute)
+
+	tests := []struct {
+		Name         string
+		RedirectType shortener.RedirectType
+		ExpiresAt    *time.Time
+		MaxClicks    int
+		Want         string
+	}{
+		{Name: "Temporary", RedirectType: shortener.RedirectFound, Want: "private, no-store"},
+		{Name: "TemporaryRedirect", RedirectType: shortener.RedirectTemporary, Want: "private, no-store"},
+		{Name: "Permanent", RedirectType: shortener.RedirectMovedPermanently, Want: "public, max-age=86400"},
+		{Name: "PermanentRedirect", RedirectType: shortener.RedirectPermanent, Want: "public, max-age=86400"},
+		{Name: "PermanentExpiresSoon", RedirectType: shortener.RedirectPermanent, ExpiresAt: &soon, Want: "public, max-age=3600"},
+		{Name: "PermanentExpired", RedirectType: shortener.RedirectPermanent, ExpiresAt: &past, Want: "private, no-store"},
+		{Name: "PermanentMaxClicks", RedirectType: shortener.RedirectMovedPermanently, MaxClicks: 10, Want: "private, no-store"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			l := &shortener.Link{
+				Slug:         "aaaaa",
+				URL:          "https://www.google.com",
+				ExpiresAt:    tc.ExpiresAt,
+				MaxClicks:    tc.MaxClicks,
+				RedirectType: tc.RedirectType,
+			}
+			if got := l.CacheControl(now, 24*time.Hour); got != tc.Want {
+				t.Errorf("Wrong cache control (want, got): (%s, %s)", tc.Want, got)
+			}
+		})
+	}
+}
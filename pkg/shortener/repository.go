@@ -2,44 +2,124 @@ package shortener
 
 import (
 	"context"
+	"errors"
 )
 
 // LinkRepository is a contract between services and underlying datastore
 type LinkRepository interface {
-	List(ctx context.Context, limit, skip int) ([]Link, error)
+	List(ctx context.Context, opts ListOptions) ([]Link, error)
 	Find(ctx context.Context, slug string) (*Link, error)
+	// FindUncached finds a link in the db, skipping the caches
+	FindUncached(ctx context.Context, slug string) (*Link, error)
+	FindByURL(ctx context.Context, owner, url string) (*Link, error)
+	// ExistingSlugs returns which of slugs are in use, according to the db
+	ExistingSlugs(ctx context.Context, slugs []string) ([]string, error)
 	Insert(ctx context.Context, l *Link) (*Link, error)
+	InsertMany(ctx context.Context, links []Link) error
 	Update(ctx context.Context, l *Link) error
 	Delete(ctx context.Context, slug string) error
+	IncrementClicks(ctx context.Context, slug string) (int, error)
 }
 
 type linkRepository struct {
-	dbDao    LinkDao
-	cacheDao LinkDao
+	dbDao LinkDao
+	// caches are tiers from the fastest to the slowest
+	caches []LinkDao
+	finds  findGroup
+	gens   generations
 }
 
 var _ LinkRepository = &linkRepository{}
 
-// NewLinkRepository instantiates a LinkRepository, given a Dao
-func NewLinkRepository(dbDao LinkDao, cacheDao LinkDao) LinkRepository {
+// NewLinkRepository instantiates a LinkRepository, given the db Dao, and cache
+// Daos ordered from the fastest to the slowest tier
+func NewLinkRepository(dbDao LinkDao, cacheDaos ...LinkDao) LinkRepository {
 	return &linkRepository{
-		dbDao,
-		cacheDao,
+		dbDao:  dbDao,
+		caches: cacheDaos,
 	}
 }
 
+// Find looks for a link in each cache tier, filling the faster tiers when found
+// in a slower one. Cache misses and errors fall back to the db, with concurrent
+// lookups of the same slug sharing a single query, and the result is cached in
+// every tier, including when the link doesn't exist. Links changed during the
+// db lookup are invalidated again, so a stale result doesn't stay cached
 func (lr *linkRepository) Find(ctx context.Context, slug string) (*Link, error) {
-	link, _ := lr.cacheDao.Find(ctx, slug)
+	for i, cache := range lr.caches {
+		link, err := cache.Find(ctx, slug)
+		if err == nil {
+			lr.cacheInsert(ctx, lr.caches[:i], link)
+			return link, nil
+		}
 
-	if link != nil {
+		if errors.Is(err, ErrLinkNotFound) {
+			lr.cacheMarkNotFound(ctx, lr.caches[:i], slug)
+			return nil, err
+		}
+	}
+
+	// the db lookup is shared by concurrent callers, and runs with a context
+	// of its own, so a caller that gives up doesn't fail the others
+	return lr.finds.do(ctx, slug, func(ctx context.Context) (*Link, error) {
+		// a link changed or created while it's looked up may be cached stale
+		// below, so it's invalidated again
+		gen := lr.gens.start(slug)
+		defer func() {
+			if lr.gens.finish(slug, gen) {
+				lr.cacheDelete(ctx, slug)
+			}
+		}()
+
+		link, err := lr.dbDao.Find(ctx, slug)
+		if errors.Is(err, ErrLinkNotFound) {
+			lr.cacheMarkNotFound(ctx, lr.caches, slug)
+			return nil, err
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		lr.cacheInsert(ctx, lr.caches, link)
 		return link, nil
+	})
+}
+
+// cache errors are ignored by the helpers below, the db is the source of truth
+
+func (lr *linkRepository) cacheInsert(ctx context.Context, caches []LinkDao, l *Link) {
+	for _, cache := range caches {
+		cache.Insert(ctx, l)
 	}
+}
 
-	link, err := lr.dbDao.Find(ctx, slug)
-	if err != nil {
-		return nil, err
+func (lr *linkRepository) cacheMarkNotFound(ctx context.Context, caches []LinkDao, slug string) {
+	for _, cache := range caches {
+		cache.MarkNotFound(ctx, slug)
 	}
-	return link, nil
+}
+
+func (lr *linkRepository) cacheDelete(ctx context.Context, slug string) {
+	lr.gens.bump(slug)
+	for _, cache := range lr.caches {
+		cache.Delete(ctx, slug)
+	}
+}
+
+// FindUncached only goes to the db, for callers that write the link back, so
+// they don't overwrite changes that aren't cached yet
+func (lr *linkRepository) FindUncached(ctx context.Context, slug string) (*Link, error) {
+	return lr.dbDao.Find(ctx, slug)
+}
+
+// FindByURL only goes to the db, since the cache is indexed by slug
+func (lr *linkRepository) FindByURL(ctx context.Context, owner, url string) (*Link, error) {
+	return lr.dbDao.FindByURL(ctx, owner, url)
+}
+
+// ExistingSlugs only goes to the db, since caches don't know every link
+func (lr *linkRepository) ExistingSlugs(ctx context.Context, slugs []string) ([]string, error) {
+	return lr.dbDao.ExistingSlugs(ctx, slugs)
 }
 
 func (lr *linkRepository) Insert(ctx context.Context, l *Link) (*Link, error) {
@@ -53,13 +133,50 @@ func (lr *linkRepository) Insert(ctx context.Context, l *Link) (*Link, error) {
 		return l, err
 	}
 
-	// ignore any possible cache errors
-	lr.cacheDao.Insert(ctx, l)
+	lr.cacheInsert(ctx, lr.caches, l)
+	lr.gens.bump(l.Slug)
 	return l, nil
 }
 
+// InsertMany writes to the db, and then caches the links, overwriting any slug
+// that was cached as not found
+func (lr *linkRepository) InsertMany(ctx context.Context, links []Link) error {
+	for i := range links {
+		err := links[i].Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := lr.dbDao.InsertMany(ctx, links)
+	if err != nil {
+		return err
+	}
+
+	for _, cache := range lr.caches {
+		cache.InsertMany(ctx, links)
+	}
+	for _, l := range links {
+		lr.gens.bump(l.Slug)
+	}
+	return nil
+}
+
 func (lr *linkRepository) Update(ctx context.Context, l *Link) error {
-	panic("not yet implemented")
+	err := l.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = lr.dbDao.Update(ctx, l)
+	if err != nil {
+		return err
+	}
+
+	// invalidate the cached link instead of overwriting it, the next Find will
+	// fetch the fresh version from the db
+	lr.cacheDelete(ctx, l.Slug)
+	return nil
 }
 
 func (lr *linkRepository) Delete(ctx context.Context, slug string) error {
@@ -68,11 +185,16 @@ func (lr *linkRepository) Delete(ctx context.Context, slug string) error {
 		return err
 	}
 
-	// ignore any possible cache errors
-	lr.cacheDao.Delete(ctx, slug)
+	lr.cacheDelete(ctx, slug)
 	return nil
 }
 
-func (lr *linkRepository) List(ctx context.Context, limit int, skip int) ([]Link, error) {
-	return lr.dbDao.List(ctx, limit, skip)
+// IncrementClicks always goes to the db, since it's the source of truth for counters
+func (lr *linkRepository) IncrementClicks(ctx context.Context, slug string) (int, error) {
+	return lr.dbDao.IncrementClicks(ctx, slug)
+}
+
+// List returns the links of the owner in opts, or every link if it's empty
+func (lr *linkRepository) List(ctx context.Context, opts ListOptions) ([]Link, error) {
+	return lr.dbDao.List(ctx, opts)
 }
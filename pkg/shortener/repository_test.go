@@ -3,6 +3,8 @@ package shortener_test
 import (
 	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -28,9 +30,25 @@ func TestFind(t *testing.T) {
 		return nil, shortener.ErrLinkNotFound
 	}
 
+	cacheMissFind := func(ctx context.Context, slug string) (*shortener.Link, error) {
+		return nil, shortener.ErrCacheMiss
+	}
+
+	newCache := func(findFn findFn) *mocks.FakeLinkDao {
+		return &mocks.FakeLinkDao{
+			FindFn: findFn,
+			InsertFn: func(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
+				return l, nil
+			},
+			MarkNotFoundFn: func(ctx context.Context, slug string) error {
+				return nil
+			},
+		}
+	}
+
 	t.Run("CacheHit", func(t *testing.T) {
 		db := &mocks.FakeLinkDao{FindFn: hitFind}
-		cache := &mocks.FakeLinkDao{FindFn: hitFind}
+		cache := newCache(hitFind)
 
 		r := shortener.NewLinkRepository(db, cache)
 
@@ -53,9 +71,26 @@ func TestFind(t *testing.T) {
 		}
 	})
 
+	t.Run("CachedNotFound", func(t *testing.T) {
+		db := &mocks.FakeLinkDao{FindFn: hitFind}
+		cache := newCache(missFind)
+
+		r := shortener.NewLinkRepository(db, cache)
+
+		_, err := r.Find(context.Background(), "dontcare")
+
+		if !errors.Is(err, shortener.ErrLinkNotFound) {
+			t.Errorf("Expected error to be ErrLinkNotFound, but got: %v", err)
+		}
+
+		if db.FindCalled {
+			t.Error("Expected db find to not have been called")
+		}
+	})
+
 	t.Run("CacheMissDbMiss", func(t *testing.T) {
 		db := &mocks.FakeLinkDao{FindFn: missFind}
-		cache := &mocks.FakeLinkDao{FindFn: missFind}
+		cache := newCache(cacheMissFind)
 
 		r := shortener.NewLinkRepository(db, cache)
 
@@ -76,11 +111,15 @@ func TestFind(t *testing.T) {
 		if !cache.FindCalled {
 			t.Error("Expected cache find to have been called")
 		}
+
+		if !cache.MarkNotFoundCalled {
+			t.Error("Expected slug to have been cached as not found")
+		}
 	})
 
 	t.Run("CacheMissDbHit", func(t *testing.T) {
 		db := &mocks.FakeLinkDao{FindFn: hitFind}
-		cache := &mocks.FakeLinkDao{FindFn: missFind}
+		cache := newCache(cacheMissFind)
 
 		r := shortener.NewLinkRepository(db, cache)
 
@@ -98,10 +137,367 @@ func TestFind(t *testing.T) {
 			t.Error("Expected cache find to have been called")
 		}
 
+		if !cache.InsertCalled {
+			t.Error("Expected link to have been cached")
+		}
+
 		if diff := cmp.Diff(sampleLink, link); diff != "" {
 			t.Errorf("Found link different from expected (-want +got):\n%s", diff)
 		}
 	})
+
+	t.Run("CacheErrorDbHit", func(t *testing.T) {
+		db := &mocks.FakeLinkDao{FindFn: hitFind}
+		cache := newCache(func(ctx context.Context, slug string) (*shortener.Link, error) {
+			return nil, errors.New("ConnectionRefused")
+		})
+
+		r := shortener.NewLinkRepository(db, cache)
+
+		_, err := r.Find(context.Background(), "dontcare")
+
+		if err != nil {
+			t.Errorf("Unexpected error calling repository Find: %v", err)
+		}
+
+		if !db.FindCalled {
+			t.Error("Expected db find to have been called")
+		}
+	})
+
+	t.Run("DbErrorNotCached", func(t *testing.T) {
+		db := &mocks.FakeLinkDao{FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+			return nil, errors.New("ConnectionRefused")
+		}}
+		cache := newCache(cacheMissFind)
+
+		r := shortener.NewLinkRepository(db, cache)
+
+		_, err := r.Find(context.Background(), "dontcare")
+
+		if err == nil {
+			t.Error("Expected db error to be returned")
+		}
+
+		if cache.InsertCalled || cache.MarkNotFoundCalled {
+			t.Error("Expected nothing to have been cached")
+		}
+	})
+
+	t.Run("TieredCacheHit", func(t *testing.T) {
+		db := &mocks.FakeLinkDao{FindFn: hitFind}
+		memory := newCache(cacheMissFind)
+		redis := newCache(hitFind)
+
+		r := shortener.NewLinkRepository(db, memory, redis)
+
+		link, err := r.Find(context.Background(), "dontcare")
+
+		if err != nil {
+			t.Errorf("Unexpected error calling repository Find: %v", err)
+		}
+
+		if db.FindCalled {
+			t.Error("Expected db find to not have been called")
+		}
+
+		if !memory.InsertCalled {
+			t.Error("Expected link to have been cached in the faster tier")
+		}
+
+		if redis.InsertCalled {
+			t.Error("Expected link to not be cached again in the tier it was found")
+		}
+
+		if diff := cmp.Diff(sampleLink, link); diff != "" {
+			t.Errorf("Found link different from expected (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("TieredCachedNotFound", func(t *testing.T) {
+		db := &mocks.FakeLinkDao{FindFn: hitFind}
+		memory := newCache(cacheMissFind)
+		redis := newCache(missFind)
+
+		r := shortener.NewLinkRepository(db, memory, redis)
+
+		_, err := r.Find(context.Background(), "dontcare")
+
+		if !errors.Is(err, shortener.ErrLinkNotFound) {
+			t.Errorf("Expected error to be ErrLinkNotFound, but got: %v", err)
+		}
+
+		if db.FindCalled {
+			t.Error("Expected db find to not have been called")
+		}
+
+		if !memory.MarkNotFoundCalled {
+			t.Error("Expected slug to have been cached as not found in the faster tier")
+		}
+	})
+
+	t.Run("TieredCacheMissDbHit", func(t *testing.T) {
+		db := &mocks.FakeLinkDao{FindFn: hitFind}
+		memory := newCache(cacheMissFind)
+		redis := newCache(cacheMissFind)
+
+		r := shortener.NewLinkRepository(db, memory, redis)
+
+		if _, err := r.Find(context.Background(), "dontcare"); err != nil {
+			t.Errorf("Unexpected error calling repository Find: %v", err)
+		}
+
+		if !memory.InsertCalled || !redis.InsertCalled {
+			t.Error("Expected link to have been cached in every tier")
+		}
+	})
+}
+
+// concurrentFindDao counts Find calls safely, for concurrent tests
+type concurrentFindDao struct {
+	*mocks.FakeLinkDao
+	calls  int32
+	findFn findFn
+}
+
+func (d *concurrentFindDao) Find(ctx context.Context, slug string) (*shortener.Link, error) {
+	atomic.AddInt32(&d.calls, 1)
+	return d.findFn(ctx, slug)
+}
+
+func TestFindCoalescing(t *testing.T) {
+	const callers = 10
+	release := make(chan struct{})
+	db := &concurrentFindDao{
+		FakeLinkDao: &mocks.FakeLinkDao{},
+		findFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+			<-release
+			return &shortener.Link{Slug: slug, URL: "https://www.google.com", Tags: []string{"search"}}, nil
+		},
+	}
+	cache := &concurrentFindDao{
+		FakeLinkDao: &mocks.FakeLinkDao{
+			InsertFn: func(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
+				return l, nil
+			},
+		},
+		findFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+			return nil, shortener.ErrCacheMiss
+		},
+	}
+
+	r := shortener.NewLinkRepository(db, cache)
+
+	var wg sync.WaitGroup
+	links := make([]*shortener.Link, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			links[i], _ = r.Find(context.Background(), "aaaaa")
+		}(i)
+	}
+
+	// wait for every caller to miss the cache before the db answers
+	for atomic.LoadInt32(&cache.calls) < callers {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls := atomic.LoadInt32(&db.calls); calls != 1 {
+		t.Errorf("Expected a single db query, but got %d", calls)
+	}
+
+	for i, l := range links {
+		if l == nil || l.Slug != "aaaaa" {
+			t.Fatalf("Wrong link for caller %d: %v", i, l)
+		}
+
+		if i > 0 && (l == links[0] || &l.Tags[0] == &links[0].Tags[0]) {
+			t.Error("Expected each caller to get its own copy of the link")
+		}
+	}
+}
+
+func TestFindCallerCanceled(t *testing.T) {
+	release := make(chan struct{})
+	db := &concurrentFindDao{
+		FakeLinkDao: &mocks.FakeLinkDao{},
+		findFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+			<-release
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return &shortener.Link{Slug: slug, URL: "https://www.google.com"}, nil
+		},
+	}
+	cache := &concurrentFindDao{
+		FakeLinkDao: &mocks.FakeLinkDao{
+			InsertFn: func(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
+				return l, nil
+			},
+		},
+		findFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+			return nil, shortener.ErrCacheMiss
+		},
+	}
+
+	r := shortener.NewLinkRepository(db, cache)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error)
+	go func() {
+		_, err := r.Find(ctx, "aaaaa")
+		canceled <- err
+	}()
+
+	var link *shortener.Link
+	var err error
+	found := make(chan struct{})
+	go func() {
+		link, err = r.Find(context.Background(), "aaaaa")
+		close(found)
+	}()
+
+	for atomic.LoadInt32(&cache.calls) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	if err := <-canceled; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the canceled caller to get context.Canceled, but got: %v", err)
+	}
+
+	close(release)
+	<-found
+
+	if err != nil {
+		t.Errorf("Expected the other caller to not fail, but got: %v", err)
+	}
+
+	if link == nil || link.Slug != "aaaaa" {
+		t.Errorf("Wrong link for the other caller: %v", link)
+	}
+}
+
+func TestFindConcurrentChange(t *testing.T) {
+	oldLink := &shortener.Link{Slug: "aaaaa", URL: "https://www.google.com"}
+
+	tests := []struct {
+		Name   string
+		Change func(r shortener.LinkRepository) error
+	}{
+		{
+			Name: "Update",
+			Change: func(r shortener.LinkRepository) error {
+				return r.Update(context.Background(), &shortener.Link{Slug: "aaaaa", URL: "https://duckduckgo.com"})
+			},
+		},
+		{
+			Name: "Delete",
+			Change: func(r shortener.LinkRepository) error {
+				return r.Delete(context.Background(), "aaaaa")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			var cached *shortener.Link
+			cache := &mocks.FakeLinkDao{
+				FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+					return nil, shortener.ErrCacheMiss
+				},
+				InsertFn: func(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
+					cached = l
+					return l, nil
+				},
+				DeleteFn: func(ctx context.Context, slug string) error {
+					cached = nil
+					return nil
+				},
+			}
+			db := &mocks.FakeLinkDao{
+				UpdateFn: func(ctx context.Context, l *shortener.Link) error {
+					return nil
+				},
+				DeleteFn: func(ctx context.Context, slug string) error {
+					return nil
+				},
+			}
+
+			r := shortener.NewLinkRepository(db, cache)
+
+			// the link changes after the db read it, but before it's cached
+			db.FindFn = func(ctx context.Context, slug string) (*shortener.Link, error) {
+				if err := tc.Change(r); err != nil {
+					t.Fatalf("Unexpected error changing the link: %v", err)
+				}
+				return oldLink, nil
+			}
+
+			if _, err := r.Find(context.Background(), "aaaaa"); err != nil {
+				t.Errorf("Unexpected error calling repository Find: %v", err)
+			}
+
+			if cached != nil {
+				t.Errorf("Expected the stale link to not stay cached, but got: %v", cached)
+			}
+		})
+	}
+}
+
+func TestFindByURL(t *testing.T) {
+	sampleLink := &shortener.Link{Slug: "aaaaa", URL: "https://www.google.com", Owner: "alice"}
+	db := &mocks.FakeLinkDao{
+		FindByURLFn: func(ctx context.Context, owner, url string) (*shortener.Link, error) {
+			return sampleLink, nil
+		},
+	}
+	cache := &mocks.FakeLinkDao{}
+
+	r := shortener.NewLinkRepository(db, cache)
+
+	link, err := r.FindByURL(context.Background(), "alice", "https://www.google.com")
+	if err != nil {
+		t.Errorf("Unexpected error calling repository FindByURL: %v", err)
+	}
+
+	if cache.FindByURLCalled {
+		t.Error("Expected cache to not have been called")
+	}
+
+	if diff := cmp.Diff(sampleLink, link); diff != "" {
+		t.Errorf("Found link different from expected (-want +got):\n%s", diff)
+	}
+}
+
+func TestFindUncached(t *testing.T) {
+	sampleLink := &shortener.Link{Slug: "aaaaa", URL: "https://www.google.com"}
+	db := &mocks.FakeLinkDao{
+		FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+			return sampleLink, nil
+		},
+	}
+	cache := &mocks.FakeLinkDao{}
+
+	r := shortener.NewLinkRepository(db, cache)
+
+	link, err := r.FindUncached(context.Background(), "aaaaa")
+	if err != nil {
+		t.Errorf("Unexpected error calling repository FindUncached: %v", err)
+	}
+
+	if cache.FindCalled || cache.InsertCalled {
+		t.Error("Expected cache to not have been called")
+	}
+
+	if diff := cmp.Diff(sampleLink, link); diff != "" {
+		t.Errorf("Found link different from expected (-want +got):\n%s", diff)
+	}
 }
 
 func TestInsert(t *testing.T) {
@@ -219,3 +615,148 @@ func TestDelete(t *testing.T) {
 		}
 	})
 }
+
+func TestUpdate(t *testing.T) {
+	sampleLink := &shortener.Link{
+		URL:       "https://www.google.com/?search=Google",
+		Slug:      "aaaaa",
+		CreatedAt: time.Now(),
+	}
+	okUpdate := func(ctx context.Context, l *shortener.Link) error {
+		return nil
+	}
+
+	failUpdate := func(ctx context.Context, l *shortener.Link) error {
+		return shortener.ErrLinkNotFound
+	}
+
+	okDelete := func(ctx context.Context, slug string) error {
+		return nil
+	}
+
+	t.Run("InvalidLink", func(t *testing.T) {
+		db := &mocks.FakeLinkDao{}
+		cache := &mocks.FakeLinkDao{}
+
+		r := shortener.NewLinkRepository(db, cache)
+
+		err := r.Update(context.Background(), &shortener.Link{Slug: "aaaaa"})
+
+		if !errors.Is(err, shortener.ErrInvalidLink) {
+			t.Errorf("Expected err to be %v, but got %v", shortener.ErrInvalidLink, err)
+		}
+
+		if db.UpdateCalled {
+			t.Error("Expected db to not have been called")
+		}
+	})
+
+	t.Run("DbFail", func(t *testing.T) {
+		db := &mocks.FakeLinkDao{UpdateFn: failUpdate}
+		cache := &mocks.FakeLinkDao{DeleteFn: okDelete}
+
+		r := shortener.NewLinkRepository(db, cache)
+
+		err := r.Update(context.Background(), sampleLink)
+
+		if !errors.Is(err, shortener.ErrLinkNotFound) {
+			t.Errorf("Expected err to be %v, but got %v", shortener.ErrLinkNotFound, err)
+		}
+
+		if cache.DeleteCalled {
+			t.Error("Expected cache to not have been called")
+		}
+	})
+
+	t.Run("DbOK", func(t *testing.T) {
+		db := &mocks.FakeLinkDao{UpdateFn: okUpdate}
+		cache := &mocks.FakeLinkDao{DeleteFn: okDelete}
+
+		r := shortener.NewLinkRepository(db, cache)
+
+		err := r.Update(context.Background(), sampleLink)
+
+		if err != nil {
+			t.Errorf("Unexpected error updating link: %v", err)
+		}
+
+		if !cache.DeleteCalled {
+			t.Error("Expected cache entry to have been invalidated")
+		}
+	})
+}
+
+func TestIncrementClicks(t *testing.T) {
+	db := &mocks.FakeLinkDao{
+		IncrementClicksFn: func(ctx context.Context, slug string) (int, error) {
+			return 3, nil
+		},
+	}
+	cache := &mocks.FakeLinkDao{}
+
+	r := shortener.NewLinkRepository(db, cache)
+
+	clicks, err := r.IncrementClicks(context.Background(), "aaaaa")
+
+	if err != nil {
+		t.Errorf("Unexpected error incrementing clicks: %v", err)
+	}
+
+	if clicks != 3 {
+		t.Errorf("Expected clicks to be 3, but got %d", clicks)
+	}
+
+	if cache.IncrementClicksCalled {
+		t.Error("Expected cache to not have been called")
+	}
+}
+
+func TestInsertMany(t *testing.T) {
+	insertMany := func(ctx context.Context, links []shortener.Link) error {
+		return nil
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		db := &mocks.FakeLinkDao{InsertManyFn: insertMany}
+		cache := &mocks.FakeLinkDao{InsertManyFn: insertMany}
+
+		r := shortener.NewLinkRepository(db, cache)
+
+		err := r.InsertMany(context.Background(), []shortener.Link{
+			{Slug: "aaaaa", URL: "https://www.google.com"},
+			{Slug: "aaaab", URL: "https://www.google.com"},
+		})
+
+		if err != nil {
+			t.Errorf("Unexpected error inserting links: %v", err)
+		}
+
+		if !db.InsertManyCalled {
+			t.Error("Expected db insert many to have been called")
+		}
+
+		if !cache.InsertManyCalled {
+			t.Error("Expected links to have been cached")
+		}
+	})
+
+	t.Run("InvalidLink", func(t *testing.T) {
+		db := &mocks.FakeLinkDao{InsertManyFn: insertMany}
+		cache := &mocks.FakeLinkDao{InsertManyFn: insertMany}
+
+		r := shortener.NewLinkRepository(db, cache)
+
+		err := r.InsertMany(context.Background(), []shortener.Link{
+			{Slug: "aaaaa", URL: "https://www.google.com"},
+			{Slug: "aaaab", URL: "invalid"},
+		})
+
+		if !errors.Is(err, shortener.ErrInvalidLink) {
+			t.Errorf("Expected ErrInvalidLink, but got: %v", err)
+		}
+
+		if db.InsertManyCalled {
+			t.Error("Expected db to not have been called")
+		}
+	})
+}
@@ -3,88 +3,428 @@ package shortener
 import (
 	"context"
 	"errors"
-	"math/rand"
+	"fmt"
+	"regexp"
 	"strings"
 	"time"
 )
 
-// slugSize represents the fixed size of a slug
+// slugSize represents the initial size of generated slugs
 const slugSize = 5
 
+// maxSlugSize is the size limit for any slug, as stored in the db
+const maxSlugSize = 32
+
+// minCustomSlugSize is the minimum size of custom slugs chosen by users
+const minCustomSlugSize = 3
+
+// customSlugRegexp restricts custom slugs to url safe characters
+var customSlugRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// builtinReservedSlugs would shadow api routes, so they're always reserved
+var builtinReservedSlugs = []string{"links", "internal"}
+
 // max attempts for trying to generate a new slug
 const maxNewSlugAttempts = 5
 
-var seededRand *rand.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
-
 // LinkService will hold the businesses logic to handle link operations
 type LinkService interface {
-	List(ctx context.Context, limit, skip int) ([]Link, error)
-	Create(ctx context.Context, URL string) (*Link, error)
-	GetURL(ctx context.Context, slug string) (string, error)
+	List(ctx context.Context, opts ListOptions) (*LinkPage, error)
+	Get(ctx context.Context, slug string) (*Link, error)
+	Create(ctx context.Context, l *Link) (*Link, error)
+	Update(ctx context.Context, u *LinkUpdate) (*Link, error)
+	Delete(ctx context.Context, slug string) error
+	// Resolve finds the link to redirect to, counting a click. Its
+	// RedirectType is always set
+	Resolve(ctx context.Context, slug string) (*Link, error)
+	// Preview finds a link that can be redirected to, without counting a click
+	Preview(ctx context.Context, slug string) (*Link, error)
 	GetNewSlug(ctx context.Context, size int) (string, error)
-	GenerateSlug(size int) string
+	Import(ctx context.Context, links []Link, dryRun bool) ([]ImportResult, error)
+	Export(ctx context.Context, fn func(l Link) error) error
 }
 
 type linkService struct {
-	repo LinkRepository
+	repo          LinkRepository
+	slugGenerator SlugGenerator
+	slugSizer     *slugSizer
+	reservedSlugs map[string]bool
+	urlPolicy     URLPolicy
+	canonical     CanonicalOptions
+	dedupeURLs    bool
+	listLimit     int
+	listMaxLimit  int
+	redirectType  RedirectType
 }
 
-// NewLinkService instantiates a LinkService, given a LinkRepository
-func NewLinkService(repo LinkRepository) LinkService {
-	return &linkService{
-		repo: repo,
-	}
+// LinkServiceOptions holds the optional settings of a LinkService
+type LinkServiceOptions struct {
+	// ReservedSlugs are words that can't be used as custom slugs
+	ReservedSlugs []string
+	// URLPolicy is what destination urls must comply with, nil allows any url
+	URLPolicy URLPolicy
+	// Canonical configures how destination urls are canonicalized
+	Canonical CanonicalOptions
+	// DedupeURLs makes Create return the existing link of the owner to the
+	// same url, instead of creating a new one
+	DedupeURLs bool
+	// ListLimit is the amount of links listed when no limit is given, and
+	// ListMaxLimit caps the limit given. Both have defaults when 0
+	ListLimit    int
+	ListMaxLimit int
+	// RedirectType is how links without one redirect, DefaultRedirectType
+	// when 0
+	RedirectType RedirectType
 }
 
-func (ls *linkService) GenerateSlug(size int) string {
-	chars := "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+// NewLinkService instantiates a LinkService, given a LinkRepository, the
+// strategy for generating slugs, where to report slug generation metrics, and
+// optional settings
+func NewLinkService(
+	repo LinkRepository,
+	slugGenerator SlugGenerator,
+	slugMetrics SlugMetrics,
+	opts LinkServiceOptions,
+) LinkService {
+	reserved := make(map[string]bool)
+	for _, s := range append(builtinReservedSlugs, opts.ReservedSlugs...) {
+		reserved[strings.ToLower(s)] = true
+	}
 
-	sb := strings.Builder{}
-	sb.Grow(size)
-	for i := 0; i < size; i++ {
-		sb.WriteByte(chars[seededRand.Intn(len(chars))])
+	listLimit := opts.ListLimit
+	if listLimit <= 0 {
+		listLimit = defaultListLimit
+	}
+
+	listMaxLimit := opts.ListMaxLimit
+	if listMaxLimit <= 0 {
+		listMaxLimit = defaultMaxLimit
+	}
+
+	redirectType := opts.RedirectType
+	if redirectType == 0 {
+		redirectType = DefaultRedirectType
+	}
+
+	return &linkService{
+		repo:          repo,
+		slugGenerator: slugGenerator,
+		slugSizer:     newSlugSizer(slugSize, slugMetrics),
+		reservedSlugs: reserved,
+		urlPolicy:     opts.URLPolicy,
+		canonical:     opts.Canonical,
+		dedupeURLs:    opts.DedupeURLs,
+		listLimit:     listLimit,
+		listMaxLimit:  listMaxLimit,
+		redirectType:  redirectType,
 	}
-	return sb.String()
 }
 
 func (ls *linkService) GetNewSlug(ctx context.Context, size int) (string, error) {
 	attempt := 0
 	for {
 		attempt++
-		slug := ls.GenerateSlug(size)
+		slug, err := ls.slugGenerator.Generate(ctx, size)
+		if err != nil {
+			return "", err
+		}
 
-		_, err := ls.repo.Find(ctx, slug)
+		_, err = ls.repo.Find(ctx, slug)
 		if errors.Is(err, ErrLinkNotFound) {
+			ls.slugSizer.observe(size, false)
 			return slug, nil
 		}
 
+		if err != nil {
+			return "", err
+		}
+
+		ls.slugSizer.observe(size, true)
 		if attempt > maxNewSlugAttempts {
 			return "", ErrLinkExists
 		}
 	}
 }
 
-func (ls *linkService) Create(ctx context.Context, URL string) (*Link, error) {
-	slug, err := ls.GetNewSlug(ctx, slugSize)
+// newGeneratedSlug gets a new slug with the current size, growing the size
+// whenever all attempts for a size collide
+func (ls *linkService) newGeneratedSlug(ctx context.Context) (string, error) {
+	for {
+		size := ls.slugSizer.current()
+
+		slug, err := ls.GetNewSlug(ctx, size)
+		if !errors.Is(err, ErrLinkExists) || size >= maxSlugSize {
+			return slug, err
+		}
+
+		ls.slugSizer.grow(size)
+	}
+}
+
+// validateCustomSlug checks if a slug chosen by the user can be used
+func (ls *linkService) validateCustomSlug(slug string) error {
+	if len(slug) < minCustomSlugSize || len(slug) > maxSlugSize {
+		return fmt.Errorf(
+			"%w: slug must have between %d and %d characters",
+			ErrInvalidSlug,
+			minCustomSlugSize,
+			maxSlugSize,
+		)
+	}
 
+	if !customSlugRegexp.MatchString(slug) {
+		return fmt.Errorf("%w: slug must contain only letters, digits, '-' or '_'", ErrInvalidSlug)
+	}
+
+	if ls.reservedSlugs[strings.ToLower(slug)] {
+		return fmt.Errorf("%w: slug '%s' is reserved", ErrInvalidSlug, slug)
+	}
+
+	return nil
+}
+
+// prepareURL canonicalizes a destination url, and returns ErrURLBlocked if it
+// doesn't comply with the url policy
+func (ls *linkService) prepareURL(rawURL string) (string, error) {
+	canonical, err := CanonicalizeURL(rawURL, ls.canonical)
+	if err != nil {
+		return "", err
+	}
+
+	if ls.urlPolicy != nil {
+		err = ls.urlPolicy.Check(canonical)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return canonical, nil
+}
+
+// findDuplicate finds an existing link that's equivalent to l, for deduping.
+// Links with a custom slug, any expiration, tags or redirect type are never
+// deduped, since their creator asked for something different from the existing
+// link
+func (ls *linkService) findDuplicate(ctx context.Context, l *Link) (*Link, error) {
+	if !ls.dedupeURLs || l.Slug != "" || l.ExpiresAt != nil || l.MaxClicks != 0 || len(l.Tags) > 0 || l.RedirectType != 0 {
+		return nil, ErrLinkNotFound
+	}
+
+	return ls.repo.FindByURL(ctx, l.Owner, l.URL)
+}
+
+func (ls *linkService) Create(ctx context.Context, l *Link) (*Link, error) {
+	if l == nil {
+		return nil, ErrInvalidLink
+	}
+
+	if l.Expired(time.Now()) {
+		return nil, fmt.Errorf("%w: Link expiration date must be in the future", ErrInvalidLink)
+	}
+
+	canonicalURL, err := ls.prepareURL(l.URL)
 	if err != nil {
 		return nil, err
 	}
 
-	return ls.repo.Insert(
-		ctx,
-		&Link{URL: URL, Slug: slug},
-	)
+	link := &Link{
+		URL:          canonicalURL,
+		Slug:         l.Slug,
+		ExpiresAt:    l.ExpiresAt,
+		MaxClicks:    l.MaxClicks,
+		Owner:        OwnerFromContext(ctx),
+		Tags:         l.Tags,
+		RedirectType: l.RedirectType,
+	}
+
+	existing, err := ls.findDuplicate(ctx, link)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, ErrLinkNotFound) {
+		return nil, err
+	}
+
+	if link.Slug != "" {
+		err = ls.validateCustomSlug(link.Slug)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		slug, err := ls.newGeneratedSlug(ctx)
+		if err != nil {
+			return nil, err
+		}
+		link.Slug = slug
+	}
+
+	return ls.repo.Insert(ctx, link)
 }
 
-func (ls *linkService) GetURL(ctx context.Context, slug string) (string, error) {
+// Update changes the link read from the db, so stale cached links are never
+// written back
+func (ls *linkService) Update(ctx context.Context, u *LinkUpdate) (*Link, error) {
+	if u == nil {
+		return nil, ErrInvalidLink
+	}
+
+	if u.ExpiresAt != nil && !time.Now().Before(*u.ExpiresAt) {
+		return nil, fmt.Errorf("%w: Link expiration date must be in the future", ErrInvalidLink)
+	}
+
+	var canonicalURL string
+	if u.URL != "" {
+		var err error
+		canonicalURL, err = ls.prepareURL(u.URL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	current, err := ls.repo.FindUncached(ctx, u.Slug)
+	if err != nil {
+		return nil, err
+	}
+	if !canAccess(ctx, current) {
+		return nil, ErrLinkNotFound
+	}
+
+	updated := *current
+	if canonicalURL != "" {
+		updated.URL = canonicalURL
+	}
+	if u.ClearExpiresAt {
+		updated.ExpiresAt = nil
+	} else if u.ExpiresAt != nil {
+		updated.ExpiresAt = u.ExpiresAt
+	}
+	if u.MaxClicks != nil {
+		updated.MaxClicks = *u.MaxClicks
+	}
+	if u.Tags != nil {
+		updated.Tags = u.Tags
+	}
+	if u.RedirectType != 0 {
+		updated.RedirectType = u.RedirectType
+	}
+
+	err = ls.repo.Update(ctx, &updated)
+	if err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// Get finds a link, links of other owners are reported as not found
+func (ls *linkService) Get(ctx context.Context, slug string) (*Link, error) {
 	l, err := ls.repo.Find(ctx, slug)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	if !canAccess(ctx, l) {
+		return nil, ErrLinkNotFound
+	}
+
+	return l, nil
+}
+
+func (ls *linkService) Delete(ctx context.Context, slug string) error {
+	if OwnerFromContext(ctx) != "" {
+		_, err := ls.Get(ctx, slug)
+		if err != nil {
+			return err
+		}
+	}
+
+	return ls.repo.Delete(ctx, slug)
+}
+
+func (ls *linkService) Resolve(ctx context.Context, slug string) (*Link, error) {
+	l, err := ls.Preview(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	// only links limited by clicks pay the price of counting them on redirect
+	if l.MaxClicks > 0 {
+		clicks, err := ls.repo.IncrementClicks(ctx, slug)
+		if err != nil {
+			return nil, err
+		}
+
+		if clicks > l.MaxClicks {
+			return nil, ErrLinkExpired
+		}
+	}
+
+	if l.RedirectType == 0 {
+		l.RedirectType = ls.redirectType
 	}
-	return l.URL, err
+
+	return l, nil
 }
 
-func (ls *linkService) List(ctx context.Context, limit, skip int) ([]Link, error) {
-	return ls.repo.List(ctx, limit, skip)
+// Preview doesn't know if a link reached its max clicks, since clicks are only
+// counted on redirect
+func (ls *linkService) Preview(ctx context.Context, slug string) (*Link, error) {
+	l, err := ls.repo.Find(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.Expired(time.Now()) {
+		return nil, ErrLinkExpired
+	}
+
+	return l, nil
+}
+
+// List returns a page of the caller links, filtered and sorted by opts. The
+// limit defaults to, and is capped by, the service list limits. An extra link
+// is fetched, to tell whether there's a next page
+func (ls *linkService) List(ctx context.Context, opts ListOptions) (*LinkPage, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = ls.listLimit
+	}
+	if opts.Limit > ls.listMaxLimit {
+		opts.Limit = ls.listMaxLimit
+	}
+
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Sort == "" {
+		opts.Sort = SortCreatedAt
+	}
+
+	// callers scoped to an owner can't see links of other owners, so asking
+	// for them finds nothing
+	if owner := OwnerFromContext(ctx); owner != "" {
+		if opts.Owner != "" && opts.Owner != owner {
+			return &LinkPage{Items: []Link{}}, nil
+		}
+		opts.Owner = owner
+	}
+
+	limit := opts.Limit
+	opts.Limit++
+
+	links, err := ls.repo.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &LinkPage{Items: links}
+	if links == nil {
+		// an empty page is still a list for clients
+		page.Items = []Link{}
+	}
+	if len(links) > limit {
+		page.Items = links[:limit]
+		page.NextCursor = EncodeListCursor(*cursorAfter(page.Items[limit-1], opts.Sort))
+	}
+	return page, nil
 }
@@ -6,27 +6,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/joao-fontenele/go-url-shortener/pkg/mocks"
 	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
 )
 
-func TestGenerateSlug(t *testing.T) {
-	s := shortener.NewLinkService(&mocks.FakeLinkRepo{})
-	slug1 := s.GenerateSlug(5)
-	if len(slug1) != 5 {
-		t.Errorf("Expected generate slug to have length 5, but got slug = %s", slug1)
-	}
-
-	slug2 := s.GenerateSlug(5)
-	if len(slug2) != 5 {
-		t.Errorf("Expected generate slug to have length 5, but got slug = %s", slug2)
-	}
-
-	if slug1 == slug2 {
-		t.Errorf("Generated slugs should have been random but got %s = %s", slug1, slug2)
-	}
-}
-
 func TestGetNewSlug(t *testing.T) {
 	t.Run("EventualSuccess", func(t *testing.T) {
 		attempts := 0
@@ -38,7 +22,7 @@ func TestGetNewSlug(t *testing.T) {
 				}
 				return nil, shortener.ErrLinkNotFound
 			},
-		})
+		}, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
 
 		slug, err := s.GetNewSlug(context.Background(), 5)
 
@@ -61,7 +45,7 @@ func TestGetNewSlug(t *testing.T) {
 				return &shortener.Link{}, nil
 			},
 		}
-		s := shortener.NewLinkService(&fakeRepo)
+		s := shortener.NewLinkService(&fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
 		_, err := s.GetNewSlug(context.Background(), 5)
 
 		if !errors.Is(err, shortener.ErrLinkExists) {
@@ -74,6 +58,21 @@ func TestGetNewSlug(t *testing.T) {
 	})
 }
 
+func TestGetNewSlugGeneratorError(t *testing.T) {
+	fakeRepo := &mocks.FakeLinkRepo{}
+	s := shortener.NewLinkService(fakeRepo, &failingSlugGenerator{}, &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+
+	_, err := s.GetNewSlug(context.Background(), 5)
+
+	if !errors.Is(err, errGenerator) {
+		t.Fatalf("Expected generator error, but got: %v", err)
+	}
+
+	if fakeRepo.FindCalled {
+		t.Errorf("Expected Find to not have been called")
+	}
+}
+
 func TestCreate(t *testing.T) {
 	t.Run("Failure", func(t *testing.T) {
 		fakeRepo := mocks.FakeLinkRepo{
@@ -82,9 +81,9 @@ func TestCreate(t *testing.T) {
 			},
 		}
 
-		s := shortener.NewLinkService(&fakeRepo)
+		s := shortener.NewLinkService(&fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
 
-		_, err := s.Create(context.Background(), "https://www.google.com")
+		_, err := s.Create(context.Background(), &shortener.Link{URL: "https://www.google.com"})
 		if err == nil {
 			t.Errorf("Expected error to not be nil, but got: %v", err)
 		}
@@ -104,9 +103,9 @@ func TestCreate(t *testing.T) {
 				return l, nil
 			},
 		}
-		s := shortener.NewLinkService(fakeRepo)
+		s := shortener.NewLinkService(fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
 
-		link, err := s.Create(context.Background(), "https://www.google.com")
+		link, err := s.Create(context.Background(), &shortener.Link{URL: "https://www.google.com"})
 
 		if err != nil {
 			t.Fatalf("Unexpected error while creating Link: %v", err)
@@ -130,7 +129,94 @@ func TestCreate(t *testing.T) {
 	})
 }
 
-func TestGetURL(t *testing.T) {
+func TestCreateExpired(t *testing.T) {
+	fakeRepo := &mocks.FakeLinkRepo{}
+	s := shortener.NewLinkService(fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+
+	expiresAt := time.Now().Add(-time.Hour)
+	_, err := s.Create(context.Background(), &shortener.Link{URL: "https://www.google.com", ExpiresAt: &expiresAt})
+
+	if !errors.Is(err, shortener.ErrInvalidLink) {
+		t.Fatalf("Expected ErrInvalidLink, but got: %v", err)
+	}
+
+	if fakeRepo.InsertCalled {
+		t.Errorf("Expected Insert to not have been called")
+	}
+}
+
+func TestCreateCustomSlug(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Slug    string
+		WantErr error
+	}{
+		{
+			Name:    "Valid",
+			Slug:    "spring-sale_2020",
+			WantErr: nil,
+		},
+		{
+			Name:    "TooShort",
+			Slug:    "ab",
+			WantErr: shortener.ErrInvalidSlug,
+		},
+		{
+			Name:    "TooLong",
+			Slug:    "abcdefghijklmnopqrstuvwxyz0123456789",
+			WantErr: shortener.ErrInvalidSlug,
+		},
+		{
+			Name:    "InvalidChars",
+			Slug:    "spring/sale",
+			WantErr: shortener.ErrInvalidSlug,
+		},
+		{
+			Name:    "BuiltinReserved",
+			Slug:    "Links",
+			WantErr: shortener.ErrInvalidSlug,
+		},
+		{
+			Name:    "ConfiguredReserved",
+			Slug:    "admin",
+			WantErr: shortener.ErrInvalidSlug,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			fakeRepo := &mocks.FakeLinkRepo{
+				InsertFn: func(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
+					return l, nil
+				},
+			}
+			s := shortener.NewLinkService(fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{ReservedSlugs: []string{"admin"}})
+
+			link, err := s.Create(context.Background(), &shortener.Link{URL: "https://www.google.com", Slug: tc.Slug})
+
+			if !errors.Is(err, tc.WantErr) {
+				t.Fatalf("Expected error to be %v, but got: %v", tc.WantErr, err)
+			}
+
+			if fakeRepo.FindCalled {
+				t.Errorf("Expected Find to not have been called for custom slugs")
+			}
+
+			if tc.WantErr != nil {
+				if fakeRepo.InsertCalled {
+					t.Errorf("Expected Insert to not have been called")
+				}
+				return
+			}
+
+			if link.Slug != tc.Slug {
+				t.Errorf("Expected Link.Slug to be %s, but got: %s", tc.Slug, link.Slug)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
 	t.Run("LinkFound", func(t *testing.T) {
 		fakeRepo := mocks.FakeLinkRepo{
 			FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
@@ -138,15 +224,15 @@ func TestGetURL(t *testing.T) {
 			},
 		}
 
-		s := shortener.NewLinkService(&fakeRepo)
-		URL, err := s.GetURL(context.Background(), "dummy")
+		s := shortener.NewLinkService(&fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+		l, err := s.Resolve(context.Background(), "dummy")
 
 		if err != nil {
-			t.Fatalf("Unexpected error from GetURL: %v", err)
+			t.Fatalf("Unexpected error from Resolve: %v", err)
 		}
 
-		if URL != "https:/www.google.com" {
-			t.Errorf("Expected URL to be 'https://www.google.com', but got: %s", URL)
+		if l.URL != "https:/www.google.com" {
+			t.Errorf("Expected URL to be 'https://www.google.com', but got: %s", l.URL)
 		}
 	})
 
@@ -157,15 +243,679 @@ func TestGetURL(t *testing.T) {
 			},
 		}
 
-		s := shortener.NewLinkService(&fakeRepo)
-		URL, err := s.GetURL(context.Background(), "dummy")
+		s := shortener.NewLinkService(&fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+		l, err := s.Resolve(context.Background(), "dummy")
 
 		if !errors.Is(err, shortener.ErrLinkNotFound) {
-			t.Fatalf("Unexpected error from GetURL: %v", err)
+			t.Fatalf("Unexpected error from Resolve: %v", err)
+		}
+
+		if l != nil {
+			t.Errorf("Expected link to be nil, but got: %v", l)
+		}
+	})
+
+	t.Run("LinkExpiredByDate", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Minute)
+		fakeRepo := mocks.FakeLinkRepo{
+			FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+				return &shortener.Link{URL: "https:/www.google.com", Slug: "dummy", ExpiresAt: &expiresAt}, nil
+			},
+		}
+
+		s := shortener.NewLinkService(&fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+		_, err := s.Resolve(context.Background(), "dummy")
+
+		if !errors.Is(err, shortener.ErrLinkExpired) {
+			t.Fatalf("Expected ErrLinkExpired, but got: %v", err)
+		}
+	})
+
+	t.Run("LinkClicksCounted", func(t *testing.T) {
+		tests := []struct {
+			Name    string
+			Clicks  int
+			WantErr error
+		}{
+			{Name: "BelowMaxClicks", Clicks: 1, WantErr: nil},
+			{Name: "LastClick", Clicks: 2, WantErr: nil},
+			{Name: "AboveMaxClicks", Clicks: 3, WantErr: shortener.ErrLinkExpired},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.Name, func(t *testing.T) {
+				fakeRepo := mocks.FakeLinkRepo{
+					FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+						return &shortener.Link{URL: "https:/www.google.com", Slug: "dummy", MaxClicks: 2}, nil
+					},
+					IncrementClicksFn: func(ctx context.Context, slug string) (int, error) {
+						return tc.Clicks, nil
+					},
+				}
+
+				s := shortener.NewLinkService(&fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+				_, err := s.Resolve(context.Background(), "dummy")
+
+				if !errors.Is(err, tc.WantErr) {
+					t.Fatalf("Expected error to be %v, but got: %v", tc.WantErr, err)
+				}
+
+				if !fakeRepo.IncrementClicksCalled {
+					t.Errorf("Expected IncrementClicks to have been called")
+				}
+			})
+		}
+	})
+
+	t.Run("UnlimitedLinkClicksNotCounted", func(t *testing.T) {
+		fakeRepo := mocks.FakeLinkRepo{
+			FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+				return &shortener.Link{URL: "https:/www.google.com", Slug: "dummy"}, nil
+			},
+		}
+
+		s := shortener.NewLinkService(&fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+		_, err := s.Resolve(context.Background(), "dummy")
+
+		if err != nil {
+			t.Fatalf("Unexpected error from Resolve: %v", err)
+		}
+
+		if fakeRepo.IncrementClicksCalled {
+			t.Errorf("Expected IncrementClicks to not have been called")
+		}
+	})
+
+	t.Run("RedirectType", func(t *testing.T) {
+		tests := []struct {
+			Name         string
+			Link         shortener.RedirectType
+			Default      shortener.RedirectType
+			WantRedirect shortener.RedirectType
+		}{
+			{Name: "DefaultNotConfigured", WantRedirect: shortener.DefaultRedirectType},
+			{Name: "ConfiguredDefault", Default: shortener.RedirectPermanent, WantRedirect: shortener.RedirectPermanent},
+			{Name: "LinkType", Link: shortener.RedirectMovedPermanently, Default: shortener.RedirectTemporary, WantRedirect: shortener.RedirectMovedPermanently},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.Name, func(t *testing.T) {
+				fakeRepo := mocks.FakeLinkRepo{
+					FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+						return &shortener.Link{URL: "https:/www.google.com", Slug: "dummy", RedirectType: tc.Link}, nil
+					},
+				}
+
+				s := shortener.NewLinkService(&fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{RedirectType: tc.Default})
+				l, err := s.Resolve(context.Background(), "dummy")
+
+				if err != nil {
+					t.Fatalf("Unexpected error from Resolve: %v", err)
+				}
+
+				if l.RedirectType != tc.WantRedirect {
+					t.Errorf("Wrong redirect type (want, got): (%d, %d)", tc.WantRedirect, l.RedirectType)
+				}
+			})
+		}
+	})
+}
+
+func TestPreview(t *testing.T) {
+	t.Run("ClicksNotCounted", func(t *testing.T) {
+		fakeRepo := mocks.FakeLinkRepo{
+			FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+				return &shortener.Link{URL: "https:/www.google.com", Slug: "dummy", MaxClicks: 2}, nil
+			},
+		}
+
+		s := shortener.NewLinkService(&fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+		l, err := s.Preview(context.Background(), "dummy")
+
+		if err != nil {
+			t.Fatalf("Unexpected error from Preview: %v", err)
+		}
+
+		if l.URL != "https:/www.google.com" {
+			t.Errorf("Expected URL to be 'https://www.google.com', but got: %s", l.URL)
+		}
+
+		if fakeRepo.IncrementClicksCalled {
+			t.Errorf("Expected IncrementClicks to not have been called")
+		}
+	})
+
+	t.Run("LinkExpiredByDate", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Minute)
+		fakeRepo := mocks.FakeLinkRepo{
+			FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+				return &shortener.Link{URL: "https:/www.google.com", Slug: "dummy", ExpiresAt: &expiresAt}, nil
+			},
+		}
+
+		s := shortener.NewLinkService(&fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+		_, err := s.Preview(context.Background(), "dummy")
+
+		if !errors.Is(err, shortener.ErrLinkExpired) {
+			t.Fatalf("Expected ErrLinkExpired, but got: %v", err)
+		}
+	})
+}
+
+func TestUpdateLink(t *testing.T) {
+	t.Run("LinkNotFound", func(t *testing.T) {
+		fakeRepo := &mocks.FakeLinkRepo{
+			FindUncachedFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+				return nil, shortener.ErrLinkNotFound
+			},
+		}
+		s := shortener.NewLinkService(fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+
+		_, err := s.Update(context.Background(), &shortener.LinkUpdate{Slug: "dummy", URL: "https://www.google.com"})
+
+		if !errors.Is(err, shortener.ErrLinkNotFound) {
+			t.Fatalf("Expected ErrLinkNotFound, but got: %v", err)
+		}
+
+		if fakeRepo.UpdateCalled {
+			t.Errorf("Expected Update to not have been called")
+		}
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		createdAt := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+		var updated *shortener.Link
+		fakeRepo := &mocks.FakeLinkRepo{
+			FindUncachedFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+				return &shortener.Link{Slug: slug, URL: "https://www.google.com", CreatedAt: createdAt}, nil
+			},
+			UpdateFn: func(ctx context.Context, l *shortener.Link) error {
+				updated = l
+				return nil
+			},
+		}
+		s := shortener.NewLinkService(fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+
+		link, err := s.Update(context.Background(), &shortener.LinkUpdate{Slug: "dummy", URL: "https://duckduckgo.com"})
+
+		if err != nil {
+			t.Fatalf("Unexpected error while updating Link: %v", err)
+		}
+
+		if !fakeRepo.UpdateCalled {
+			t.Fatalf("Expected Update to have been called, but it wasn't called")
+		}
+
+		if fakeRepo.FindCalled {
+			t.Errorf("Expected the updated link to not be read from the cache")
+		}
+
+		if updated.URL != "https://duckduckgo.com" {
+			t.Errorf("Expected updated URL to be 'https://duckduckgo.com', but got: %s", updated.URL)
+		}
+
+		if link.URL != "https://duckduckgo.com" || link.Slug != "dummy" || !link.CreatedAt.Equal(createdAt) {
+			t.Errorf("Unexpected updated link: %v", link)
+		}
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		current := &shortener.Link{Slug: "dummy", URL: "https://www.google.com", Tags: []string{"sale"}}
+		fakeRepo := &mocks.FakeLinkRepo{
+			FindUncachedFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+				l := *current
+				return &l, nil
+			},
+			UpdateFn: func(ctx context.Context, l *shortener.Link) error {
+				return nil
+			},
+		}
+		s := shortener.NewLinkService(fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+
+		maxClicks := 3
+		link, err := s.Update(context.Background(), &shortener.LinkUpdate{Slug: "dummy", MaxClicks: &maxClicks})
+		if err != nil {
+			t.Fatalf("Unexpected error while updating Link: %v", err)
+		}
+		if diff := cmp.Diff([]string{"sale"}, link.Tags); diff != "" {
+			t.Errorf("Expected tags to be kept when not given (-want +got):\n%s", diff)
+		}
+
+		link, err = s.Update(context.Background(), &shortener.LinkUpdate{Slug: "dummy", Tags: []string{}})
+		if err != nil {
+			t.Fatalf("Unexpected error while updating Link: %v", err)
+		}
+		if len(link.Tags) != 0 {
+			t.Errorf("Expected tags to be removed, but got: %v", link.Tags)
 		}
+	})
+
+	t.Run("Limits", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour)
+		current := &shortener.Link{Slug: "dummy", URL: "https://www.google.com", ExpiresAt: &expiresAt, MaxClicks: 10}
+		fakeRepo := &mocks.FakeLinkRepo{
+			FindUncachedFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+				l := *current
+				return &l, nil
+			},
+			UpdateFn: func(ctx context.Context, l *shortener.Link) error {
+				return nil
+			},
+		}
+		s := shortener.NewLinkService(fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+
+		link, err := s.Update(context.Background(), &shortener.LinkUpdate{Slug: "dummy", URL: "https://duckduckgo.com"})
+		if err != nil {
+			t.Fatalf("Unexpected error while updating Link: %v", err)
+		}
+		if link.ExpiresAt == nil || link.MaxClicks != 10 {
+			t.Errorf("Expected limits to be kept when not given, but got: (%v, %d)", link.ExpiresAt, link.MaxClicks)
+		}
+
+		noLimit := 0
+		link, err = s.Update(context.Background(), &shortener.LinkUpdate{Slug: "dummy", ClearExpiresAt: true, MaxClicks: &noLimit})
+		if err != nil {
+			t.Fatalf("Unexpected error while updating Link: %v", err)
+		}
+		if link.ExpiresAt != nil || link.MaxClicks != 0 {
+			t.Errorf("Expected limits to be removed, but got: (%v, %d)", link.ExpiresAt, link.MaxClicks)
+		}
+
+		fakeRepo.UpdateCalled = false
+		past := time.Now().Add(-time.Hour)
+		_, err = s.Update(context.Background(), &shortener.LinkUpdate{Slug: "dummy", ExpiresAt: &past})
+		if !errors.Is(err, shortener.ErrInvalidLink) {
+			t.Errorf("Expected ErrInvalidLink for an expiration in the past, but got: %v", err)
+		}
+		if fakeRepo.UpdateCalled {
+			t.Errorf("Expected Update to not have been called")
+		}
+	})
+}
+
+func TestGetLink(t *testing.T) {
+	link := &shortener.Link{Slug: "dummy", URL: "https://google.com"}
+	fakeRepo := &mocks.FakeLinkRepo{
+		FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+			if slug == "dummy" {
+				return link, nil
+			}
+			return nil, shortener.ErrLinkNotFound
+		},
+	}
+	s := shortener.NewLinkService(fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+
+	got, err := s.Get(context.Background(), "dummy")
+	if err != nil {
+		t.Fatalf("Unexpected error getting link: %v", err)
+	}
+	if diff := cmp.Diff(link, got); diff != "" {
+		t.Errorf("Get() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := s.Get(context.Background(), "other"); !errors.Is(err, shortener.ErrLinkNotFound) {
+		t.Errorf("Expected ErrLinkNotFound, but got: %v", err)
+	}
+}
+
+func TestDeleteLink(t *testing.T) {
+	fakeRepo := &mocks.FakeLinkRepo{
+		DeleteFn: func(ctx context.Context, slug string) error {
+			if slug == "dummy" {
+				return nil
+			}
+			return shortener.ErrLinkNotFound
+		},
+	}
+	s := shortener.NewLinkService(fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+
+	if err := s.Delete(context.Background(), "dummy"); err != nil {
+		t.Errorf("Unexpected error deleting link: %v", err)
+	}
+
+	if err := s.Delete(context.Background(), "other"); !errors.Is(err, shortener.ErrLinkNotFound) {
+		t.Errorf("Expected ErrLinkNotFound, but got: %v", err)
+	}
+}
+
+func TestGetNewSlugRepoError(t *testing.T) {
+	unexpectedErr := errors.New("UnexpectedError")
+	fakeRepo := &mocks.FakeLinkRepo{
+		FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+			return nil, unexpectedErr
+		},
+	}
+	s := shortener.NewLinkService(fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+
+	_, err := s.GetNewSlug(context.Background(), 5)
+
+	if !errors.Is(err, unexpectedErr) {
+		t.Fatalf("Expected repository error, but got: %v", err)
+	}
+}
+
+func TestCreateSlugGrowth(t *testing.T) {
+	insert := func(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
+		return l, nil
+	}
+
+	t.Run("SizeSaturated", func(t *testing.T) {
+		fakeRepo := &mocks.FakeLinkRepo{
+			FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+				if len(slug) == 5 {
+					return &shortener.Link{}, nil
+				}
+				return nil, shortener.ErrLinkNotFound
+			},
+			InsertFn: insert,
+		}
+		slugMetrics := &mocks.FakeSlugMetrics{}
+		s := shortener.NewLinkService(fakeRepo, shortener.NewRandomSlugGenerator(), slugMetrics, shortener.LinkServiceOptions{})
+
+		link, err := s.Create(context.Background(), &shortener.Link{URL: "https://www.google.com"})
+
+		if err != nil {
+			t.Fatalf("Unexpected error while creating Link: %v", err)
+		}
+
+		if len(link.Slug) != 6 {
+			t.Errorf("Expected Link.Slug to have grown to len = 6, but got: %s", link.Slug)
+		}
+
+		if diff := cmp.Diff([]int{5, 6}, slugMetrics.Sizes); diff != "" {
+			t.Errorf("Unexpected reported slug sizes (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("HighCollisionRate", func(t *testing.T) {
+		attempts := 0
+		fakeRepo := &mocks.FakeLinkRepo{
+			FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+				attempts++
+				// 3 out of 4 slugs with size 5 collide
+				if len(slug) == 5 && attempts%4 != 0 {
+					return &shortener.Link{}, nil
+				}
+				return nil, shortener.ErrLinkNotFound
+			},
+			InsertFn: insert,
+		}
+		s := shortener.NewLinkService(fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+
+		for i := 0; i < 50; i++ {
+			link, err := s.Create(context.Background(), &shortener.Link{URL: "https://www.google.com"})
+			if err != nil {
+				t.Fatalf("Unexpected error while creating Link: %v", err)
+			}
+
+			if len(link.Slug) == 6 {
+				return
+			}
+		}
+
+		t.Error("Expected slug size to grow after frequent collisions")
+	})
+}
 
-		if URL != "" {
-			t.Errorf("Expected URL to be '', but got: %s", URL)
+func TestOwnership(t *testing.T) {
+	aliceCtx := context.WithValue(context.Background(), shortener.OwnerKey, "alice")
+	bobCtx := context.WithValue(context.Background(), shortener.OwnerKey, "bob")
+
+	var inserted *shortener.Link
+	var listedOwner string
+	fakeRepo := &mocks.FakeLinkRepo{
+		FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+			if slug == "alices" {
+				return &shortener.Link{Slug: slug, URL: "https://www.google.com", Owner: "alice"}, nil
+			}
+			return nil, shortener.ErrLinkNotFound
+		},
+		InsertFn: func(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
+			inserted = l
+			return l, nil
+		},
+		UpdateFn: func(ctx context.Context, l *shortener.Link) error {
+			return nil
+		},
+		DeleteFn: func(ctx context.Context, slug string) error {
+			return nil
+		},
+		ListFn: func(ctx context.Context, opts shortener.ListOptions) ([]shortener.Link, error) {
+			listedOwner = opts.Owner
+			return []shortener.Link{}, nil
+		},
+	}
+	fakeRepo.FindUncachedFn = fakeRepo.FindFn
+	s := shortener.NewLinkService(fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{})
+
+	t.Run("CreateSetsOwner", func(t *testing.T) {
+		_, err := s.Create(aliceCtx, &shortener.Link{URL: "https://www.google.com", Owner: "bob"})
+		if err != nil {
+			t.Fatalf("Unexpected error creating link: %v", err)
+		}
+
+		if inserted.Owner != "alice" {
+			t.Errorf("Wrong link owner (want, got): (alice, %s)", inserted.Owner)
+		}
+	})
+
+	t.Run("ListScopedToOwner", func(t *testing.T) {
+		_, err := s.List(aliceCtx, shortener.ListOptions{Limit: 10})
+		if err != nil {
+			t.Fatalf("Unexpected error listing links: %v", err)
+		}
+
+		if listedOwner != "alice" {
+			t.Errorf("Wrong listed owner (want, got): (alice, %s)", listedOwner)
+		}
+	})
+
+	t.Run("ListOtherOwnerEmpty", func(t *testing.T) {
+		listedOwner = ""
+		page, err := s.List(aliceCtx, shortener.ListOptions{Owner: "bob"})
+		if err != nil {
+			t.Fatalf("Unexpected error listing links: %v", err)
+		}
+
+		if len(page.Items) != 0 || listedOwner != "" {
+			t.Errorf("Expected no links of other owners to be listed, but got: %v", page.Items)
+		}
+	})
+
+	t.Run("OwnerAccess", func(t *testing.T) {
+		if _, err := s.Get(aliceCtx, "alices"); err != nil {
+			t.Errorf("Unexpected error getting own link: %v", err)
+		}
+
+		if _, err := s.Update(aliceCtx, &shortener.LinkUpdate{Slug: "alices", Tags: []string{"sale"}}); err != nil {
+			t.Errorf("Unexpected error updating own link: %v", err)
+		}
+
+		if err := s.Delete(aliceCtx, "alices"); err != nil {
+			t.Errorf("Unexpected error deleting own link: %v", err)
+		}
+	})
+
+	t.Run("OtherOwnerNotFound", func(t *testing.T) {
+		fakeRepo.DeleteCalled = false
+		fakeRepo.UpdateCalled = false
+
+		if _, err := s.Get(bobCtx, "alices"); !errors.Is(err, shortener.ErrLinkNotFound) {
+			t.Errorf("Expected ErrLinkNotFound getting other owner link, but got: %v", err)
+		}
+
+		if _, err := s.Update(bobCtx, &shortener.LinkUpdate{Slug: "alices", Tags: []string{"sale"}}); !errors.Is(err, shortener.ErrLinkNotFound) {
+			t.Errorf("Expected ErrLinkNotFound updating other owner link, but got: %v", err)
+		}
+
+		if err := s.Delete(bobCtx, "alices"); !errors.Is(err, shortener.ErrLinkNotFound) {
+			t.Errorf("Expected ErrLinkNotFound deleting other owner link, but got: %v", err)
+		}
+
+		if fakeRepo.UpdateCalled || fakeRepo.DeleteCalled {
+			t.Error("Expected other owner link to not be changed")
+		}
+	})
+
+	t.Run("RedirectIsPublic", func(t *testing.T) {
+		if _, err := s.Resolve(bobCtx, "alices"); err != nil {
+			t.Errorf("Unexpected error getting url of other owner link: %v", err)
+		}
+	})
+}
+
+func TestURLPolicy(t *testing.T) {
+	fakeRepo := &mocks.FakeLinkRepo{
+		FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+			if slug == "found" {
+				return &shortener.Link{Slug: slug, URL: "https://www.google.com"}, nil
+			}
+			return nil, shortener.ErrLinkNotFound
+		},
+		ExistingSlugsFn: func(ctx context.Context, slugs []string) ([]string, error) {
+			return []string{}, nil
+		},
+		InsertFn: func(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
+			return l, nil
+		},
+		InsertManyFn: func(ctx context.Context, links []shortener.Link) error {
+			return nil
+		},
+	}
+	policy := shortener.NewURLPolicy(shortener.URLPolicyOptions{BlockedDomains: []string{"evil.com"}})
+	s := shortener.NewLinkService(fakeRepo, shortener.NewRandomSlugGenerator(), &mocks.FakeSlugMetrics{}, shortener.LinkServiceOptions{URLPolicy: policy})
+	ctx := context.Background()
+
+	if _, err := s.Create(ctx, &shortener.Link{URL: "https://evil.com"}); !errors.Is(err, shortener.ErrURLBlocked) {
+		t.Errorf("Expected blocked url to not be created, but got: %v", err)
+	}
+
+	if fakeRepo.InsertCalled {
+		t.Error("Expected blocked url to not be inserted")
+	}
+
+	if _, err := s.Update(ctx, &shortener.LinkUpdate{Slug: "found", URL: "https://evil.com"}); !errors.Is(err, shortener.ErrURLBlocked) {
+		t.Errorf("Expected link to not be updated to a blocked url, but got: %v", err)
+	}
+
+	results, err := s.Import(ctx, []shortener.Link{{URL: "https://evil.com"}, {URL: "https://www.google.com"}}, false)
+	if err != nil {
+		t.Fatalf("Unexpected error importing links: %v", err)
+	}
+
+	if !errors.Is(results[0].Err, shortener.ErrURLBlocked) || results[1].Err != nil {
+		t.Errorf("Expected only the blocked url to fail, but got: (%v, %v)", results[0].Err, results[1].Err)
+	}
+}
+
+func TestCreateDedupe(t *testing.T) {
+	aliceCtx := context.WithValue(context.Background(), shortener.OwnerKey, "alice")
+	existing := &shortener.Link{Slug: "exist", URL: "https://example.com/a?a=2&b=1", Owner: "alice"}
+
+	newRepo := func() *mocks.FakeLinkRepo {
+		return &mocks.FakeLinkRepo{
+			FindFn: func(ctx context.Context, slug string) (*shortener.Link, error) {
+				return nil, shortener.ErrLinkNotFound
+			},
+			FindByURLFn: func(ctx context.Context, owner, url string) (*shortener.Link, error) {
+				if owner == existing.Owner && url == existing.URL {
+					return existing, nil
+				}
+				return nil, shortener.ErrLinkNotFound
+			},
+			InsertFn: func(ctx context.Context, l *shortener.Link) (*shortener.Link, error) {
+				return l, nil
+			},
+		}
+	}
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		Name         string
+		Dedupe       bool
+		Ctx          context.Context
+		Link         *shortener.Link
+		WantExisting bool
+	}{
+		{
+			Name:         "SameCanonicalURL",
+			Dedupe:       true,
+			Ctx:          aliceCtx,
+			Link:         &shortener.Link{URL: "https://Example.com:443/a?b=1&a=2"},
+			WantExisting: true,
+		},
+		{
+			Name:   "Disabled",
+			Dedupe: false,
+			Ctx:    aliceCtx,
+			Link:   &shortener.Link{URL: "https://example.com/a?a=2&b=1"},
+		},
+		{
+			Name:   "OtherOwner",
+			Dedupe: true,
+			Ctx:    context.WithValue(context.Background(), shortener.OwnerKey, "bob"),
+			Link:   &shortener.Link{URL: "https://example.com/a?a=2&b=1"},
+		},
+		{
+			Name:   "CustomSlug",
+			Dedupe: true,
+			Ctx:    aliceCtx,
+			Link:   &shortener.Link{URL: "https://example.com/a?a=2&b=1", Slug: "mine"},
+		},
+		{
+			Name:   "Expiring",
+			Dedupe: true,
+			Ctx:    aliceCtx,
+			Link:   &shortener.Link{URL: "https://example.com/a?a=2&b=1", ExpiresAt: &future},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := newRepo()
+			s := shortener.NewLinkService(
+				repo,
+				shortener.NewRandomSlugGenerator(),
+				&mocks.FakeSlugMetrics{},
+				shortener.LinkServiceOptions{DedupeURLs: tc.Dedupe},
+			)
+
+			got, err := s.Create(tc.Ctx, tc.Link)
+			if err != nil {
+				t.Fatalf("Unexpected error creating link: %v", err)
+			}
+
+			if tc.WantExisting {
+				if got != existing || repo.InsertCalled {
+					t.Errorf("Expected existing link to be returned, but got: %v", got)
+				}
+				return
+			}
+
+			if !repo.InsertCalled {
+				t.Error("Expected a new link to be inserted")
+			}
+
+			if got.URL != existing.URL {
+				t.Errorf("Expected canonical url to be saved (want, got): (%s, %s)", existing.URL, got.URL)
+			}
+		})
+	}
+
+	t.Run("RepoError", func(t *testing.T) {
+		repo := newRepo()
+		repo.FindByURLFn = func(ctx context.Context, owner, url string) (*shortener.Link, error) {
+			return nil, errors.New("UnexpectedError")
+		}
+		s := shortener.NewLinkService(
+			repo,
+			shortener.NewRandomSlugGenerator(),
+			&mocks.FakeSlugMetrics{},
+			shortener.LinkServiceOptions{DedupeURLs: true},
+		)
+
+		if _, err := s.Create(aliceCtx, &shortener.Link{URL: "https://example.com"}); err == nil {
+			t.Error("Expected repository error to be returned")
 		}
 	})
 }
@@ -0,0 +1,48 @@
+package shortener
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// findTimeout bounds a db lookup shared by concurrent Finds of the same slug
+const findTimeout = 2 * time.Second
+
+// findGroup coalesces concurrent lookups of the same slug, so a burst of
+// requests for a link that isn't cached results in a single db query
+type findGroup struct {
+	group singleflight.Group
+}
+
+// do calls fn, unless there's already a call in progress for slug, in which
+// case it waits for that call and shares its result. fn gets a context of its
+// own, so a caller giving up on ctx doesn't fail the others waiting for it.
+// Each caller gets its own copy of the link
+func (g *findGroup) do(ctx context.Context, slug string, fn func(ctx context.Context) (*Link, error)) (*Link, error) {
+	results := g.group.DoChan(slug, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), findTimeout)
+		defer cancel()
+
+		return fn(ctx)
+	})
+
+	var res singleflight.Result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res = <-results:
+	}
+	if res.Err != nil {
+		return nil, res.Err
+	}
+
+	link := *res.Val.(*Link)
+	if link.ExpiresAt != nil {
+		expiresAt := *link.ExpiresAt
+		link.ExpiresAt = &expiresAt
+	}
+	link.Tags = append([]string(nil), link.Tags...)
+	return &link, nil
+}
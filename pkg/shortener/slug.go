@@ -0,0 +1,122 @@
+package shortener
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	mathrand "math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// slugChars is the alphabet of random slugs, it lacks easily confused chars
+const slugChars = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// base58Chars is the bitcoin base58 alphabet, used by counter based slugs
+const base58Chars = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// SlugGenerator represents a strategy for generating new slugs
+type SlugGenerator interface {
+	Generate(ctx context.Context, size int) (string, error)
+}
+
+// SlugCounter represents a source of unique, increasing numbers
+type SlugCounter interface {
+	Next(ctx context.Context) (uint64, error)
+}
+
+type randomSlugGenerator struct {
+	// mu guards rand, which isn't safe for concurrent use
+	mu   sync.Mutex
+	rand *mathrand.Rand
+}
+
+// NewRandomSlugGenerator instantiates a SlugGenerator backed by math/rand. It's
+// fast, but its output is predictable
+func NewRandomSlugGenerator() SlugGenerator {
+	return &randomSlugGenerator{
+		rand: mathrand.New(mathrand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (g *randomSlugGenerator) Generate(ctx context.Context, size int) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	sb := strings.Builder{}
+	sb.Grow(size)
+	for i := 0; i < size; i++ {
+		sb.WriteByte(slugChars[g.rand.Intn(len(slugChars))])
+	}
+	return sb.String(), nil
+}
+
+type cryptoSlugGenerator struct{}
+
+// NewCryptoSlugGenerator instantiates a SlugGenerator backed by crypto/rand,
+// for slugs that can't be guessed
+func NewCryptoSlugGenerator() SlugGenerator {
+	return &cryptoSlugGenerator{}
+}
+
+func (g *cryptoSlugGenerator) Generate(ctx context.Context, size int) (string, error) {
+	max := big.NewInt(int64(len(slugChars)))
+
+	sb := strings.Builder{}
+	sb.Grow(size)
+	for i := 0; i < size; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(slugChars[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+type counterSlugGenerator struct {
+	counter SlugCounter
+}
+
+// NewCounterSlugGenerator instantiates a SlugGenerator that base58 encodes
+// numbers from a counter, so generated slugs never collide with each other.
+// Slugs are sequential, thus easy to enumerate
+func NewCounterSlugGenerator(counter SlugCounter) SlugGenerator {
+	return &counterSlugGenerator{
+		counter: counter,
+	}
+}
+
+func (g *counterSlugGenerator) Generate(ctx context.Context, size int) (string, error) {
+	n, err := g.counter.Next(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return EncodeBase58(n, size), nil
+}
+
+// EncodeBase58 encodes n in base58, left padded with the zero digit until it
+// has at least minSize chars. Padding keeps the encoding unique, since unpadded
+// encodings never start with the zero digit
+func EncodeBase58(n uint64, minSize int) string {
+	base := uint64(len(base58Chars))
+
+	encoded := []byte{}
+	for n > 0 {
+		encoded = append(encoded, base58Chars[n%base])
+		n /= base
+	}
+
+	for len(encoded) < minSize {
+		encoded = append(encoded, base58Chars[0])
+	}
+
+	// digits were appended from the least significant one
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+
+	return string(encoded)
+}
@@ -0,0 +1,127 @@
+package shortener_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+var errGenerator = errors.New("generator failed")
+
+type failingSlugGenerator struct{}
+
+func (g *failingSlugGenerator) Generate(ctx context.Context, size int) (string, error) {
+	return "", errGenerator
+}
+
+type fakeCounter struct {
+	n uint64
+}
+
+func (c *fakeCounter) Next(ctx context.Context) (uint64, error) {
+	c.n++
+	return c.n, nil
+}
+
+func TestRandomGenerators(t *testing.T) {
+	alphabet := "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+	generators := []struct {
+		Name      string
+		Generator shortener.SlugGenerator
+	}{
+		{Name: "Random", Generator: shortener.NewRandomSlugGenerator()},
+		{Name: "Crypto", Generator: shortener.NewCryptoSlugGenerator()},
+	}
+
+	for _, tc := range generators {
+		t.Run(tc.Name, func(t *testing.T) {
+			slug1, err := tc.Generator.Generate(context.Background(), 5)
+			if err != nil {
+				t.Fatalf("Unexpected error generating slug: %v", err)
+			}
+
+			slug2, err := tc.Generator.Generate(context.Background(), 5)
+			if err != nil {
+				t.Fatalf("Unexpected error generating slug: %v", err)
+			}
+
+			for _, slug := range []string{slug1, slug2} {
+				if len(slug) != 5 {
+					t.Errorf("Expected generated slug to have length 5, but got slug = %s", slug)
+				}
+
+				for _, c := range slug {
+					if !strings.ContainsRune(alphabet, c) {
+						t.Errorf("Generated slug %s has char %c outside of the alphabet", slug, c)
+					}
+				}
+			}
+
+			if slug1 == slug2 {
+				t.Errorf("Generated slugs should have been random but got %s = %s", slug1, slug2)
+			}
+		})
+	}
+}
+
+// TestRandomGeneratorConcurrency is meant to be run with -race
+func TestRandomGeneratorConcurrency(t *testing.T) {
+	g := shortener.NewRandomSlugGenerator()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := g.Generate(context.Background(), 5); err != nil {
+					t.Errorf("Unexpected error generating slug: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCounterSlugGenerator(t *testing.T) {
+	g := shortener.NewCounterSlugGenerator(&fakeCounter{n: 56})
+
+	want := []string{"1111z", "11121"}
+	for _, w := range want {
+		got, err := g.Generate(context.Background(), 5)
+		if err != nil {
+			t.Fatalf("Unexpected error generating slug: %v", err)
+		}
+
+		if got != w {
+			t.Errorf("Wrong slug generated (want, got): (%s, %s)", w, got)
+		}
+	}
+}
+
+func TestEncodeBase58(t *testing.T) {
+	tests := []struct {
+		N       uint64
+		MinSize int
+		Want    string
+	}{
+		{N: 0, MinSize: 0, Want: ""},
+		{N: 0, MinSize: 3, Want: "111"},
+		{N: 57, MinSize: 1, Want: "z"},
+		{N: 58, MinSize: 1, Want: "21"},
+		{N: 58, MinSize: 5, Want: "11121"},
+		{N: 656356767, MinSize: 5, Want: "zzzzz"},
+		{N: 656356768, MinSize: 5, Want: "211111"},
+	}
+
+	for _, tc := range tests {
+		if got := shortener.EncodeBase58(tc.N, tc.MinSize); got != tc.Want {
+			t.Errorf("EncodeBase58(%d, %d) (want, got): (%s, %s)", tc.N, tc.MinSize, tc.Want, got)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package shortener
+
+import (
+	"sync"
+)
+
+// tuning of the slug size growth
+const (
+	// collisionRateWeight is how much the latest attempt weighs in the moving
+	// collision rate
+	collisionRateWeight = 0.05
+
+	// maxCollisionRate is the moving collision rate that makes slugs grow
+	maxCollisionRate = 0.5
+)
+
+// SlugMetrics receives slug generation events, so they can be instrumented
+type SlugMetrics interface {
+	ObserveAttempt(size int, collided bool)
+	SetCollisionRate(rate float64)
+	SetSize(size int)
+}
+
+// slugSizer keeps track of the size of generated slugs. The size grows when
+// generated slugs collide too often, meaning the keyspace is getting full.
+// The size isn't persisted, after a restart it grows back after a few collisions
+type slugSizer struct {
+	mu            sync.Mutex
+	size          int
+	collisionRate float64
+	metrics       SlugMetrics
+}
+
+func newSlugSizer(size int, metrics SlugMetrics) *slugSizer {
+	metrics.SetSize(size)
+	return &slugSizer{
+		size:    size,
+		metrics: metrics,
+	}
+}
+
+func (s *slugSizer) current() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+// observe records the result of a slug generation attempt with the given size
+func (s *slugSizer) observe(size int, collided bool) {
+	s.metrics.ObserveAttempt(size, collided)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// attempts started before a growth don't say anything about the new size
+	if size != s.size {
+		return
+	}
+
+	sample := 0.0
+	if collided {
+		sample = 1
+	}
+	s.collisionRate = (1-collisionRateWeight)*s.collisionRate + collisionRateWeight*sample
+	s.metrics.SetCollisionRate(s.collisionRate)
+
+	if s.collisionRate > maxCollisionRate {
+		s.growLocked()
+	}
+}
+
+// grow moves on to the next size, unless the size already grew past from
+func (s *slugSizer) grow(from int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if from == s.size {
+		s.growLocked()
+	}
+}
+
+func (s *slugSizer) growLocked() {
+	if s.size >= maxSlugSize {
+		return
+	}
+
+	s.size++
+	s.collisionRate = 0
+	s.metrics.SetSize(s.size)
+	s.metrics.SetCollisionRate(s.collisionRate)
+}
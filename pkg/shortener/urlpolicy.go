@@ -0,0 +1,293 @@
+package shortener
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultAllowedSchemes are used when a policy doesn't set its own schemes
+var defaultAllowedSchemes = []string{"http", "https"}
+
+// privateNetworks are address ranges that aren't reachable from the internet,
+// linking to them could expose internal services. Loopback, link local and
+// unspecified addresses are checked with net.IP methods
+var privateNetworks = mustParseCIDRs(
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"100.64.0.0/10",
+	"fc00::/7",
+)
+
+// resolveTimeout bounds the lookup of a destination host, when policies resolve
+// hosts
+const resolveTimeout = 2 * time.Second
+
+// URLPolicy decides if a destination URL may be shortened, by its scheme and
+// host only, so the result holds for other urls with the same scheme and host
+type URLPolicy interface {
+	Check(rawURL string) error
+}
+
+// DomainList tells if a host belongs to a list of domains
+type DomainList interface {
+	Contains(host string) bool
+}
+
+// Resolver looks up the addresses of a host, *net.Resolver implements it
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// URLPolicyOptions configures a URLPolicy. Domains also match their subdomains
+type URLPolicyOptions struct {
+	// AllowedSchemes defaults to http and https
+	AllowedSchemes []string
+	// AllowedDomains, when not empty, are the only domains links may point to
+	AllowedDomains []string
+	// BlockedDomains can't be linked to
+	BlockedDomains []string
+	// Blocklist is checked in addition to BlockedDomains, e.g. a FileBlocklist
+	Blocklist DomainList
+	// SelfDomains are where the shortener is served, linking to them would loop
+	SelfDomains []string
+	// AllowPrivateIPs allows loopback, private and link local addresses
+	AllowPrivateIPs bool
+	// Resolver, when set, looks hostnames up, and links to hosts with any
+	// private address are blocked. Hosts that can't be resolved are allowed
+	Resolver Resolver
+}
+
+type urlPolicy struct {
+	schemes         map[string]bool
+	allowedDomains  domainSet
+	blockedDomains  domainSet
+	blocklist       DomainList
+	selfDomains     domainSet
+	allowPrivateIPs bool
+	resolver        Resolver
+}
+
+// NewURLPolicy instantiates a URLPolicy with the given options
+func NewURLPolicy(opts URLPolicyOptions) URLPolicy {
+	schemes := opts.AllowedSchemes
+	if len(schemes) == 0 {
+		schemes = defaultAllowedSchemes
+	}
+
+	p := &urlPolicy{
+		schemes:         make(map[string]bool),
+		allowedDomains:  newDomainSet(opts.AllowedDomains),
+		blockedDomains:  newDomainSet(opts.BlockedDomains),
+		blocklist:       opts.Blocklist,
+		selfDomains:     newDomainSet(opts.SelfDomains),
+		allowPrivateIPs: opts.AllowPrivateIPs,
+		resolver:        opts.Resolver,
+	}
+	for _, s := range schemes {
+		p.schemes[strings.ToLower(s)] = true
+	}
+
+	return p
+}
+
+// Check returns ErrURLBlocked if the url isn't allowed by the policy, or
+// ErrInvalidLink if it can't be parsed
+func (p *urlPolicy) Check(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: Parsing Link.URL generated error", ErrInvalidLink)
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if scheme == "" {
+		return fmt.Errorf("%w: Link URL is malformed", ErrInvalidLink)
+	}
+
+	if !p.schemes[scheme] {
+		return fmt.Errorf("%w: scheme '%s' is not allowed", ErrURLBlocked, scheme)
+	}
+
+	host := normalizeDomain(u.Hostname())
+	if host == "" {
+		return fmt.Errorf("%w: Link URL is malformed", ErrInvalidLink)
+	}
+
+	if p.selfDomains.Contains(host) {
+		return fmt.Errorf("%w: links to this shortener would loop", ErrURLBlocked)
+	}
+
+	if !p.allowPrivateIPs && p.isPrivateHost(host) {
+		return fmt.Errorf("%w: private and loopback addresses are not allowed", ErrURLBlocked)
+	}
+
+	if len(p.allowedDomains) > 0 && !p.allowedDomains.Contains(host) {
+		return fmt.Errorf("%w: domain '%s' is not allowed", ErrURLBlocked, host)
+	}
+
+	if p.blockedDomains.Contains(host) || (p.blocklist != nil && p.blocklist.Contains(host)) {
+		return fmt.Errorf("%w: domain '%s' is blocked", ErrURLBlocked, host)
+	}
+
+	return nil
+}
+
+// isPrivateHost tells if host is an address that isn't reachable from the
+// internet, or a hostname resolving to any such address. Hostnames are only
+// resolved by policies with a resolver, except for localhost
+func (p *urlPolicy) isPrivateHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+
+	ip := parseHostIP(host)
+	if ip != nil {
+		return isPrivateIP(ip)
+	}
+
+	if p.resolver == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+	addrs, err := p.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return false
+	}
+
+	for _, addr := range addrs {
+		if isPrivateIP(addr.IP) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseHostIP parses host as an IPv6 address, or as an IPv4 address in any of
+// the forms inet_aton accepts, which browsers and resolvers accept too. Like
+// 2130706433, 127.1, 0x7f000001 or 0177.0.0.1. Returns nil for hostnames
+func parseHostIP(host string) net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip
+	}
+
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return nil
+	}
+
+	values := make([]uint64, len(parts))
+	for i, part := range parts {
+		base := 10
+		if len(part) > 2 && (part[:2] == "0x" || part[:2] == "0X") {
+			base, part = 16, part[2:]
+		} else if len(part) > 1 && part[0] == '0' {
+			base, part = 8, part[1:]
+		}
+
+		v, err := strconv.ParseUint(part, base, 32)
+		if err != nil {
+			return nil
+		}
+		values[i] = v
+	}
+
+	// every part but the last is a byte, the last fills the remaining bytes
+	var addr uint64
+	for _, v := range values[:len(values)-1] {
+		if v > 0xff {
+			return nil
+		}
+		addr = addr<<8 | v
+	}
+	last := values[len(values)-1]
+	remaining := uint(4-len(values)+1) * 8
+	if last >= 1<<remaining {
+		return nil
+	}
+	addr = addr<<remaining | last
+
+	return net.IPv4(byte(addr>>24), byte(addr>>16), byte(addr>>8), byte(addr))
+}
+
+// isPrivateIP tells if ip isn't reachable from the internet
+func isPrivateIP(ip net.IP) bool {
+	if ip.IsLoopback() ||
+		ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() {
+		return true
+	}
+
+	for _, n := range privateNetworks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = n
+	}
+	return networks
+}
+
+// domainSet matches domains, and their subdomains
+type domainSet map[string]bool
+
+func newDomainSet(domains []string) domainSet {
+	s := make(domainSet)
+	for _, d := range domains {
+		d = normalizeDomain(d)
+		if d != "" {
+			s[d] = true
+		}
+	}
+	return s
+}
+
+// Contains checks host and each of its parent domains against the set
+func (s domainSet) Contains(host string) bool {
+	if len(s) == 0 {
+		return false
+	}
+
+	host = normalizeDomain(host)
+	for host != "" {
+		if s[host] {
+			return true
+		}
+
+		i := strings.IndexByte(host, '.')
+		if i < 0 {
+			return false
+		}
+		host = host[i+1:]
+	}
+
+	return false
+}
+
+// normalizeDomain lowercases a domain, accepting wildcards like *.example.com
+// and leading or trailing dots
+func normalizeDomain(d string) string {
+	d = strings.ToLower(strings.TrimSpace(d))
+	d = strings.TrimPrefix(d, "*.")
+	return strings.Trim(d, ".")
+}
@@ -0,0 +1,149 @@
+package shortener_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/joao-fontenele/go-url-shortener/pkg/shortener"
+)
+
+type fakeDomainList map[string]bool
+
+func (f fakeDomainList) Contains(host string) bool {
+	return f[host]
+}
+
+type fakeResolver map[string][]string
+
+func (f fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := f[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	addrs := make([]net.IPAddr, len(ips))
+	for i, ip := range ips {
+		addrs[i] = net.IPAddr{IP: net.ParseIP(ip)}
+	}
+	return addrs, nil
+}
+
+func TestURLPolicyCheck(t *testing.T) {
+	policy := shortener.NewURLPolicy(shortener.URLPolicyOptions{
+		BlockedDomains: []string{"evil.com", "*.tk"},
+		Blocklist:      fakeDomainList{"malware.net": true},
+		SelfDomains:    []string{"sho.rt"},
+	})
+
+	tests := []struct {
+		Name    string
+		URL     string
+		WantErr error
+	}{
+		{Name: "Allowed", URL: "https://www.google.com/search?q=golang"},
+		{Name: "AllowedPublicIP", URL: "http://8.8.8.8/"},
+		{Name: "UppercaseScheme", URL: "HTTPS://www.google.com"},
+		{Name: "JavascriptScheme", URL: "javascript:alert(1)", WantErr: shortener.ErrURLBlocked},
+		{Name: "FileScheme", URL: "file:///etc/passwd", WantErr: shortener.ErrURLBlocked},
+		{Name: "DataScheme", URL: "data:text/html,<script>alert(1)</script>", WantErr: shortener.ErrURLBlocked},
+		{Name: "NoHost", URL: "https://", WantErr: shortener.ErrInvalidLink},
+		{Name: "BlockedDomain", URL: "https://evil.com/path", WantErr: shortener.ErrURLBlocked},
+		{Name: "BlockedSubdomain", URL: "https://www.EVIL.com./path", WantErr: shortener.ErrURLBlocked},
+		{Name: "NotBlockedLookalike", URL: "https://notevil.com"},
+		{Name: "BlockedSuffix", URL: "https://free.tk", WantErr: shortener.ErrURLBlocked},
+		{Name: "BlocklistDomain", URL: "https://malware.net", WantErr: shortener.ErrURLBlocked},
+		{Name: "SelfDomain", URL: "https://sho.rt/aaaaa", WantErr: shortener.ErrURLBlocked},
+		{Name: "SelfDomainWithPort", URL: "https://sho.rt:443/aaaaa", WantErr: shortener.ErrURLBlocked},
+		{Name: "Loopback", URL: "http://127.0.0.1:8080", WantErr: shortener.ErrURLBlocked},
+		{Name: "Localhost", URL: "http://localhost/admin", WantErr: shortener.ErrURLBlocked},
+		{Name: "PrivateNetwork", URL: "http://192.168.0.1", WantErr: shortener.ErrURLBlocked},
+		{Name: "LinkLocal", URL: "http://169.254.169.254/latest/meta-data", WantErr: shortener.ErrURLBlocked},
+		{Name: "Unspecified", URL: "http://0.0.0.0", WantErr: shortener.ErrURLBlocked},
+		{Name: "IPv6Loopback", URL: "http://[::1]/", WantErr: shortener.ErrURLBlocked},
+		{Name: "IPv6Private", URL: "http://[fd00::1]/", WantErr: shortener.ErrURLBlocked},
+		{Name: "IPv4MappedIPv6", URL: "http://[::ffff:10.0.0.1]/", WantErr: shortener.ErrURLBlocked},
+		{Name: "DecimalLoopback", URL: "http://2130706433/", WantErr: shortener.ErrURLBlocked},
+		{Name: "ShortLoopback", URL: "http://127.1/", WantErr: shortener.ErrURLBlocked},
+		{Name: "HexLoopback", URL: "http://0x7f000001/", WantErr: shortener.ErrURLBlocked},
+		{Name: "OctalLoopback", URL: "http://0177.0.0.1/", WantErr: shortener.ErrURLBlocked},
+		{Name: "MixedPrivateNetwork", URL: "http://0xc0.168.1/", WantErr: shortener.ErrURLBlocked},
+		{Name: "DecimalPublicIP", URL: "http://134744072/"},
+		{Name: "NumericOutOfRange", URL: "http://256.0.0.1/"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := policy.Check(tc.URL)
+			if !errors.Is(err, tc.WantErr) || (tc.WantErr == nil) != (err == nil) {
+				t.Errorf("Wrong error (want, got): (%v, %v)", tc.WantErr, err)
+			}
+		})
+	}
+}
+
+func TestURLPolicyOptions(t *testing.T) {
+	t.Run("AllowedDomains", func(t *testing.T) {
+		policy := shortener.NewURLPolicy(shortener.URLPolicyOptions{
+			AllowedDomains: []string{"example.com"},
+			BlockedDomains: []string{"private.example.com"},
+		})
+
+		if err := policy.Check("https://docs.example.com"); err != nil {
+			t.Errorf("Expected subdomain of allowed domain to be allowed, but got: %v", err)
+		}
+
+		if err := policy.Check("https://www.google.com"); !errors.Is(err, shortener.ErrURLBlocked) {
+			t.Errorf("Expected domain out of the allowlist to be blocked, but got: %v", err)
+		}
+
+		if err := policy.Check("https://private.example.com"); !errors.Is(err, shortener.ErrURLBlocked) {
+			t.Errorf("Expected blocked domain to be blocked even if allowed, but got: %v", err)
+		}
+	})
+
+	t.Run("AllowedSchemes", func(t *testing.T) {
+		policy := shortener.NewURLPolicy(shortener.URLPolicyOptions{
+			AllowedSchemes: []string{"https"},
+		})
+
+		if err := policy.Check("http://www.google.com"); !errors.Is(err, shortener.ErrURLBlocked) {
+			t.Errorf("Expected scheme out of the allowlist to be blocked, but got: %v", err)
+		}
+	})
+
+	t.Run("Resolver", func(t *testing.T) {
+		policy := shortener.NewURLPolicy(shortener.URLPolicyOptions{
+			Resolver: fakeResolver{
+				"localtest.me":   {"127.0.0.1"},
+				"mixed.example":  {"8.8.8.8", "10.0.0.1"},
+				"public.example": {"8.8.8.8", "2001:4860:4860::8888"},
+			},
+		})
+
+		for _, host := range []string{"localtest.me", "mixed.example"} {
+			if err := policy.Check("http://" + host); !errors.Is(err, shortener.ErrURLBlocked) {
+				t.Errorf("Expected %s, resolving to a private address, to be blocked, but got: %v", host, err)
+			}
+		}
+
+		if err := policy.Check("https://public.example/path"); err != nil {
+			t.Errorf("Expected host resolving to public addresses to be allowed, but got: %v", err)
+		}
+
+		if err := policy.Check("https://unknown.example"); err != nil {
+			t.Errorf("Expected host that can't be resolved to be allowed, but got: %v", err)
+		}
+	})
+
+	t.Run("AllowPrivateIPs", func(t *testing.T) {
+		policy := shortener.NewURLPolicy(shortener.URLPolicyOptions{
+			AllowPrivateIPs: true,
+		})
+
+		if err := policy.Check("http://10.0.0.1/intranet"); err != nil {
+			t.Errorf("Expected private address to be allowed, but got: %v", err)
+		}
+	})
+}